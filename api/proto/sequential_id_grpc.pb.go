@@ -36,6 +36,44 @@ type SequentialIDServiceClient interface {
 	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error)
 	// Update configuration for a prefix
 	UpdateConfig(ctx context.Context, in *UpdateConfigRequest, opts ...grpc.CallOption) (*UpdateConfigResponse, error)
+	// Query audit log entries with filters and cursor pagination
+	QueryAuditLogs(ctx context.Context, in *QueryAuditLogsRequest, opts ...grpc.CallOption) (*QueryAuditLogsResponse, error)
+	// Look up an issued ID by its full number
+	LookupID(ctx context.Context, in *LookupIDRequest, opts ...grpc.CallOption) (*LookupIDResponse, error)
+	// Reserve a provisional number of a gapless prefix
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error)
+	// Commit a reserved number, issuing it
+	CommitReservation(ctx context.Context, in *CommitReservationRequest, opts ...grpc.CallOption) (*CommitReservationResponse, error)
+	// Release a reserved number for reuse
+	ReleaseReservation(ctx context.Context, in *ReleaseReservationRequest, opts ...grpc.CallOption) (*ReleaseReservationResponse, error)
+	// List recorded configuration changes of a prefix
+	GetConfigHistory(ctx context.Context, in *GetConfigHistoryRequest, opts ...grpc.CallOption) (*GetConfigHistoryResponse, error)
+	// Restore a previous configuration version of a prefix
+	RollbackConfig(ctx context.Context, in *RollbackConfigRequest, opts ...grpc.CallOption) (*RollbackConfigResponse, error)
+	// List configured prefixes with their counters
+	ListPrefixes(ctx context.Context, in *ListPrefixesRequest, opts ...grpc.CallOption) (*ListPrefixesResponse, error)
+	// Stop a prefix from issuing new IDs
+	ArchivePrefix(ctx context.Context, in *ArchivePrefixRequest, opts ...grpc.CallOption) (*ArchivePrefixResponse, error)
+	// Let an archived prefix issue IDs again
+	UnarchivePrefix(ctx context.Context, in *UnarchivePrefixRequest, opts ...grpc.CallOption) (*UnarchivePrefixResponse, error)
+	// Delete the configuration of a prefix
+	DeletePrefix(ctx context.Context, in *DeletePrefixRequest, opts ...grpc.CallOption) (*DeletePrefixResponse, error)
+	// Render sample IDs for a proposed format template
+	PreviewFormat(ctx context.Context, in *PreviewFormatRequest, opts ...grpc.CallOption) (*PreviewFormatResponse, error)
+	// List counter resets of a prefix
+	GetResetHistory(ctx context.Context, in *GetResetHistoryRequest, opts ...grpc.CallOption) (*GetResetHistoryResponse, error)
+	// Compare counters against the audit log and optionally backfill gaps
+	Reconcile(ctx context.Context, in *ReconcileRequest, opts ...grpc.CallOption) (*ReconcileResponse, error)
+	// List audit events in the dead letter queue
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error)
+	// Publish dead-lettered audit events back to the worker
+	ReplayDeadLetters(ctx context.Context, in *DeadLettersRequest, opts ...grpc.CallOption) (*DeadLettersResponse, error)
+	// Discard dead-lettered audit events
+	PurgeDeadLetters(ctx context.Context, in *DeadLettersRequest, opts ...grpc.CallOption) (*DeadLettersResponse, error)
+	// Stream the ID ranges allocated for a single demand
+	StreamNext(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (SequentialIDService_StreamNextClient, error)
+	// Allocate ID ranges for a stream of demands, answered in order
+	AllocateRanges(ctx context.Context, opts ...grpc.CallOption) (SequentialIDService_AllocateRangesClient, error)
 }
 
 type sequentialIDServiceClient struct {
@@ -109,6 +147,222 @@ func (c *sequentialIDServiceClient) UpdateConfig(ctx context.Context, in *Update
 	return out, nil
 }
 
+func (c *sequentialIDServiceClient) QueryAuditLogs(ctx context.Context, in *QueryAuditLogsRequest, opts ...grpc.CallOption) (*QueryAuditLogsResponse, error) {
+	out := new(QueryAuditLogsResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/QueryAuditLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) LookupID(ctx context.Context, in *LookupIDRequest, opts ...grpc.CallOption) (*LookupIDResponse, error) {
+	out := new(LookupIDResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/LookupID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error) {
+	out := new(ReserveResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/Reserve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) CommitReservation(ctx context.Context, in *CommitReservationRequest, opts ...grpc.CallOption) (*CommitReservationResponse, error) {
+	out := new(CommitReservationResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/CommitReservation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) ReleaseReservation(ctx context.Context, in *ReleaseReservationRequest, opts ...grpc.CallOption) (*ReleaseReservationResponse, error) {
+	out := new(ReleaseReservationResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/ReleaseReservation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) GetConfigHistory(ctx context.Context, in *GetConfigHistoryRequest, opts ...grpc.CallOption) (*GetConfigHistoryResponse, error) {
+	out := new(GetConfigHistoryResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/GetConfigHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) RollbackConfig(ctx context.Context, in *RollbackConfigRequest, opts ...grpc.CallOption) (*RollbackConfigResponse, error) {
+	out := new(RollbackConfigResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/RollbackConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) ListPrefixes(ctx context.Context, in *ListPrefixesRequest, opts ...grpc.CallOption) (*ListPrefixesResponse, error) {
+	out := new(ListPrefixesResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/ListPrefixes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) ArchivePrefix(ctx context.Context, in *ArchivePrefixRequest, opts ...grpc.CallOption) (*ArchivePrefixResponse, error) {
+	out := new(ArchivePrefixResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/ArchivePrefix", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) UnarchivePrefix(ctx context.Context, in *UnarchivePrefixRequest, opts ...grpc.CallOption) (*UnarchivePrefixResponse, error) {
+	out := new(UnarchivePrefixResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/UnarchivePrefix", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) DeletePrefix(ctx context.Context, in *DeletePrefixRequest, opts ...grpc.CallOption) (*DeletePrefixResponse, error) {
+	out := new(DeletePrefixResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/DeletePrefix", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) PreviewFormat(ctx context.Context, in *PreviewFormatRequest, opts ...grpc.CallOption) (*PreviewFormatResponse, error) {
+	out := new(PreviewFormatResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/PreviewFormat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) GetResetHistory(ctx context.Context, in *GetResetHistoryRequest, opts ...grpc.CallOption) (*GetResetHistoryResponse, error) {
+	out := new(GetResetHistoryResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/GetResetHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) Reconcile(ctx context.Context, in *ReconcileRequest, opts ...grpc.CallOption) (*ReconcileResponse, error) {
+	out := new(ReconcileResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/Reconcile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error) {
+	out := new(ListDeadLettersResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/ListDeadLetters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) ReplayDeadLetters(ctx context.Context, in *DeadLettersRequest, opts ...grpc.CallOption) (*DeadLettersResponse, error) {
+	out := new(DeadLettersResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/ReplayDeadLetters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) PurgeDeadLetters(ctx context.Context, in *DeadLettersRequest, opts ...grpc.CallOption) (*DeadLettersResponse, error) {
+	out := new(DeadLettersResponse)
+	err := c.cc.Invoke(ctx, "/sequentialid.SequentialIDService/PurgeDeadLetters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequentialIDServiceClient) StreamNext(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (SequentialIDService_StreamNextClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SequentialIDService_ServiceDesc.Streams[0], "/sequentialid.SequentialIDService/StreamNext", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sequentialIDServiceStreamNextClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SequentialIDService_StreamNextClient interface {
+	Recv() (*AllocatedRange, error)
+	grpc.ClientStream
+}
+
+type sequentialIDServiceStreamNextClient struct {
+	grpc.ClientStream
+}
+
+func (x *sequentialIDServiceStreamNextClient) Recv() (*AllocatedRange, error) {
+	m := new(AllocatedRange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sequentialIDServiceClient) AllocateRanges(ctx context.Context, opts ...grpc.CallOption) (SequentialIDService_AllocateRangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SequentialIDService_ServiceDesc.Streams[1], "/sequentialid.SequentialIDService/AllocateRanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sequentialIDServiceAllocateRangesClient{stream}
+	return x, nil
+}
+
+type SequentialIDService_AllocateRangesClient interface {
+	Send(*AllocateRequest) error
+	Recv() (*AllocatedRange, error)
+	grpc.ClientStream
+}
+
+type sequentialIDServiceAllocateRangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *sequentialIDServiceAllocateRangesClient) Send(m *AllocateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *sequentialIDServiceAllocateRangesClient) Recv() (*AllocatedRange, error) {
+	m := new(AllocatedRange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SequentialIDServiceServer is the server API for SequentialIDService service.
 // All implementations must embed UnimplementedSequentialIDServiceServer
 // for forward compatibility
@@ -127,6 +381,44 @@ type SequentialIDServiceServer interface {
 	GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error)
 	// Update configuration for a prefix
 	UpdateConfig(context.Context, *UpdateConfigRequest) (*UpdateConfigResponse, error)
+	// Query audit log entries with filters and cursor pagination
+	QueryAuditLogs(context.Context, *QueryAuditLogsRequest) (*QueryAuditLogsResponse, error)
+	// Look up an issued ID by its full number
+	LookupID(context.Context, *LookupIDRequest) (*LookupIDResponse, error)
+	// Reserve a provisional number of a gapless prefix
+	Reserve(context.Context, *ReserveRequest) (*ReserveResponse, error)
+	// Commit a reserved number, issuing it
+	CommitReservation(context.Context, *CommitReservationRequest) (*CommitReservationResponse, error)
+	// Release a reserved number for reuse
+	ReleaseReservation(context.Context, *ReleaseReservationRequest) (*ReleaseReservationResponse, error)
+	// List recorded configuration changes of a prefix
+	GetConfigHistory(context.Context, *GetConfigHistoryRequest) (*GetConfigHistoryResponse, error)
+	// Restore a previous configuration version of a prefix
+	RollbackConfig(context.Context, *RollbackConfigRequest) (*RollbackConfigResponse, error)
+	// List configured prefixes with their counters
+	ListPrefixes(context.Context, *ListPrefixesRequest) (*ListPrefixesResponse, error)
+	// Stop a prefix from issuing new IDs
+	ArchivePrefix(context.Context, *ArchivePrefixRequest) (*ArchivePrefixResponse, error)
+	// Let an archived prefix issue IDs again
+	UnarchivePrefix(context.Context, *UnarchivePrefixRequest) (*UnarchivePrefixResponse, error)
+	// Delete the configuration of a prefix
+	DeletePrefix(context.Context, *DeletePrefixRequest) (*DeletePrefixResponse, error)
+	// Render sample IDs for a proposed format template
+	PreviewFormat(context.Context, *PreviewFormatRequest) (*PreviewFormatResponse, error)
+	// List counter resets of a prefix
+	GetResetHistory(context.Context, *GetResetHistoryRequest) (*GetResetHistoryResponse, error)
+	// Compare counters against the audit log and optionally backfill gaps
+	Reconcile(context.Context, *ReconcileRequest) (*ReconcileResponse, error)
+	// List audit events in the dead letter queue
+	ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListDeadLettersResponse, error)
+	// Publish dead-lettered audit events back to the worker
+	ReplayDeadLetters(context.Context, *DeadLettersRequest) (*DeadLettersResponse, error)
+	// Discard dead-lettered audit events
+	PurgeDeadLetters(context.Context, *DeadLettersRequest) (*DeadLettersResponse, error)
+	// Stream the ID ranges allocated for a single demand
+	StreamNext(*AllocateRequest, SequentialIDService_StreamNextServer) error
+	// Allocate ID ranges for a stream of demands, answered in order
+	AllocateRanges(SequentialIDService_AllocateRangesServer) error
 	mustEmbedUnimplementedSequentialIDServiceServer()
 }
 
@@ -155,6 +447,63 @@ func (UnimplementedSequentialIDServiceServer) GetConfig(context.Context, *GetCon
 func (UnimplementedSequentialIDServiceServer) UpdateConfig(context.Context, *UpdateConfigRequest) (*UpdateConfigResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateConfig not implemented")
 }
+func (UnimplementedSequentialIDServiceServer) QueryAuditLogs(context.Context, *QueryAuditLogsRequest) (*QueryAuditLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryAuditLogs not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) LookupID(context.Context, *LookupIDRequest) (*LookupIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupID not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) Reserve(context.Context, *ReserveRequest) (*ReserveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reserve not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) CommitReservation(context.Context, *CommitReservationRequest) (*CommitReservationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitReservation not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) ReleaseReservation(context.Context, *ReleaseReservationRequest) (*ReleaseReservationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseReservation not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) GetConfigHistory(context.Context, *GetConfigHistoryRequest) (*GetConfigHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfigHistory not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) RollbackConfig(context.Context, *RollbackConfigRequest) (*RollbackConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackConfig not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) ListPrefixes(context.Context, *ListPrefixesRequest) (*ListPrefixesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPrefixes not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) ArchivePrefix(context.Context, *ArchivePrefixRequest) (*ArchivePrefixResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchivePrefix not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) UnarchivePrefix(context.Context, *UnarchivePrefixRequest) (*UnarchivePrefixResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnarchivePrefix not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) DeletePrefix(context.Context, *DeletePrefixRequest) (*DeletePrefixResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePrefix not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) PreviewFormat(context.Context, *PreviewFormatRequest) (*PreviewFormatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewFormat not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) GetResetHistory(context.Context, *GetResetHistoryRequest) (*GetResetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResetHistory not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) Reconcile(context.Context, *ReconcileRequest) (*ReconcileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconcile not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadLetters not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) ReplayDeadLetters(context.Context, *DeadLettersRequest) (*DeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayDeadLetters not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) PurgeDeadLetters(context.Context, *DeadLettersRequest) (*DeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeDeadLetters not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) StreamNext(*AllocateRequest, SequentialIDService_StreamNextServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamNext not implemented")
+}
+func (UnimplementedSequentialIDServiceServer) AllocateRanges(SequentialIDService_AllocateRangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method AllocateRanges not implemented")
+}
 func (UnimplementedSequentialIDServiceServer) mustEmbedUnimplementedSequentialIDServiceServer() {}
 
 // UnsafeSequentialIDServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -294,6 +643,359 @@ func _SequentialIDService_UpdateConfig_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SequentialIDService_QueryAuditLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryAuditLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).QueryAuditLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/QueryAuditLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).QueryAuditLogs(ctx, req.(*QueryAuditLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_LookupID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).LookupID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/LookupID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).LookupID(ctx, req.(*LookupIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).Reserve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/Reserve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).Reserve(ctx, req.(*ReserveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_CommitReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).CommitReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/CommitReservation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).CommitReservation(ctx, req.(*CommitReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_ReleaseReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).ReleaseReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/ReleaseReservation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).ReleaseReservation(ctx, req.(*ReleaseReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_GetConfigHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).GetConfigHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/GetConfigHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).GetConfigHistory(ctx, req.(*GetConfigHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_RollbackConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).RollbackConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/RollbackConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).RollbackConfig(ctx, req.(*RollbackConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_ListPrefixes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPrefixesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).ListPrefixes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/ListPrefixes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).ListPrefixes(ctx, req.(*ListPrefixesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_ArchivePrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArchivePrefixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).ArchivePrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/ArchivePrefix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).ArchivePrefix(ctx, req.(*ArchivePrefixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_UnarchivePrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnarchivePrefixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).UnarchivePrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/UnarchivePrefix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).UnarchivePrefix(ctx, req.(*UnarchivePrefixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_DeletePrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePrefixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).DeletePrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/DeletePrefix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).DeletePrefix(ctx, req.(*DeletePrefixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_PreviewFormat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewFormatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).PreviewFormat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/PreviewFormat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).PreviewFormat(ctx, req.(*PreviewFormatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_GetResetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).GetResetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/GetResetHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).GetResetHistory(ctx, req.(*GetResetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_Reconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).Reconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/Reconcile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).Reconcile(ctx, req.(*ReconcileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/ListDeadLetters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).ListDeadLetters(ctx, req.(*ListDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_ReplayDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).ReplayDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/ReplayDeadLetters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).ReplayDeadLetters(ctx, req.(*DeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_PurgeDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequentialIDServiceServer).PurgeDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sequentialid.SequentialIDService/PurgeDeadLetters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequentialIDServiceServer).PurgeDeadLetters(ctx, req.(*DeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SequentialIDService_StreamNext_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AllocateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SequentialIDServiceServer).StreamNext(m, &sequentialIDServiceStreamNextServer{stream})
+}
+
+type SequentialIDService_StreamNextServer interface {
+	Send(*AllocatedRange) error
+	grpc.ServerStream
+}
+
+type sequentialIDServiceStreamNextServer struct {
+	grpc.ServerStream
+}
+
+func (x *sequentialIDServiceStreamNextServer) Send(m *AllocatedRange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SequentialIDService_AllocateRanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SequentialIDServiceServer).AllocateRanges(&sequentialIDServiceAllocateRangesServer{stream})
+}
+
+type SequentialIDService_AllocateRangesServer interface {
+	Send(*AllocatedRange) error
+	Recv() (*AllocateRequest, error)
+	grpc.ServerStream
+}
+
+type sequentialIDServiceAllocateRangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *sequentialIDServiceAllocateRangesServer) Send(m *AllocatedRange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *sequentialIDServiceAllocateRangesServer) Recv() (*AllocateRequest, error) {
+	m := new(AllocateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SequentialIDService_ServiceDesc is the grpc.ServiceDesc for SequentialIDService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -329,7 +1031,87 @@ var SequentialIDService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateConfig",
 			Handler:    _SequentialIDService_UpdateConfig_Handler,
 		},
+		{
+			MethodName: "QueryAuditLogs",
+			Handler:    _SequentialIDService_QueryAuditLogs_Handler,
+		},
+		{
+			MethodName: "LookupID",
+			Handler:    _SequentialIDService_LookupID_Handler,
+		},
+		{
+			MethodName: "Reserve",
+			Handler:    _SequentialIDService_Reserve_Handler,
+		},
+		{
+			MethodName: "CommitReservation",
+			Handler:    _SequentialIDService_CommitReservation_Handler,
+		},
+		{
+			MethodName: "ReleaseReservation",
+			Handler:    _SequentialIDService_ReleaseReservation_Handler,
+		},
+		{
+			MethodName: "GetConfigHistory",
+			Handler:    _SequentialIDService_GetConfigHistory_Handler,
+		},
+		{
+			MethodName: "RollbackConfig",
+			Handler:    _SequentialIDService_RollbackConfig_Handler,
+		},
+		{
+			MethodName: "ListPrefixes",
+			Handler:    _SequentialIDService_ListPrefixes_Handler,
+		},
+		{
+			MethodName: "ArchivePrefix",
+			Handler:    _SequentialIDService_ArchivePrefix_Handler,
+		},
+		{
+			MethodName: "UnarchivePrefix",
+			Handler:    _SequentialIDService_UnarchivePrefix_Handler,
+		},
+		{
+			MethodName: "DeletePrefix",
+			Handler:    _SequentialIDService_DeletePrefix_Handler,
+		},
+		{
+			MethodName: "PreviewFormat",
+			Handler:    _SequentialIDService_PreviewFormat_Handler,
+		},
+		{
+			MethodName: "GetResetHistory",
+			Handler:    _SequentialIDService_GetResetHistory_Handler,
+		},
+		{
+			MethodName: "Reconcile",
+			Handler:    _SequentialIDService_Reconcile_Handler,
+		},
+		{
+			MethodName: "ListDeadLetters",
+			Handler:    _SequentialIDService_ListDeadLetters_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetters",
+			Handler:    _SequentialIDService_ReplayDeadLetters_Handler,
+		},
+		{
+			MethodName: "PurgeDeadLetters",
+			Handler:    _SequentialIDService_PurgeDeadLetters_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamNext",
+			Handler:       _SequentialIDService_StreamNext_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "AllocateRanges",
+			Handler:       _SequentialIDService_AllocateRanges_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/sequential_id.proto",
 }
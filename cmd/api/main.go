@@ -11,14 +11,18 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	pb "github.com/putram11/sequential-id-counter-service/api/proto"
 	"github.com/putram11/sequential-id-counter-service/internal/api/grpc"
 	"github.com/putram11/sequential-id-counter-service/internal/api/rest"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
 	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 	"github.com/putram11/sequential-id-counter-service/internal/repository"
 	"github.com/putram11/sequential-id-counter-service/internal/service"
 	"github.com/sirupsen/logrus"
 	grpc_server "google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -40,18 +44,18 @@ func main() {
 	logger.Info("Starting Sequential ID Counter Service")
 
 	// Initialize repositories
-	redisRepo, err := repository.NewRedisRepository(cfg.Redis)
-	if err != nil {
-		logger.Fatalf("Failed to initialize Redis repository: %v", err)
-	}
-	defer redisRepo.Close()
-
 	dbRepo, err := repository.NewPostgresRepository(cfg.Database)
 	if err != nil {
 		logger.Fatalf("Failed to initialize database repository: %v", err)
 	}
 	defer dbRepo.Close()
 
+	counters, err := repository.NewCounterBackend(cfg, dbRepo)
+	if err != nil {
+		logger.Fatalf("Failed to initialize %s counter backend: %v", cfg.Counter.Backend, err)
+	}
+	defer counters.Close()
+
 	rabbitRepo, err := repository.NewRabbitMQRepository(cfg.RabbitMQ)
 	if err != nil {
 		logger.Fatalf("Failed to initialize RabbitMQ repository: %v", err)
@@ -60,27 +64,66 @@ func main() {
 
 	// Initialize service
 	seqService := service.NewSequentialIDService(
-		redisRepo,
+		counters,
 		dbRepo,
 		rabbitRepo,
+		cfg,
 		logger,
 	)
 
-	// Sync Redis with database on startup
+	// Sync the counter store with the database on startup
 	if err := seqService.SyncCountersOnStartup(context.Background()); err != nil {
 		logger.Errorf("Failed to sync counters on startup: %v", err)
-		// Continue anyway - service can still work with Redis
+		// Continue anyway - service can still work with the counter store
 	}
 
+	// Initialize authentication shared by REST and gRPC
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		logger.Fatalf("Failed to initialize authentication: %v", err)
+	}
+	if authenticator == nil {
+		logger.Warn("Authentication is disabled; admin endpoints are open to every caller")
+	}
+
+	// Export queue depth and connection pool statistics at scrape time
+	prometheus.MustRegister(
+		metrics.NewQueueCollector(rabbitRepo),
+		metrics.NewDBStatsCollector(dbRepo.GetStats),
+		metrics.NewOutboxCollector(dbRepo),
+	)
+
 	// Create context for graceful shutdown
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Drop cached prefix configs when any instance changes them, and leased
+	// counter blocks when any instance resets a counter
+	configListener := repository.NewConfigListener(cfg.Database)
+	defer configListener.Close()
+	onReconnect := func() {
+		seqService.InvalidateAllConfigs()
+		seqService.DiscardAllBlocks()
+	}
+	go func() {
+		if err := configListener.Run(ctx, seqService.InvalidateConfig, seqService.DiscardBlocks, onReconnect); err != nil && err != context.Canceled {
+			logger.Errorf("Config change listener stopped: %v", err)
+		}
+	}()
+
+	// Start the relay publishing audit events from the outbox
+	outboxRelay := service.NewOutboxRelay(dbRepo, rabbitRepo, cfg.Outbox, logger)
+	go func() {
+		if err := outboxRelay.Run(ctx); err != nil && err != context.Canceled {
+			logger.Errorf("Outbox relay stopped: %v", err)
+		}
+	}()
+
 	// Start REST API server
 	restHandler := rest.NewHandler(seqService, logger)
 	restServer := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.Port),
-		Handler: setupGinRouter(restHandler),
+		Handler: setupGinRouter(restHandler, rest.NewAuthMiddleware(authenticator, logger)),
 	}
 
 	go func() {
@@ -92,11 +135,24 @@ func main() {
 
 	// Start gRPC server
 	grpcHandler := grpc.NewServer(seqService, logger)
-	grpcServer := grpc_server.NewServer()
+	authInterceptor := grpc.NewAuthInterceptor(authenticator, logger)
+	grpcServer := grpc_server.NewServer(
+		grpc_server.ChainUnaryInterceptor(authInterceptor.Unary()),
+		grpc_server.ChainStreamInterceptor(authInterceptor.Stream()),
+	)
 
 	// Register our service with the gRPC server
 	pb.RegisterSequentialIDServiceServer(grpcServer, grpcHandler)
 
+	// Standard health service, fed by the component checks
+	grpcHealth := grpc.NewHealthReporter(seqService, cfg.HealthInterval, logger)
+	grpcHealth.Register(grpcServer)
+	go grpcHealth.Run(ctx)
+
+	if cfg.GRPCReflection {
+		reflection.Register(grpcServer)
+	}
+
 	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
 	if err != nil {
 		logger.Fatalf("Failed to listen on gRPC port: %v", err)
@@ -122,12 +178,23 @@ func main() {
 		}
 	}()
 
+	// Start metrics server
+	metricsServer := metrics.NewServer(cfg.MetricsPort)
+
+	go func() {
+		logger.Infof("Starting metrics server on port %s", cfg.MetricsPort)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Metrics server error: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
 	logger.Info("Shutting down gracefully...")
+	cancel()
 
 	// Graceful shutdown with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -138,18 +205,29 @@ func main() {
 		logger.Errorf("Failed to shutdown REST server: %v", err)
 	}
 
-	// Shutdown gRPC server
+	// Shutdown gRPC server, reporting NOT_SERVING first
+	grpcHealth.Shutdown()
 	grpcServer.GracefulStop()
 
+	// Give back counter values leased in blocks but never handed out
+	if err := seqService.ReleaseBlocks(shutdownCtx); err != nil {
+		logger.Errorf("Failed to release counter blocks: %v", err)
+	}
+
 	// Shutdown health server
 	if err := healthServer.Shutdown(shutdownCtx); err != nil {
 		logger.Errorf("Failed to shutdown health server: %v", err)
 	}
 
+	// Shutdown metrics server
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Failed to shutdown metrics server: %v", err)
+	}
+
 	logger.Info("Server stopped")
 }
 
-func setupGinRouter(handler *rest.Handler) *gin.Engine {
+func setupGinRouter(handler *rest.Handler, authz *rest.AuthMiddleware) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 
@@ -159,12 +237,32 @@ func setupGinRouter(handler *rest.Handler) *gin.Engine {
 
 	// API routes
 	v1 := router.Group("/api/v1")
+	v1.Use(authz.Authenticate())
 	{
-		v1.GET("/next/:prefix", handler.GetNext)
-		v1.GET("/status/:prefix", handler.GetStatus)
-		v1.POST("/reset/:prefix", handler.ResetCounter)
-		v1.GET("/config/:prefix", handler.GetConfig)
-		v1.POST("/config/:prefix", handler.UpdateConfig)
+		v1.GET("/next/:prefix", authz.Require(auth.RoleGenerator), handler.GetNext)
+		v1.POST("/batch/:prefix", authz.Require(auth.RoleGenerator), handler.GetNextBatch)
+		v1.POST("/reserve/:prefix", authz.Require(auth.RoleGenerator), handler.Reserve)
+		v1.POST("/reservations/:reservation_id/commit", authz.Require(auth.RoleGenerator), handler.CommitReservation)
+		v1.POST("/reservations/:reservation_id/release", authz.Require(auth.RoleGenerator), handler.ReleaseReservation)
+		v1.GET("/status/:prefix", authz.Require(auth.RoleGenerator, auth.RoleAuditor), handler.GetStatus)
+		v1.POST("/reset/:prefix", authz.Require(auth.RoleAdmin), handler.ResetCounter)
+		v1.GET("/reset/:prefix/history", authz.Require(auth.RoleAuditor), handler.GetResetHistory)
+		v1.GET("/config/:prefix", authz.Require(auth.RoleGenerator, auth.RoleAuditor), handler.GetConfig)
+		v1.POST("/config/:prefix", authz.Require(auth.RoleAdmin), handler.UpdateConfig)
+		v1.POST("/config/:prefix/preview", authz.Require(auth.RoleAdmin), handler.PreviewFormat)
+		v1.GET("/config/:prefix/history", authz.Require(auth.RoleAuditor), handler.GetConfigHistory)
+		v1.POST("/config/:prefix/rollback", authz.Require(auth.RoleAdmin), handler.RollbackConfig)
+		v1.GET("/prefixes", authz.Require(auth.RoleGenerator, auth.RoleAuditor), handler.ListPrefixes)
+		v1.POST("/prefixes/:prefix/archive", authz.Require(auth.RoleAdmin), handler.ArchivePrefix)
+		v1.POST("/prefixes/:prefix/unarchive", authz.Require(auth.RoleAdmin), handler.UnarchivePrefix)
+		v1.DELETE("/prefixes/:prefix", authz.Require(auth.RoleAdmin), handler.DeletePrefix)
+		v1.GET("/audit", authz.Require(auth.RoleAuditor), handler.GetAuditLogs)
+		v1.GET("/audit/:prefix", authz.Require(auth.RoleAuditor), handler.GetAuditLogs)
+		v1.GET("/ids/:full_number", authz.Require(auth.RoleAuditor), handler.LookupID)
+		v1.POST("/reconcile", authz.Require(auth.RoleAdmin), handler.Reconcile)
+		v1.GET("/dlq", authz.Require(auth.RoleAdmin), handler.ListDeadLetters)
+		v1.POST("/dlq/replay", authz.Require(auth.RoleAdmin), handler.ReplayDeadLetters)
+		v1.POST("/dlq/purge", authz.Require(auth.RoleAdmin), handler.PurgeDeadLetters)
 	}
 
 	return router
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `usage: dlq <command> [flags]
+
+Inspect and handle audit events the worker moved to the dead letter queue.
+
+commands:
+  list    list dead letters with the error that stopped each
+  replay  publish dead letters back to the main exchange
+  purge   discard dead letters (requires --reason)
+
+Run "dlq <command> -h" for the flags of a command.
+`
+
+// dlq lists, replays and purges the messages of the dead letter queue.
+// Replays and purges are recorded in seq_dlq_audit. The result is written
+// to stdout as JSON.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	tenant := flags.String("tenant", "", "tenant of the dead letters (default: all tenants)")
+	limit := flags.Int("limit", service.DefaultDeadLetterLimit, "number of queued messages to inspect")
+	var (
+		ids       *string
+		all       *bool
+		reason    *string
+		adminUser *string
+	)
+	switch command {
+	case "list":
+	case "replay", "purge":
+		ids = flags.String("id", "", "comma-separated message IDs to "+command)
+		all = flags.Bool("all", false, command+" every inspected message")
+		reason = flags.String("reason", "", "reason recorded in the audit log")
+		adminUser = flags.String("admin-user", currentUser(), "operator recorded in the audit log")
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	flags.Parse(os.Args[2:])
+
+	// Logs go to stderr so the JSON result on stdout stays parseable
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stderr)
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Set log level
+	if level, err := logrus.ParseLevel(cfg.LogLevel); err == nil {
+		logger.SetLevel(level)
+	}
+
+	// Initialize repositories
+	dbRepo, err := repository.NewPostgresRepository(cfg.Database)
+	if err != nil {
+		logger.Fatalf("Failed to initialize database repository: %v", err)
+	}
+	defer dbRepo.Close()
+
+	rabbitRepo, err := repository.NewRabbitMQRepository(cfg.RabbitMQ)
+	if err != nil {
+		logger.Fatalf("Failed to initialize RabbitMQ repository: %v", err)
+	}
+	defer rabbitRepo.Close()
+
+	manager := service.NewDeadLetterManager(rabbitRepo, dbRepo, logger)
+	ctx := context.Background()
+
+	var result interface{}
+	if command == "list" {
+		result, err = manager.List(ctx, &models.DeadLetterQuery{Tenant: *tenant, Limit: *limit})
+	} else {
+		req := &models.DeadLetterRequest{
+			Tenant:    *tenant,
+			All:       *all,
+			Limit:     *limit,
+			Reason:    *reason,
+			AdminUser: *adminUser,
+		}
+		for _, id := range strings.Split(*ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				req.MessageIDs = append(req.MessageIDs, id)
+			}
+		}
+		if command == "replay" {
+			result, err = manager.Replay(ctx, req)
+		} else {
+			result, err = manager.Purge(ctx, req)
+		}
+	}
+	if err != nil {
+		logger.Fatalf("Failed to %s dead letters: %v", command, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		logger.Fatalf("Failed to write result: %v", err)
+	}
+}
+
+// currentUser returns the login name of the operator running the command
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// reconcile compares the stored counters and checkpoints against seq_log and
+// reports counter values that were issued but never audited. With
+// --fix-gaps it backfills "lost" placeholder entries for them.
+//
+// The process exits with status 2 when unaudited values remain after the run.
+func main() {
+	checkAll := flag.Bool("check-all-prefixes", false, "reconcile every configured prefix")
+	prefixes := flag.String("prefix", "", "comma-separated prefixes to reconcile")
+	tenant := flag.String("tenant", "", "tenant of the prefixes (default: all tenants, or \"default\" with --prefix)")
+	fixGaps := flag.Bool("fix-gaps", false, "backfill placeholder entries for missing counter values")
+	maxBackfill := flag.Int64("max-backfill", service.DefaultMaxBackfill, "maximum number of placeholders written per run")
+	flag.Parse()
+
+	// Logs go to stderr so the JSON report on stdout stays parseable
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stderr)
+
+	req := &models.ReconcileRequest{
+		Tenant:      *tenant,
+		FixGaps:     *fixGaps,
+		MaxBackfill: *maxBackfill,
+	}
+	for _, prefix := range strings.Split(*prefixes, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			req.Prefixes = append(req.Prefixes, prefix)
+		}
+	}
+	if *checkAll == (len(req.Prefixes) > 0) {
+		fmt.Fprintln(os.Stderr, "exactly one of --check-all-prefixes or --prefix is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Set log level
+	if level, err := logrus.ParseLevel(cfg.LogLevel); err == nil {
+		logger.SetLevel(level)
+	}
+
+	// Initialize repositories
+	dbRepo, err := repository.NewPostgresRepository(cfg.Database)
+	if err != nil {
+		logger.Fatalf("Failed to initialize database repository: %v", err)
+	}
+	defer dbRepo.Close()
+
+	counters, err := repository.NewCounterBackend(cfg, dbRepo)
+	if err != nil {
+		logger.Fatalf("Failed to initialize %s counter backend: %v", cfg.Counter.Backend, err)
+	}
+	defer counters.Close()
+
+	report, err := service.NewReconciler(counters, dbRepo, logger).Reconcile(context.Background(), req)
+	if err != nil {
+		logger.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logger.Fatalf("Failed to write report: %v", err)
+	}
+
+	if report.Missing > report.Backfilled {
+		counters.Close()
+		dbRepo.Close()
+		os.Exit(2)
+	}
+}
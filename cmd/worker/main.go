@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
 	"github.com/putram11/sequential-id-counter-service/internal/repository"
 	"github.com/sirupsen/logrus"
@@ -44,10 +47,27 @@ func main() {
 	}
 	defer rabbitRepo.Close()
 
+	// Export queue depth and connection pool statistics at scrape time
+	prometheus.MustRegister(
+		metrics.NewQueueCollector(rabbitRepo),
+		metrics.NewDBStatsCollector(dbRepo.GetStats),
+	)
+
+	// Start metrics server
+	metricsServer := metrics.NewServer(cfg.MetricsPort)
+
+	go func() {
+		logger.Infof("Starting metrics server on port %s", cfg.MetricsPort)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Metrics server error: %v", err)
+		}
+	}()
+
 	// Create worker
 	worker := &Worker{
 		dbRepo:     dbRepo,
 		rabbitRepo: rabbitRepo,
+		cfg:        cfg.Worker,
 		logger:     logger,
 	}
 
@@ -72,6 +92,14 @@ func main() {
 
 	// Give worker time to finish processing current messages
 	time.Sleep(5 * time.Second)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Failed to shutdown metrics server: %v", err)
+	}
+
 	logger.Info("Worker stopped")
 }
 
@@ -79,30 +107,42 @@ func main() {
 type Worker struct {
 	dbRepo     *repository.PostgresRepository
 	rabbitRepo *repository.RabbitMQRepository
+	cfg        config.WorkerConfig
 	logger     *logrus.Logger
 }
 
 // Start begins processing messages from the queue
 func (w *Worker) Start(ctx context.Context) error {
-	w.logger.Info("Worker started, waiting for messages")
+	w.logger.WithField("concurrency", w.cfg.Concurrency).Info("Worker started, waiting for messages")
 
-	// Define event handler
+	// Define event handler; it runs on cfg.Concurrency goroutines
 	handler := func(event *models.Event) error {
 		return w.processEvent(ctx, event)
 	}
 
 	// Start consuming events
-	return w.rabbitRepo.ConsumeEvents(ctx, handler)
+	return w.rabbitRepo.ConsumeEvents(ctx, w.cfg, handler)
 }
 
 // processEvent processes a single event and inserts it into the database
-func (w *Worker) processEvent(ctx context.Context, event *models.Event) error {
+func (w *Worker) processEvent(ctx context.Context, event *models.Event) (err error) {
 	startTime := time.Now()
+	defer func() {
+		metrics.WorkerEventDuration.WithLabelValues(metrics.Result(err)).Observe(time.Since(startTime).Seconds())
+	}()
+
+	// Events published before tenants were introduced carry none
+	tenant := event.Tenant
+	if tenant == "" {
+		tenant = models.DefaultTenant
+	}
 
 	// Create audit log entry
 	auditLog := &models.AuditLog{
+		Tenant:        tenant,
 		Prefix:        event.Prefix,
 		CounterValue:  event.Counter,
+		PeriodKey:     event.PeriodKey,
 		FullNumber:    event.FullNumber,
 		GeneratedBy:   &event.GeneratedBy,
 		ClientID:      &event.ClientID,
@@ -117,6 +157,7 @@ func (w *Worker) processEvent(ctx context.Context, event *models.Event) error {
 	if err := w.dbRepo.InsertAuditLog(ctx, auditLog); err != nil {
 		w.logger.WithError(err).WithFields(logrus.Fields{
 			"message_id":  event.MessageID,
+			"tenant":      tenant,
 			"prefix":      event.Prefix,
 			"counter":     event.Counter,
 			"full_number": event.FullNumber,
@@ -126,9 +167,11 @@ func (w *Worker) processEvent(ctx context.Context, event *models.Event) error {
 	}
 
 	processingTime := time.Since(startTime)
+	metrics.WorkerEventLag.Observe(time.Since(event.GeneratedAt).Seconds())
 
 	w.logger.WithFields(logrus.Fields{
 		"message_id":      event.MessageID,
+		"tenant":          tenant,
 		"prefix":          event.Prefix,
 		"counter":         event.Counter,
 		"full_number":     event.FullNumber,
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"errors"
+
+	pb "github.com/putram11/sequential-id-counter-service/api/proto"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// grpcCodes maps service error kinds to gRPC codes
+var grpcCodes = map[service.Kind]codes.Code{
+	service.KindInvalidArgument:  codes.InvalidArgument,
+	service.KindNotFound:         codes.NotFound,
+	service.KindConflict:         codes.FailedPrecondition,
+	service.KindExpired:          codes.FailedPrecondition,
+	service.KindUnprocessable:    codes.FailedPrecondition,
+	service.KindAborted:          codes.Aborted,
+	service.KindUnavailable:      codes.Unavailable,
+	service.KindPermissionDenied: codes.PermissionDenied,
+	service.KindCanceled:         codes.Canceled,
+	service.KindDeadlineExceeded: codes.DeadlineExceeded,
+	service.KindInternal:         codes.Internal,
+}
+
+// reasonUnauthenticated is the ErrorInfo reason of calls without valid
+// credentials
+const reasonUnauthenticated = "UNAUTHENTICATED"
+
+// toStatus converts a service error to a gRPC status whose ErrorInfo detail
+// carries the error code. Internal errors report the given message and
+// Unavailable errors only name the failing component, so connection
+// details stay in the logs.
+func toStatus(err error, internalMsg string) error {
+	svcErr := service.Classify(err)
+	code := grpcCodes[svcErr.Kind]
+
+	var parseErr *idformat.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return statusError(code, svcErr.Code, parseErr.Error(),
+			&errdetails.BadRequest_FieldViolation{Field: "format_template", Description: parseErr.Error()})
+	case svcErr.Kind == service.KindInternal:
+		return statusError(code, svcErr.Code, internalMsg)
+	case svcErr.Kind == service.KindUnavailable:
+		return statusError(code, svcErr.Code, svcErr.Error())
+	default:
+		return statusError(code, svcErr.Code, err.Error())
+	}
+}
+
+// invalidArgument reports a malformed request field with a BadRequest detail
+func invalidArgument(field, description string) error {
+	return statusError(codes.InvalidArgument, service.CodeInvalidArgument, description,
+		&errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// statusError builds a status with an ErrorInfo detail and, when fields
+// are given, a BadRequest detail listing them
+func statusError(code codes.Code, reason, msg string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(code, msg)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: pb.SequentialIDService_ServiceDesc.ServiceName,
+	}}
+	if len(violations) > 0 {
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	_, parseErr := idformat.Parse("{prefix}{bogus}")
+	if parseErr == nil {
+		t.Fatal("idformat.Parse accepted an unknown placeholder")
+	}
+	dbDown := errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		reason  string
+		message string
+	}{
+		{"invalid argument", fmt.Errorf("%w: count must be between 1 and 1000", service.ErrInvalidCount), codes.InvalidArgument, "INVALID_COUNT", "invalid count: count must be between 1 and 1000"},
+		{"format template", parseErr, codes.InvalidArgument, "INVALID_FORMAT_TEMPLATE", parseErr.Error()},
+		{"not found", fmt.Errorf("%w: SG", service.ErrPrefixNotFound), codes.NotFound, "PREFIX_NOT_CONFIGURED", "prefix not found: SG"},
+		{"conflict", fmt.Errorf("%w: SG", service.ErrPrefixExists), codes.FailedPrecondition, "PREFIX_EXISTS", "prefix already exists: SG"},
+		{"expired", service.ErrReservationExpired, codes.FailedPrecondition, "RESERVATION_EXPIRED", "reservation expired or released"},
+		{"aborted", service.ErrIdempotencyInProgress, codes.Aborted, service.ErrIdempotencyInProgress.Code, service.ErrIdempotencyInProgress.Error()},
+		{"permission denied", fmt.Errorf("prefix SG: %w", auth.ErrForbidden), codes.PermissionDenied, "PERMISSION_DENIED", "prefix SG: " + auth.ErrForbidden.Error()},
+		{"canceled", context.Canceled, codes.Canceled, "CANCELED", context.Canceled.Error()},
+		{"deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded, "DEADLINE_EXCEEDED", context.DeadlineExceeded.Error()},
+		// Store failures and unexpected errors must not leak their cause
+		{"unavailable", fmt.Errorf("%w: failed to query audit logs: %w", service.ErrAuditStoreUnavailable, dbDown), codes.Unavailable, "AUDIT_STORE_UNAVAILABLE", "audit store unavailable"},
+		{"internal", fmt.Errorf("failed to scan row: %w", dbDown), codes.Internal, "INTERNAL", "failed to get status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatus(tt.err, "failed to get status"))
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("status = %v %q, want %v %q", st.Code(), st.Message(), tt.code, tt.message)
+			}
+
+			var reason string
+			var violations []*errdetails.BadRequest_FieldViolation
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = d.Reason
+				case *errdetails.BadRequest:
+					violations = d.FieldViolations
+				}
+			}
+			if reason != tt.reason {
+				t.Errorf("ErrorInfo reason = %q, want %q", reason, tt.reason)
+			}
+			if wantField := tt.err == parseErr; wantField != (len(violations) == 1 && violations[0].Field == "format_template") {
+				t.Errorf("field violations = %v, want format_template only for template errors", violations)
+			}
+		})
+	}
+}
+
+func TestGRPCCodesCoverEveryKind(t *testing.T) {
+	for kind := service.KindInternal; kind <= service.KindDeadlineExceeded; kind++ {
+		if _, ok := grpcCodes[kind]; !ok {
+			t.Errorf("no gRPC code for kind %d", kind)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/putram11/sequential-id-counter-service/api/proto"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// componentHealthy is the component state reported by HealthCheck when a
+// component responds
+const componentHealthy = "healthy"
+
+// HealthReporter serves the standard grpc.health.v1 service from
+// SequentialIDService.HealthCheck. The overall status is reported for the
+// empty service name and for SequentialIDService, and each component under
+// its own name (redis or postgres, database, rabbitmq).
+type HealthReporter struct {
+	server   *health.Server
+	service  *service.SequentialIDService
+	interval time.Duration
+	logger   *logrus.Logger
+}
+
+// NewHealthReporter creates a health reporter refreshing every interval
+func NewHealthReporter(svc *service.SequentialIDService, interval time.Duration, logger *logrus.Logger) *HealthReporter {
+	return &HealthReporter{
+		server:   health.NewServer(),
+		service:  svc,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Register adds the health service to a gRPC server
+func (h *HealthReporter) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.server)
+}
+
+// Run refreshes the statuses every interval until ctx is done. Checks run
+// in the background so probes never wait on a slow component.
+func (h *HealthReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports every service as NOT_SERVING and ignores later
+// refreshes, so load balancers drain the server before it stops
+func (h *HealthReporter) Shutdown() {
+	h.server.Shutdown()
+}
+
+// refresh runs the component checks and publishes their statuses
+func (h *HealthReporter) refresh(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+
+	result := h.service.HealthCheck(checkCtx)
+
+	overall := servingStatus(result.Healthy)
+	h.server.SetServingStatus("", overall)
+	h.server.SetServingStatus(pb.SequentialIDService_ServiceDesc.ServiceName, overall)
+
+	for component, state := range result.Components {
+		h.server.SetServingStatus(component, servingStatus(state == componentHealthy))
+	}
+
+	if !result.Healthy {
+		h.logger.WithField("components", result.Components).Warn("gRPC health check reports unhealthy components")
+	}
+}
+
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
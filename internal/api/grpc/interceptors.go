@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/putram11/sequential-id-counter-service/api/proto"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// methodRoles lists the roles accepted by each RPC. A nil entry marks a
+// public method; methods missing from the map require the admin role.
+var methodRoles = map[string][]auth.Role{
+	"GetNext":            {auth.RoleGenerator},
+	"GetNextBatch":       {auth.RoleGenerator},
+	"GetStatus":          {auth.RoleGenerator, auth.RoleAuditor},
+	"GetConfig":          {auth.RoleGenerator, auth.RoleAuditor},
+	"ResetCounter":       {auth.RoleAdmin},
+	"UpdateConfig":       {auth.RoleAdmin},
+	"QueryAuditLogs":     {auth.RoleAuditor},
+	"LookupID":           {auth.RoleAuditor},
+	"Reserve":            {auth.RoleGenerator},
+	"CommitReservation":  {auth.RoleGenerator},
+	"ReleaseReservation": {auth.RoleGenerator},
+	"GetConfigHistory":   {auth.RoleAuditor},
+	"RollbackConfig":     {auth.RoleAdmin},
+	"ListPrefixes":       {auth.RoleGenerator, auth.RoleAuditor},
+	"ArchivePrefix":      {auth.RoleAdmin},
+	"UnarchivePrefix":    {auth.RoleAdmin},
+	"DeletePrefix":       {auth.RoleAdmin},
+	"PreviewFormat":      {auth.RoleAdmin},
+	"GetResetHistory":    {auth.RoleAuditor},
+	"Reconcile":          {auth.RoleAdmin},
+	"ListDeadLetters":    {auth.RoleAdmin},
+	"ReplayDeadLetters":  {auth.RoleAdmin},
+	"PurgeDeadLetters":   {auth.RoleAdmin},
+	"StreamNext":         {auth.RoleGenerator},
+	"AllocateRanges":     {auth.RoleGenerator},
+	"Health":             nil,
+}
+
+// publicServices are served without authentication: health probes and
+// schema discovery run before a client holds credentials
+var publicServices = map[string]bool{
+	healthpb.Health_ServiceDesc.ServiceName:                      true,
+	reflectionpb.ServerReflection_ServiceDesc.ServiceName:        true,
+	reflectionv1alphapb.ServerReflection_ServiceDesc.ServiceName: true,
+}
+
+// AuthInterceptor authenticates gRPC calls and enforces per-method roles.
+// With a nil authenticator (authentication disabled) every call passes.
+type AuthInterceptor struct {
+	authenticator auth.Authenticator
+	logger        *logrus.Logger
+}
+
+// NewAuthInterceptor creates a new authentication interceptor
+func NewAuthInterceptor(authenticator auth.Authenticator, logger *logrus.Logger) *AuthInterceptor {
+	return &AuthInterceptor{
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+// Unary returns the unary server interceptor
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the stream server interceptor
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize authenticates the caller from metadata and checks the method's roles
+func (i *AuthInterceptor) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	if i.authenticator == nil {
+		return ctx, nil
+	}
+
+	if publicServices[serviceName(fullMethod)] {
+		return ctx, nil
+	}
+
+	roles, known := methodRoles[methodName(fullMethod)]
+	if known && roles == nil {
+		return ctx, nil
+	}
+	if !known {
+		roles = []auth.Role{auth.RoleAdmin}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	creds := auth.ParseAuthorization(firstValue(md, "authorization"), firstValue(md, "x-api-key"))
+
+	principal, err := i.authenticator.Authenticate(ctx, creds)
+	if err != nil {
+		i.logger.WithError(err).WithField("method", fullMethod).Warn("Authentication failed")
+		return nil, statusError(codes.Unauthenticated, reasonUnauthenticated, "authentication required")
+	}
+
+	if err := principal.Authorize(roles...); err != nil {
+		i.logger.WithError(err).WithFields(logrus.Fields{
+			"method":  fullMethod,
+			"subject": principal.Subject,
+		}).Warn("Authorization failed")
+		return nil, toStatus(err, "permission denied")
+	}
+
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// methodName strips the service name from a full gRPC method name
+func methodName(fullMethod string) string {
+	prefix := "/" + pb.SequentialIDService_ServiceDesc.ServiceName + "/"
+	if len(fullMethod) > len(prefix) && fullMethod[:len(prefix)] == prefix {
+		return fullMethod[len(prefix):]
+	}
+	return fullMethod
+}
+
+// serviceName returns the service part of a full gRPC method name
+func serviceName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// authenticatedStream carries the authenticated context into stream handlers
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -2,14 +2,18 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"sort"
 	"time"
 
 	pb "github.com/putram11/sequential-id-counter-service/api/proto"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
 	"github.com/putram11/sequential-id-counter-service/internal/service"
 	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Server implements the SequentialIDServiceServer interface
@@ -30,10 +34,16 @@ func NewServer(sequentialIDService *service.SequentialIDService, logger *logrus.
 // GetNext gets the next sequential ID for a prefix
 func (s *Server) GetNext(ctx context.Context, req *pb.GetNextRequest) (*pb.GetNextResponse, error) {
 	if req.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
-	result, err := s.sequentialIDService.GetNext(ctx, req.Prefix, req.ClientId, req.CorrelationId)
+	result, err := s.sequentialIDService.GetNext(ctx, &models.NextRequest{
+		Prefix:         req.Prefix,
+		ClientID:       req.ClientId,
+		GeneratedBy:    req.GeneratedBy,
+		CorrelationID:  req.CorrelationId,
+		IdempotencyKey: req.IdempotencyKey,
+	})
 	if err != nil {
 		s.logger.WithError(err).WithFields(logrus.Fields{
 			"prefix":         req.Prefix,
@@ -41,33 +51,37 @@ func (s *Server) GetNext(ctx context.Context, req *pb.GetNextRequest) (*pb.GetNe
 			"correlation_id": req.CorrelationId,
 		}).Error("Failed to get next sequential ID")
 
-		return nil, status.Error(codes.Internal, "failed to generate sequential ID")
+		return nil, toStatus(err, "failed to generate sequential ID")
 	}
 
 	return &pb.GetNextResponse{
-		FullNumber:  result.FullNumber,
-		Prefix:      result.Prefix,
-		Counter:     result.Counter,
-		GeneratedAt: result.GeneratedAt.Format(time.RFC3339),
-		MessageId:   result.MessageID,
+		FullNumber:    result.FullNumber,
+		Prefix:        result.Prefix,
+		Counter:       result.Counter,
+		MessageId:     result.MessageID,
+		GeneratedAt:   timestamppb.New(result.GeneratedAt),
+		PeriodKey:     result.PeriodKey,
+		CorrelationId: result.CorrelationID,
 	}, nil
 }
 
 // GetNextBatch gets a batch of sequential IDs
 func (s *Server) GetNextBatch(ctx context.Context, req *pb.GetNextBatchRequest) (*pb.GetNextBatchResponse, error) {
 	if req.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
 	if req.Count <= 0 || req.Count > 1000 {
-		return nil, status.Error(codes.InvalidArgument, "count must be between 1 and 1000")
+		return nil, invalidArgument("count", "count must be between 1 and 1000")
 	}
 
 	batchReq := &models.BatchRequest{
-		Prefix:        req.Prefix,
-		Count:         int(req.Count),
-		ClientID:      req.ClientId,
-		CorrelationID: req.CorrelationId,
+		Prefix:         req.Prefix,
+		Count:          int(req.Count),
+		ClientID:       req.ClientId,
+		GeneratedBy:    req.GeneratedBy,
+		CorrelationID:  req.CorrelationId,
+		IdempotencyKey: req.IdempotencyKey,
 	}
 
 	result, err := s.sequentialIDService.GetNextBatch(ctx, batchReq)
@@ -79,7 +93,7 @@ func (s *Server) GetNextBatch(ctx context.Context, req *pb.GetNextBatchRequest)
 			"correlation_id": req.CorrelationId,
 		}).Error("Failed to get batch of sequential IDs")
 
-		return nil, status.Error(codes.Internal, "failed to generate batch of sequential IDs")
+		return nil, toStatus(err, "failed to generate batch of sequential IDs")
 	}
 
 	return &pb.GetNextBatchResponse{
@@ -88,11 +102,86 @@ func (s *Server) GetNextBatch(ctx context.Context, req *pb.GetNextBatchRequest)
 		StartCounter: result.IDs[0].Counter,
 		EndCounter:   result.IDs[len(result.IDs)-1].Counter,
 		Count:        int32(result.Count),
-		GeneratedAt:  result.GeneratedAt.Format(time.RFC3339),
 		BatchId:      result.BatchID,
+		GeneratedAt:  timestamppb.New(result.GeneratedAt),
+		PeriodKey:    result.IDs[0].PeriodKey,
 	}, nil
 }
 
+// StreamNext streams the ID ranges allocated for a single demand
+func (s *Server) StreamNext(req *pb.AllocateRequest, stream pb.SequentialIDService_StreamNextServer) error {
+	return s.allocate(stream.Context(), req, stream.Send)
+}
+
+// AllocateRanges serves a stream of demands in order, streaming every range
+// of a demand before reading the next one. The stream ends at the first
+// demand that fails.
+func (s *Server) AllocateRanges(stream pb.SequentialIDService_AllocateRangesServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.allocate(stream.Context(), req, stream.Send); err != nil {
+			return err
+		}
+	}
+}
+
+// allocate serves one demand, sending each range as it is allocated. Send
+// blocks while the client's flow-control window is full, which holds back
+// further allocation.
+func (s *Server) allocate(ctx context.Context, req *pb.AllocateRequest, send func(*pb.AllocatedRange) error) error {
+	if req.Prefix == "" {
+		return invalidArgument("prefix", "prefix is required")
+	}
+
+	var sendErr error
+	err := s.sequentialIDService.AllocateRanges(ctx, &models.RangeRequest{
+		DemandID:      req.DemandId,
+		Prefix:        req.Prefix,
+		Count:         int(req.Count),
+		ChunkSize:     int(req.ChunkSize),
+		ClientID:      req.ClientId,
+		GeneratedBy:   req.GeneratedBy,
+		CorrelationID: req.CorrelationId,
+	}, func(r *models.IDRange) error {
+		sendErr = send(&pb.AllocatedRange{
+			DemandId:     r.DemandID,
+			Prefix:       r.Prefix,
+			PeriodKey:    r.PeriodKey,
+			StartCounter: r.StartCounter,
+			EndCounter:   r.EndCounter,
+			Count:        int32(r.Count),
+			FullNumbers:  r.FullNumbers,
+			BatchId:      r.BatchID,
+			GeneratedAt:  timestamppb.New(r.GeneratedAt),
+			Remaining:    int32(r.Remaining),
+		})
+		return sendErr
+	})
+	switch {
+	case err == nil:
+		return nil
+	case sendErr != nil:
+		// The client went away; the transport already carries the status
+		return sendErr
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	s.logger.WithError(err).WithFields(logrus.Fields{
+		"prefix":    req.Prefix,
+		"count":     req.Count,
+		"demand_id": req.DemandId,
+	}).Error("Failed to allocate ID ranges")
+
+	return toStatus(err, "failed to allocate ID ranges")
+}
+
 // extractFullNumbers extracts full numbers from SequentialID slice
 func extractFullNumbers(ids []models.SequentialID) []string {
 	fullNumbers := make([]string, len(ids))
@@ -105,18 +194,18 @@ func extractFullNumbers(ids []models.SequentialID) []string {
 // ResetCounter resets the counter for a prefix
 func (s *Server) ResetCounter(ctx context.Context, req *pb.ResetCounterRequest) (*pb.ResetCounterResponse, error) {
 	if req.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
 	if req.NewValue < 0 {
-		return nil, status.Error(codes.InvalidArgument, "new_value must be non-negative")
+		return nil, invalidArgument("new_value", "new_value must be non-negative")
 	}
 
 	resetReq := &models.ResetRequest{
 		SetTo:     req.NewValue,
 		Reason:    req.Reason,
 		AdminUser: req.ClientId,
-		Force:     false,
+		Force:     req.Force,
 	}
 
 	result, err := s.sequentialIDService.ResetCounter(ctx, req.Prefix, resetReq)
@@ -129,7 +218,7 @@ func (s *Server) ResetCounter(ctx context.Context, req *pb.ResetCounterRequest)
 			"correlation_id": req.CorrelationId,
 		}).Error("Failed to reset counter")
 
-		return nil, status.Error(codes.Internal, "failed to reset counter")
+		return nil, toStatus(err, "failed to reset counter")
 	}
 
 	return &pb.ResetCounterResponse{
@@ -137,28 +226,33 @@ func (s *Server) ResetCounter(ctx context.Context, req *pb.ResetCounterRequest)
 		Message:  result.Message,
 		OldValue: result.OldValue,
 		NewValue: result.NewValue,
+		ResetId:  result.ResetID,
 	}, nil
 }
 
-// GetStatus gets the status of a counter (simplified for compatibility)
+// GetStatus gets the status of a counter
 func (s *Server) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
 	if req.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
 	statusResult, err := s.sequentialIDService.GetStatus(ctx, req.Prefix)
 	if err != nil {
 		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to get status")
-		return nil, status.Error(codes.Internal, "failed to get counter status")
+		return nil, toStatus(err, "failed to get counter status")
 	}
 
 	return &pb.GetStatusResponse{
-		Prefix:         statusResult.Prefix,
-		CurrentCounter: statusResult.CurrentCounter,
-		IsActive:       true,                            // Default value since not in CounterStatus
-		LastGenerated:  time.Now().Format(time.RFC3339), // Default since not in CounterStatus
-		TotalGenerated: statusResult.LastAuditCounter,
-		Config:         nil, // Will be nil since CounterStatus doesn't include config
+		Prefix:          statusResult.Prefix,
+		CurrentCounter:  statusResult.CurrentCounter,
+		IsActive:        true, // Default value since not in CounterStatus
+		TotalGenerated:  statusResult.LastAuditCounter,
+		Config:          nil, // Will be nil since CounterStatus doesn't include config
+		PeriodKey:       statusResult.PeriodKey,
+		NextCounter:     statusResult.NextCounter,
+		RedisHealthy:    statusResult.RedisHealthy,
+		QueueHealthy:    statusResult.QueueHealthy,
+		DatabaseHealthy: statusResult.DatabaseHealthy,
 	}, nil
 }
 
@@ -184,13 +278,13 @@ func (s *Server) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthR
 // GetConfig gets the configuration for a prefix
 func (s *Server) GetConfig(ctx context.Context, req *pb.GetConfigRequest) (*pb.GetConfigResponse, error) {
 	if req.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
 	config, err := s.sequentialIDService.GetConfig(ctx, req.Prefix)
 	if err != nil {
 		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to get config")
-		return nil, status.Error(codes.Internal, "failed to get configuration")
+		return nil, toStatus(err, "failed to get configuration")
 	}
 
 	if config == nil {
@@ -200,28 +294,35 @@ func (s *Server) GetConfig(ctx context.Context, req *pb.GetConfigRequest) (*pb.G
 	}
 
 	return &pb.GetConfigResponse{
-		Config: &pb.ConfigInfo{
-			Prefix:       config.Prefix,
-			Format:       config.FormatTemplate,
-			Padding:      int32(config.PaddingLength),
-			Separator:    "",               // Not in model
-			InitialValue: 0,                // Not in model
-			MaxValue:     0,                // Not in model
-			IsActive:     true,             // Not in model
-			Description:  config.ResetRule, // Using reset rule as description
-		},
-		Found: true,
+		Config: toConfigInfo(config),
+		Found:  true,
 	}, nil
 }
 
+// toConfigInfo converts a prefix configuration to its protobuf representation
+func toConfigInfo(config *models.PrefixConfig) *pb.ConfigInfo {
+	return &pb.ConfigInfo{
+		Prefix:       config.Prefix,
+		Format:       config.FormatTemplate,
+		Padding:      int32(config.PaddingLength),
+		Separator:    "", // Not in model
+		InitialValue: 0,  // Not in model
+		MaxValue:     0,  // Not in model
+		IsActive:     config.ArchivedAt == nil,
+		Description:  config.ResetRule, // Using reset rule as description
+		Gapless:      proto.Bool(config.Gapless),
+		BlockSize:    proto.Int32(int32(config.BlockSize)),
+	}
+}
+
 // UpdateConfig updates the configuration for a prefix (simplified implementation)
 func (s *Server) UpdateConfig(ctx context.Context, req *pb.UpdateConfigRequest) (*pb.UpdateConfigResponse, error) {
 	if req.Config == nil {
-		return nil, status.Error(codes.InvalidArgument, "config is required")
+		return nil, invalidArgument("config", "config is required")
 	}
 
 	if req.Config.Prefix == "" {
-		return nil, status.Error(codes.InvalidArgument, "prefix is required")
+		return nil, invalidArgument("prefix", "prefix is required")
 	}
 
 	updateReq := &models.ConfigUpdateRequest{
@@ -236,6 +337,11 @@ func (s *Server) UpdateConfig(ctx context.Context, req *pb.UpdateConfigRequest)
 		padding := int(req.Config.Padding)
 		updateReq.PaddingLength = &padding
 	}
+	updateReq.Gapless = req.Config.Gapless
+	if req.Config.BlockSize != nil {
+		blockSize := int(*req.Config.BlockSize)
+		updateReq.BlockSize = &blockSize
+	}
 
 	err := s.sequentialIDService.UpdateConfig(ctx, req.Config.Prefix, updateReq)
 	if err != nil {
@@ -245,7 +351,7 @@ func (s *Server) UpdateConfig(ctx context.Context, req *pb.UpdateConfigRequest)
 			"correlation_id": req.CorrelationId,
 		}).Error("Failed to update config")
 
-		return nil, status.Error(codes.Internal, "failed to update configuration")
+		return nil, toStatus(err, "failed to update configuration")
 	}
 
 	return &pb.UpdateConfigResponse{
@@ -254,3 +360,616 @@ func (s *Server) UpdateConfig(ctx context.Context, req *pb.UpdateConfigRequest)
 		Config:  req.Config,
 	}, nil
 }
+
+// QueryAuditLogs queries audit log entries with filters and cursor pagination
+func (s *Server) QueryAuditLogs(ctx context.Context, req *pb.QueryAuditLogsRequest) (*pb.QueryAuditLogsResponse, error) {
+	query := &models.AuditQuery{
+		Prefix:        req.Prefix,
+		PeriodKey:     req.PeriodKey,
+		CounterFrom:   req.CounterFrom,
+		CounterTo:     req.CounterTo,
+		ClientID:      req.ClientId,
+		GeneratedBy:   req.GeneratedBy,
+		BatchID:       req.BatchId,
+		CorrelationID: req.CorrelationId,
+		Limit:         int(req.Limit),
+		Cursor:        req.Cursor,
+	}
+
+	var err error
+	if query.From, err = fromTimestamp("from", req.From); err != nil {
+		return nil, err
+	}
+	if query.To, err = fromTimestamp("to", req.To); err != nil {
+		return nil, err
+	}
+
+	page, err := s.sequentialIDService.QueryAuditLogs(ctx, query)
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to query audit logs")
+		return nil, toStatus(err, "failed to query audit logs")
+	}
+
+	logs := make([]*pb.AuditLogEntry, len(page.Logs))
+	for i := range page.Logs {
+		logs[i] = toAuditLogEntry(&page.Logs[i])
+	}
+
+	return &pb.QueryAuditLogsResponse{
+		Logs:       logs,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+// LookupID looks up an issued ID by its full number
+func (s *Server) LookupID(ctx context.Context, req *pb.LookupIDRequest) (*pb.LookupIDResponse, error) {
+	if req.FullNumber == "" {
+		return nil, invalidArgument("full_number", "full_number is required")
+	}
+
+	lookup, err := s.sequentialIDService.LookupID(ctx, req.FullNumber)
+	if err != nil {
+		s.logger.WithError(err).WithField("full_number", req.FullNumber).Error("Failed to look up ID")
+		return nil, toStatus(err, "failed to look up ID")
+	}
+
+	if lookup == nil {
+		return &pb.LookupIDResponse{
+			Found:      false,
+			FullNumber: req.FullNumber,
+		}, nil
+	}
+
+	resp := &pb.LookupIDResponse{
+		Found:        true,
+		FullNumber:   lookup.FullNumber,
+		Status:       lookup.Status,
+		Tenant:       lookup.Tenant,
+		Prefix:       lookup.Prefix,
+		PeriodKey:    lookup.PeriodKey,
+		CounterValue: lookup.CounterValue,
+		Resets:       make([]*pb.ResetLogEntry, len(lookup.Resets)),
+	}
+	if lookup.Audit != nil {
+		resp.Audit = toAuditLogEntry(lookup.Audit)
+	}
+	if lookup.Batch != nil {
+		resp.Batch = &pb.BatchSummary{
+			BatchId:      lookup.Batch.BatchID,
+			Size:         int32(lookup.Batch.Size),
+			FirstCounter: lookup.Batch.FirstCounter,
+			LastCounter:  lookup.Batch.LastCounter,
+		}
+	}
+	for i := range lookup.Resets {
+		resp.Resets[i] = toResetLogEntry(&lookup.Resets[i])
+	}
+
+	return resp, nil
+}
+
+// Reserve reserves a provisional number of a gapless prefix
+func (s *Server) Reserve(ctx context.Context, req *pb.ReserveRequest) (*pb.ReserveResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	result, err := s.sequentialIDService.Reserve(ctx, &models.ReserveRequest{
+		Prefix:       req.Prefix,
+		ClientID:     req.ClientId,
+		GeneratedBy:  req.GeneratedBy,
+		LeaseSeconds: int(req.LeaseSeconds),
+	})
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix":    req.Prefix,
+			"client_id": req.ClientId,
+		}).Error("Failed to reserve gapless number")
+
+		return nil, toStatus(err, "failed to reserve number")
+	}
+
+	return &pb.ReserveResponse{
+		ReservationId: result.ReservationID,
+		FullNumber:    result.FullNumber,
+		Prefix:        result.Prefix,
+		Counter:       result.CounterValue,
+		PeriodKey:     result.PeriodKey,
+		ReservedAt:    timestamppb.New(result.ReservedAt),
+		ExpiresAt:     timestamppb.New(result.ExpiresAt),
+	}, nil
+}
+
+// CommitReservation commits a reserved number
+func (s *Server) CommitReservation(ctx context.Context, req *pb.CommitReservationRequest) (*pb.CommitReservationResponse, error) {
+	if req.ReservationId == "" {
+		return nil, invalidArgument("reservation_id", "reservation_id is required")
+	}
+
+	result, err := s.sequentialIDService.CommitReservation(ctx, req.ReservationId)
+	if err != nil {
+		s.logger.WithError(err).WithField("reservation_id", req.ReservationId).Error("Failed to commit reservation")
+		return nil, toStatus(err, "failed to commit reservation")
+	}
+
+	return &pb.CommitReservationResponse{
+		FullNumber:  result.FullNumber,
+		Prefix:      result.Prefix,
+		Counter:     result.Counter,
+		MessageId:   result.MessageID,
+		GeneratedAt: timestamppb.New(result.GeneratedAt),
+	}, nil
+}
+
+// ReleaseReservation releases a reserved number for reuse
+func (s *Server) ReleaseReservation(ctx context.Context, req *pb.ReleaseReservationRequest) (*pb.ReleaseReservationResponse, error) {
+	if req.ReservationId == "" {
+		return nil, invalidArgument("reservation_id", "reservation_id is required")
+	}
+
+	if err := s.sequentialIDService.ReleaseReservation(ctx, req.ReservationId); err != nil {
+		s.logger.WithError(err).WithField("reservation_id", req.ReservationId).Error("Failed to release reservation")
+		return nil, toStatus(err, "failed to release reservation")
+	}
+
+	return &pb.ReleaseReservationResponse{Success: true}, nil
+}
+
+// GetConfigHistory lists the recorded configuration changes of a prefix
+func (s *Server) GetConfigHistory(ctx context.Context, req *pb.GetConfigHistoryRequest) (*pb.GetConfigHistoryResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	changes, err := s.sequentialIDService.GetConfigHistory(ctx, req.Prefix, int(req.Limit))
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to get config history")
+		return nil, toStatus(err, "failed to get configuration history")
+	}
+
+	resp := &pb.GetConfigHistoryResponse{
+		Changes: make([]*pb.ConfigChangeEntry, len(changes)),
+	}
+	for i := range changes {
+		entry, err := toConfigChangeEntry(&changes[i])
+		if err != nil {
+			s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to encode config history")
+			return nil, toStatus(err, "failed to get configuration history")
+		}
+		resp.Changes[i] = entry
+	}
+
+	return resp, nil
+}
+
+// RollbackConfig restores a previous configuration version of a prefix
+func (s *Server) RollbackConfig(ctx context.Context, req *pb.RollbackConfigRequest) (*pb.RollbackConfigResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+	if req.Version <= 0 {
+		return nil, invalidArgument("version", "version is required")
+	}
+
+	config, err := s.sequentialIDService.RollbackConfig(ctx, req.Prefix, &models.ConfigRollbackRequest{
+		Version:   req.Version,
+		AdminUser: req.ClientId,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix":    req.Prefix,
+			"version":   req.Version,
+			"client_id": req.ClientId,
+		}).Error("Failed to roll back config")
+
+		return nil, toStatus(err, "failed to roll back configuration")
+	}
+
+	resp := &pb.RollbackConfigResponse{Success: true}
+	if config != nil {
+		resp.Config = toConfigInfo(config)
+	}
+	return resp, nil
+}
+
+// ListPrefixes lists the configured prefixes with their counters
+func (s *Server) ListPrefixes(ctx context.Context, req *pb.ListPrefixesRequest) (*pb.ListPrefixesResponse, error) {
+	prefixes, err := s.sequentialIDService.ListPrefixes(ctx, req.IncludeArchived)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list prefixes")
+		return nil, toStatus(err, "failed to list prefixes")
+	}
+
+	resp := &pb.ListPrefixesResponse{
+		Prefixes: make([]*pb.PrefixSummary, len(prefixes)),
+	}
+	for i := range prefixes {
+		p := &prefixes[i]
+		summary := &pb.PrefixSummary{
+			Config:         toConfigInfo(&p.PrefixConfig),
+			PeriodKey:      p.PeriodKey,
+			CurrentCounter: p.CurrentCounter,
+			LastIssuedAt:   toTimestamp(p.LastIssuedAt),
+			ArchivedAt:     toTimestamp(p.ArchivedAt),
+		}
+		resp.Prefixes[i] = summary
+	}
+
+	return resp, nil
+}
+
+// ArchivePrefix stops a prefix from issuing new IDs
+func (s *Server) ArchivePrefix(ctx context.Context, req *pb.ArchivePrefixRequest) (*pb.ArchivePrefixResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	config, err := s.sequentialIDService.ArchivePrefix(ctx, req.Prefix, &models.PrefixArchiveRequest{AdminUser: req.ClientId})
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to archive prefix")
+		return nil, toStatus(err, "failed to archive prefix")
+	}
+
+	resp := &pb.ArchivePrefixResponse{}
+	if config != nil {
+		resp.Config = toConfigInfo(config)
+	}
+	return resp, nil
+}
+
+// UnarchivePrefix lets an archived prefix issue IDs again
+func (s *Server) UnarchivePrefix(ctx context.Context, req *pb.UnarchivePrefixRequest) (*pb.UnarchivePrefixResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	config, err := s.sequentialIDService.UnarchivePrefix(ctx, req.Prefix, &models.PrefixArchiveRequest{AdminUser: req.ClientId})
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to unarchive prefix")
+		return nil, toStatus(err, "failed to unarchive prefix")
+	}
+
+	resp := &pb.UnarchivePrefixResponse{}
+	if config != nil {
+		resp.Config = toConfigInfo(config)
+	}
+	return resp, nil
+}
+
+// DeletePrefix deletes the configuration of a prefix
+func (s *Server) DeletePrefix(ctx context.Context, req *pb.DeletePrefixRequest) (*pb.DeletePrefixResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	err := s.sequentialIDService.DeletePrefix(ctx, req.Prefix, &models.PrefixDeleteRequest{
+		AdminUser: req.ClientId,
+		Force:     req.Force,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix": req.Prefix,
+			"force":  req.Force,
+		}).Error("Failed to delete prefix")
+		return nil, toStatus(err, "failed to delete prefix")
+	}
+
+	return &pb.DeletePrefixResponse{Success: true}, nil
+}
+
+// PreviewFormat renders sample IDs for a proposed format template
+func (s *Server) PreviewFormat(ctx context.Context, req *pb.PreviewFormatRequest) (*pb.PreviewFormatResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	previewReq := &models.FormatPreviewRequest{
+		FormatTemplate: req.FormatTemplate,
+		StartCounter:   req.StartCounter,
+		Count:          int(req.Count),
+	}
+	if req.PaddingLength != nil {
+		padding := int(*req.PaddingLength)
+		previewReq.PaddingLength = &padding
+	}
+	var err error
+	if previewReq.At, err = fromTimestamp("at", req.At); err != nil {
+		return nil, err
+	}
+
+	result, err := s.sequentialIDService.PreviewFormat(ctx, req.Prefix, previewReq)
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Debug("Failed to preview format template")
+		return nil, toStatus(err, "failed to preview format template")
+	}
+
+	return &pb.PreviewFormatResponse{
+		Prefix:         result.Prefix,
+		FormatTemplate: result.FormatTemplate,
+		PaddingLength:  int32(result.PaddingLength),
+		Samples:        result.Samples,
+	}, nil
+}
+
+// GetResetHistory lists the counter resets of a prefix
+func (s *Server) GetResetHistory(ctx context.Context, req *pb.GetResetHistoryRequest) (*pb.GetResetHistoryResponse, error) {
+	if req.Prefix == "" {
+		return nil, invalidArgument("prefix", "prefix is required")
+	}
+
+	resets, err := s.sequentialIDService.GetResetHistory(ctx, req.Prefix, req.PeriodKey, int(req.Limit))
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", req.Prefix).Error("Failed to get reset history")
+		return nil, toStatus(err, "failed to get reset history")
+	}
+
+	resp := &pb.GetResetHistoryResponse{
+		Resets: make([]*pb.ResetLogEntry, len(resets)),
+	}
+	for i := range resets {
+		resp.Resets[i] = toResetLogEntry(&resets[i])
+	}
+
+	return resp, nil
+}
+
+// Reconcile compares counters against the audit log
+func (s *Server) Reconcile(ctx context.Context, req *pb.ReconcileRequest) (*pb.ReconcileResponse, error) {
+	report, err := s.sequentialIDService.Reconcile(ctx, &models.ReconcileRequest{
+		Prefixes:    req.Prefixes,
+		FixGaps:     req.FixGaps,
+		MaxBackfill: req.MaxBackfill,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"prefixes": req.Prefixes,
+			"fix_gaps": req.FixGaps,
+		}).Error("Failed to reconcile counters")
+		return nil, toStatus(err, "failed to reconcile counters")
+	}
+
+	resp := &pb.ReconcileResponse{
+		Periods:    make([]*pb.PeriodReconciliation, len(report.Periods)),
+		Missing:    report.Missing,
+		Backfilled: report.Backfilled,
+		Truncated:  report.Truncated,
+		CheckedAt:  timestamppb.New(report.CheckedAt),
+	}
+	for i := range report.Periods {
+		p := &report.Periods[i]
+		period := &pb.PeriodReconciliation{
+			Tenant:            p.Tenant,
+			Prefix:            p.Prefix,
+			PeriodKey:         p.PeriodKey,
+			Active:            p.Active,
+			RedisCounter:      p.RedisCounter,
+			CheckpointCounter: p.CheckpointCounter,
+			AuditedCount:      p.AuditedCount,
+			AuditedMax:        p.AuditedMax,
+			LostCount:         p.LostCount,
+			RedisBehind:       p.RedisBehind,
+			Missing:           p.Missing,
+			Gaps:              make([]*pb.CounterGap, len(p.Gaps)),
+		}
+		for j, gap := range p.Gaps {
+			period.Gaps[j] = &pb.CounterGap{
+				From:       gap.From,
+				To:         gap.To,
+				Count:      gap.Count,
+				Reason:     gap.Reason,
+				Backfilled: gap.Backfilled,
+			}
+		}
+		resp.Periods[i] = period
+	}
+
+	return resp, nil
+}
+
+// ListDeadLetters lists audit events in the dead letter queue
+func (s *Server) ListDeadLetters(ctx context.Context, req *pb.ListDeadLettersRequest) (*pb.ListDeadLettersResponse, error) {
+	list, err := s.sequentialIDService.ListDeadLetters(ctx, &models.DeadLetterQuery{Limit: int(req.Limit)})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list dead letters")
+		return nil, toStatus(err, "failed to list dead letters")
+	}
+
+	resp := &pb.ListDeadLettersResponse{
+		Messages: make([]*pb.DeadLetterEntry, len(list.Messages)),
+		Scanned:  int32(list.Scanned),
+		Depth:    int32(list.Depth),
+	}
+	for i := range list.Messages {
+		entry, err := toDeadLetterEntry(&list.Messages[i])
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to encode dead letter")
+			return nil, toStatus(err, "failed to list dead letters")
+		}
+		resp.Messages[i] = entry
+	}
+
+	return resp, nil
+}
+
+// ReplayDeadLetters publishes dead-lettered audit events back to the worker
+func (s *Server) ReplayDeadLetters(ctx context.Context, req *pb.DeadLettersRequest) (*pb.DeadLettersResponse, error) {
+	result, err := s.sequentialIDService.ReplayDeadLetters(ctx, toDeadLetterRequest(req))
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"message_ids": req.MessageIds,
+			"all":         req.All,
+		}).Error("Failed to replay dead letters")
+		return nil, toStatus(err, "failed to replay dead letters")
+	}
+
+	return toDeadLettersResponse(result), nil
+}
+
+// PurgeDeadLetters discards dead-lettered audit events
+func (s *Server) PurgeDeadLetters(ctx context.Context, req *pb.DeadLettersRequest) (*pb.DeadLettersResponse, error) {
+	result, err := s.sequentialIDService.PurgeDeadLetters(ctx, toDeadLetterRequest(req))
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"message_ids": req.MessageIds,
+			"all":         req.All,
+		}).Error("Failed to purge dead letters")
+		return nil, toStatus(err, "failed to purge dead letters")
+	}
+
+	return toDeadLettersResponse(result), nil
+}
+
+// toDeadLetterRequest converts a dead letter request from its protobuf
+// representation
+func toDeadLetterRequest(req *pb.DeadLettersRequest) *models.DeadLetterRequest {
+	return &models.DeadLetterRequest{
+		MessageIDs: req.MessageIds,
+		All:        req.All,
+		Limit:      int(req.Limit),
+		Reason:     req.Reason,
+		AdminUser:  req.ClientId,
+	}
+}
+
+// toDeadLettersResponse converts a dead letter result to its protobuf
+// representation
+func toDeadLettersResponse(result *models.DeadLetterResult) *pb.DeadLettersResponse {
+	return &pb.DeadLettersResponse{
+		AuditId:    result.AuditID,
+		Action:     result.Action,
+		MessageIds: result.MessageIDs,
+		Scanned:    int32(result.Scanned),
+		Remaining:  int32(result.Remaining),
+		HandledAt:  timestamppb.New(result.HandledAt),
+	}
+}
+
+// toDeadLetterEntry converts a dead letter to its protobuf representation;
+// parsed events are JSON-encoded as the payload
+func toDeadLetterEntry(dl *models.DeadLetter) (*pb.DeadLetterEntry, error) {
+	entry := &pb.DeadLetterEntry{
+		MessageId:   dl.MessageID,
+		Tenant:      dl.Tenant,
+		Prefix:      dl.Prefix,
+		FullNumber:  dl.FullNumber,
+		RetryCount:  int32(dl.RetryCount),
+		Reason:      dl.Reason,
+		PublishedAt: timestamppb.New(dl.PublishedAt),
+		Payload:     dl.Payload,
+	}
+	if dl.Event != nil {
+		payload, err := json.Marshal(dl.Event)
+		if err != nil {
+			return nil, err
+		}
+		entry.Payload = string(payload)
+	}
+	return entry, nil
+}
+
+// toConfigChangeEntry converts a configuration change to its protobuf
+// representation; changed values are JSON-encoded
+func toConfigChangeEntry(change *models.ConfigChange) (*pb.ConfigChangeEntry, error) {
+	entry := &pb.ConfigChangeEntry{
+		Version:    change.Version,
+		Prefix:     change.Prefix,
+		ChangeType: change.ChangeType,
+		AdminUser:  change.AdminUser,
+		Reason:     change.Reason,
+		ChangedAt:  timestamppb.New(change.ChangedAt),
+		Changes:    make([]*pb.ConfigFieldChange, 0, len(change.Diff)),
+	}
+	if change.OldConfig != nil {
+		entry.OldConfig = toConfigInfo(change.OldConfig)
+	}
+	if change.NewConfig != nil {
+		entry.NewConfig = toConfigInfo(change.NewConfig)
+	}
+
+	fields := make([]string, 0, len(change.Diff))
+	for field := range change.Diff {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		oldValue, err := json.Marshal(change.Diff[field].Old)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := json.Marshal(change.Diff[field].New)
+		if err != nil {
+			return nil, err
+		}
+		entry.Changes = append(entry.Changes, &pb.ConfigFieldChange{
+			Field:    field,
+			OldValue: string(oldValue),
+			NewValue: string(newValue),
+		})
+	}
+
+	return entry, nil
+}
+
+// toAuditLogEntry converts an audit log to its protobuf representation
+func toAuditLogEntry(log *models.AuditLog) *pb.AuditLogEntry {
+	return &pb.AuditLogEntry{
+		Id:            log.ID,
+		Prefix:        log.Prefix,
+		CounterValue:  log.CounterValue,
+		PeriodKey:     log.PeriodKey,
+		FullNumber:    log.FullNumber,
+		GeneratedBy:   stringValue(log.GeneratedBy),
+		ClientId:      stringValue(log.ClientID),
+		CorrelationId: stringValue(log.CorrelationID),
+		MessageId:     log.MessageID,
+		BatchId:       stringValue(log.BatchID),
+		Status:        log.Status,
+		Tenant:        log.Tenant,
+		GeneratedAt:   timestamppb.New(log.GeneratedAt),
+	}
+}
+
+// toResetLogEntry converts a counter reset to its protobuf representation
+func toResetLogEntry(reset *models.ResetLog) *pb.ResetLogEntry {
+	return &pb.ResetLogEntry{
+		ResetId:   reset.ResetID,
+		PeriodKey: reset.PeriodKey,
+		OldValue:  reset.OldValue,
+		NewValue:  reset.NewValue,
+		Reason:    reset.Reason,
+		AdminUser: reset.AdminUser,
+		ResetAt:   timestamppb.New(reset.ResetAt),
+		Prefix:    reset.Prefix,
+	}
+}
+
+// toTimestamp converts an optional time; nil stays unset
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// fromTimestamp converts an optional timestamp field of a request; unset
+// fields stay nil
+func fromTimestamp(field string, ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, invalidArgument(field, field+" is not a valid timestamp")
+	}
+	t := ts.AsTime()
+	return &t, nil
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
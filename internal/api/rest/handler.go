@@ -1,8 +1,12 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
@@ -10,6 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// IdempotencyKeyHeader carries the client's idempotency key for ID generation
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // Handler handles REST API requests
 type Handler struct {
 	service *service.SequentialIDService
@@ -33,24 +40,34 @@ func NewHandler(service *service.SequentialIDService, logger *logrus.Logger) *Ha
 // @Param prefix path string true "Prefix identifier"
 // @Param client_id query string false "Client identifier"
 // @Param generated_by query string false "User or system that generated the ID"
+// @Param correlation_id query string false "Correlation identifier recorded in the audit log"
+// @Param Idempotency-Key header string false "Replays the original ID when the request is retried"
 // @Success 200 {object} models.SequentialID
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/next/{prefix} [get]
 func (h *Handler) GetNext(c *gin.Context) {
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
-	clientID := c.Query("client_id")
-	generatedBy := c.Query("generated_by")
+	req := &models.NextRequest{
+		Prefix:         prefix,
+		ClientID:       c.Query("client_id"),
+		GeneratedBy:    c.Query("generated_by"),
+		CorrelationID:  c.Query("correlation_id"),
+		IdempotencyKey: c.GetHeader(IdempotencyKeyHeader),
+	}
 
-	seqID, err := h.service.GetNext(c.Request.Context(), prefix, clientID, generatedBy)
+	seqID, err := h.service.GetNext(c.Request.Context(), req)
 	if err != nil {
 		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to generate sequential ID")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -65,29 +82,34 @@ func (h *Handler) GetNext(c *gin.Context) {
 // @Produce json
 // @Param prefix path string true "Prefix identifier"
 // @Param request body models.BatchRequest true "Batch request"
+// @Param Idempotency-Key header string false "Replays the original batch when the request is retried"
 // @Success 200 {object} models.BatchResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/batch/{prefix} [post]
 func (h *Handler) GetNextBatch(c *gin.Context) {
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
 	var req models.BatchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, "", err.Error())
 		return
 	}
 
 	req.Prefix = prefix // Override with path parameter
+	req.IdempotencyKey = c.GetHeader(IdempotencyKeyHeader)
 
 	resp, err := h.service.GetNextBatch(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to generate batch of sequential IDs")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -102,20 +124,20 @@ func (h *Handler) GetNextBatch(c *gin.Context) {
 // @Produce json
 // @Param prefix path string true "Prefix identifier"
 // @Success 200 {object} models.CounterStatus
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/status/{prefix} [get]
 func (h *Handler) GetStatus(c *gin.Context) {
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
 	status, err := h.service.GetStatus(c.Request.Context(), prefix)
 	if err != nil {
 		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to get counter status")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -132,21 +154,21 @@ func (h *Handler) GetStatus(c *gin.Context) {
 // @Param request body models.ResetRequest true "Reset request"
 // @Security BearerAuth
 // @Success 200 {object} models.ResetResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/reset/{prefix} [post]
 func (h *Handler) ResetCounter(c *gin.Context) {
-	// TODO: Add authentication middleware
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
 	var req models.ResetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, "", err.Error())
 		return
 	}
 
@@ -157,7 +179,7 @@ func (h *Handler) ResetCounter(c *gin.Context) {
 			"set_to":     req.SetTo,
 			"admin_user": req.AdminUser,
 		}).Error("Failed to reset counter")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -172,26 +194,26 @@ func (h *Handler) ResetCounter(c *gin.Context) {
 // @Produce json
 // @Param prefix path string true "Prefix identifier"
 // @Success 200 {object} models.PrefixConfig
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/config/{prefix} [get]
 func (h *Handler) GetConfig(c *gin.Context) {
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
 	config, err := h.service.GetConfig(c.Request.Context(), prefix)
 	if err != nil {
 		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to get prefix config")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	if config == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "prefix not found"})
+		respondError(c, service.ErrPrefixNotFound)
 		return
 	}
 
@@ -208,21 +230,21 @@ func (h *Handler) GetConfig(c *gin.Context) {
 // @Param request body models.ConfigUpdateRequest true "Configuration update request"
 // @Security BearerAuth
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/config/{prefix} [post]
 func (h *Handler) UpdateConfig(c *gin.Context) {
-	// TODO: Add authentication middleware
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
 	var req models.ConfigUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, "", err.Error())
 		return
 	}
 
@@ -232,57 +254,705 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 			"prefix":     prefix,
 			"admin_user": req.AdminUser,
 		}).Error("Failed to update prefix config")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "configuration updated successfully"})
 }
 
-// GetAuditLogs retrieves audit logs for a prefix
-// @Summary Get audit logs
-// @Description Get audit logs for a prefix with pagination
-// @Tags audit
+// PreviewFormat renders sample IDs for a proposed format template
+// @Summary Preview format template
+// @Description Render sample IDs for a proposed format template without consuming counter values
+// @Tags configuration
 // @Accept json
 // @Produce json
 // @Param prefix path string true "Prefix identifier"
-// @Param limit query int false "Number of records to return (default: 100)"
-// @Param offset query int false "Number of records to skip (default: 0)"
-// @Success 200 {array} models.AuditLog
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/audit/{prefix} [get]
-func (h *Handler) GetAuditLogs(c *gin.Context) {
+// @Param request body models.FormatPreviewRequest true "Format preview request"
+// @Success 200 {object} models.FormatPreviewResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/config/{prefix}/preview [post]
+func (h *Handler) PreviewFormat(c *gin.Context) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	var req models.FormatPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	resp, err := h.service.PreviewFormat(c.Request.Context(), prefix, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("prefix", prefix).Debug("Failed to preview format template")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetResetHistory returns the recorded counter resets of a prefix
+// @Summary Get counter reset history
+// @Description List counter resets of a prefix with the old and new values, newest first
+// @Tags audit
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param period query string false "Reset period key (e.g. 2026); all periods when omitted"
+// @Param limit query int false "Number of resets to return (default: 50, max: 500)"
+// @Success 200 {array} models.ResetLog
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reset/{prefix}/history [get]
+func (h *Handler) GetResetHistory(c *gin.Context) {
 	prefix := c.Param("prefix")
 	if prefix == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		badRequest(c, "prefix", "prefix is required")
 		return
 	}
 
-	limit := 100
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-			limit = parsedLimit
+	var periodKey *string
+	if period, ok := c.GetQuery("period"); ok {
+		periodKey = &period
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			badRequest(c, "limit", fmt.Sprintf("invalid limit: %q", raw))
+			return
 		}
+		limit = v
 	}
 
-	offset := 0
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+	resets, err := h.service.GetResetHistory(c.Request.Context(), prefix, periodKey, limit)
+	if err != nil {
+		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to get reset history")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resets)
+}
+
+// GetConfigHistory returns the recorded configuration changes of a prefix
+// @Summary Get prefix configuration history
+// @Description List configuration changes of a prefix with the changed fields, newest first
+// @Tags configuration
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param limit query int false "Number of changes to return (default: 50, max: 500)"
+// @Success 200 {array} models.ConfigChange
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/config/{prefix}/history [get]
+func (h *Handler) GetConfigHistory(c *gin.Context) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			badRequest(c, "limit", fmt.Sprintf("invalid limit: %q", raw))
+			return
 		}
+		limit = v
+	}
+
+	changes, err := h.service.GetConfigHistory(c.Request.Context(), prefix, limit)
+	if err != nil {
+		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to get config history")
+		respondError(c, err)
+		return
 	}
 
-	// This would require implementing GetAuditLogs in the service
-	// For now, return a placeholder response
-	c.JSON(http.StatusOK, gin.H{
-		"message": "audit logs endpoint - to be implemented",
-		"prefix":  prefix,
-		"limit":   limit,
-		"offset":  offset,
+	c.JSON(http.StatusOK, changes)
+}
+
+// RollbackConfig restores a previous configuration version (admin operation)
+// @Summary Roll back prefix configuration
+// @Description Restore the settings a prefix had at a version from its history; the rollback is recorded as a new change (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param request body models.ConfigRollbackRequest true "Configuration rollback request"
+// @Security BearerAuth
+// @Success 200 {object} models.PrefixConfig
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/config/{prefix}/rollback [post]
+func (h *Handler) RollbackConfig(c *gin.Context) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	var req models.ConfigRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	config, err := h.service.RollbackConfig(c.Request.Context(), prefix, &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix":  prefix,
+			"version": req.Version,
+		}).Error("Failed to roll back prefix config")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// ListPrefixes lists the configured prefixes
+// @Summary List prefixes
+// @Description List configured prefixes with their current counter and last issued time
+// @Tags configuration
+// @Produce json
+// @Param include_archived query bool false "Include archived prefixes"
+// @Success 200 {array} models.PrefixSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/prefixes [get]
+func (h *Handler) ListPrefixes(c *gin.Context) {
+	includeArchived := false
+	if raw := c.Query("include_archived"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			badRequest(c, "include_archived", fmt.Sprintf("invalid include_archived: %q", raw))
+			return
+		}
+		includeArchived = v
+	}
+
+	prefixes, err := h.service.ListPrefixes(c.Request.Context(), includeArchived)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list prefixes")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prefixes)
+}
+
+// ArchivePrefix archives a prefix (admin operation)
+// @Summary Archive prefix
+// @Description Stop a prefix from issuing new IDs while keeping its configuration and history (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param request body models.PrefixArchiveRequest false "Archive request"
+// @Security BearerAuth
+// @Success 200 {object} models.PrefixConfig
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/prefixes/{prefix}/archive [post]
+func (h *Handler) ArchivePrefix(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// UnarchivePrefix unarchives a prefix (admin operation)
+// @Summary Unarchive prefix
+// @Description Let an archived prefix issue IDs again (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param request body models.PrefixArchiveRequest false "Unarchive request"
+// @Security BearerAuth
+// @Success 200 {object} models.PrefixConfig
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/prefixes/{prefix}/unarchive [post]
+func (h *Handler) UnarchivePrefix(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+// setArchived handles archive and unarchive requests
+func (h *Handler) setArchived(c *gin.Context, archived bool) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	// The body is optional
+	var req models.PrefixArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	var config *models.PrefixConfig
+	var err error
+	if archived {
+		config, err = h.service.ArchivePrefix(c.Request.Context(), prefix, &req)
+	} else {
+		config, err = h.service.UnarchivePrefix(c.Request.Context(), prefix, &req)
+	}
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix":   prefix,
+			"archived": archived,
+		}).Error("Failed to change prefix archive state")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// DeletePrefix deletes a prefix (admin operation)
+// @Summary Delete prefix
+// @Description Delete the configuration of a prefix. Prefixes with issued IDs require force and a reason; counters and audit history are kept (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param request body models.PrefixDeleteRequest false "Delete request"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/prefixes/{prefix} [delete]
+func (h *Handler) DeletePrefix(c *gin.Context) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	// The body is optional
+	var req models.PrefixDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	if err := h.service.DeletePrefix(c.Request.Context(), prefix, &req); err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"prefix": prefix,
+			"force":  req.Force,
+		}).Error("Failed to delete prefix")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "prefix deleted successfully"})
+}
+
+// httpStatus maps service error kinds to HTTP status codes
+var httpStatus = map[service.Kind]int{
+	service.KindInvalidArgument:  http.StatusBadRequest,
+	service.KindNotFound:         http.StatusNotFound,
+	service.KindConflict:         http.StatusConflict,
+	service.KindExpired:          http.StatusGone,
+	service.KindUnprocessable:    http.StatusUnprocessableEntity,
+	service.KindAborted:          http.StatusConflict,
+	service.KindUnavailable:      http.StatusServiceUnavailable,
+	service.KindPermissionDenied: http.StatusForbidden,
+	service.KindCanceled:         statusClientClosedRequest,
+	service.KindDeadlineExceeded: http.StatusGatewayTimeout,
+	service.KindInternal:         http.StatusInternalServerError,
+}
+
+// statusClientClosedRequest is the non-standard status of requests the
+// client canceled, as logged by nginx
+const statusClientClosedRequest = 499
+
+// respondError writes the error envelope for a service error. Internal and
+// Unavailable errors only report the service error's own message, so
+// database and connection details stay in the logs; callers log the full
+// error before responding.
+func respondError(c *gin.Context, err error) {
+	svcErr := service.Classify(err)
+	msg := err.Error()
+	if svcErr.Kind == service.KindInternal || svcErr.Kind == service.KindUnavailable {
+		msg = svcErr.Error()
+	}
+	c.JSON(httpStatus[svcErr.Kind], models.ErrorResponse{
+		Error: msg,
+		Code:  svcErr.Code,
+	})
+}
+
+// badRequest writes the error envelope for a malformed request; field is
+// empty when the body as a whole cannot be parsed
+func badRequest(c *gin.Context, field, msg string) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error: msg,
+		Code:  service.CodeInvalidArgument,
+		Field: field,
 	})
 }
 
+// GetAuditLogs queries audit logs
+// @Summary Query audit logs
+// @Description Query audit logs with filters and cursor pagination (newest first)
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param prefix path string false "Prefix identifier (also accepted as query parameter)"
+// @Param period query string false "Reset period key (e.g. 2026)"
+// @Param counter_from query int false "Minimum counter value (inclusive)"
+// @Param counter_to query int false "Maximum counter value (inclusive)"
+// @Param from query string false "Generated at or after (RFC3339)"
+// @Param to query string false "Generated before (RFC3339)"
+// @Param client_id query string false "Client identifier"
+// @Param generated_by query string false "User or system that generated the ID"
+// @Param batch_id query string false "Batch identifier"
+// @Param correlation_id query string false "Correlation identifier"
+// @Param limit query int false "Number of records to return (default: 100, max: 1000)"
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} models.AuditPage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/audit/{prefix} [get]
+func (h *Handler) GetAuditLogs(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	page, err := h.service.QueryAuditLogs(c.Request.Context(), query)
+	if err != nil {
+		h.logger.WithError(err).WithField("prefix", query.Prefix).Error("Failed to query audit logs")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseAuditQuery builds an audit query from path and query parameters
+func parseAuditQuery(c *gin.Context) (*models.AuditQuery, error) {
+	q := &models.AuditQuery{
+		Prefix:        c.Param("prefix"),
+		ClientID:      c.Query("client_id"),
+		GeneratedBy:   c.Query("generated_by"),
+		BatchID:       c.Query("batch_id"),
+		CorrelationID: c.Query("correlation_id"),
+		Cursor:        c.Query("cursor"),
+	}
+	if q.Prefix == "" {
+		q.Prefix = c.Query("prefix")
+	}
+
+	if period, ok := c.GetQuery("period"); ok {
+		q.PeriodKey = &period
+	}
+
+	for name, dst := range map[string]**int64{"counter_from": &q.CounterFrom, "counter_to": &q.CounterTo} {
+		if raw := c.Query(name); raw != "" {
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", name, raw)
+			}
+			*dst = &v
+		}
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		if raw := c.Query(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: must be an RFC3339 timestamp", name)
+			}
+			*dst = &t
+		}
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit: %q", raw)
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+// LookupID resolves an issued ID by its full number
+// @Summary Look up an issued ID
+// @Description Resolve a full number to its audit entry, batch and reset history. IDs handed out but not yet written to the audit table are reported with status issued_not_audited.
+// @Tags audit
+// @Produce json
+// @Param full_number path string true "Full ID number (e.g. SO000123)"
+// @Success 200 {object} models.IDLookup
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/ids/{full_number} [get]
+func (h *Handler) LookupID(c *gin.Context) {
+	fullNumber := c.Param("full_number")
+	if fullNumber == "" {
+		badRequest(c, "full_number", "full number is required")
+		return
+	}
+
+	lookup, err := h.service.LookupID(c.Request.Context(), fullNumber)
+	if err != nil {
+		h.logger.WithError(err).WithField("full_number", fullNumber).Error("Failed to look up ID")
+		respondError(c, err)
+		return
+	}
+
+	if lookup == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "ID not issued", Code: "ID_NOT_ISSUED"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lookup)
+}
+
+// Reconcile compares counters against the audit log (admin operation)
+// @Summary Reconcile counters with the audit log
+// @Description Report counter values issued by Redis but missing from seq_log and optionally backfill them with "lost" placeholder entries (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.ReconcileRequest true "Reconcile request"
+// @Security BearerAuth
+// @Success 200 {object} models.ReconcileReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reconcile [post]
+func (h *Handler) Reconcile(c *gin.Context) {
+	var req models.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	report, err := h.service.Reconcile(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"prefixes": req.Prefixes,
+			"fix_gaps": req.FixGaps,
+		}).Error("Failed to reconcile counters")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListDeadLetters lists dead-lettered audit events (admin operation)
+// @Summary List dead-lettered audit events
+// @Description Inspect the oldest messages of the dead letter queue with the error that stopped each; messages stay queued (requires admin authentication)
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Number of queued messages to inspect (default: 100, max: 10000)"
+// @Security BearerAuth
+// @Success 200 {object} models.DeadLetterList
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/dlq [get]
+func (h *Handler) ListDeadLetters(c *gin.Context) {
+	var q models.DeadLetterQuery
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			badRequest(c, "limit", fmt.Sprintf("invalid limit: %q", raw))
+			return
+		}
+		q.Limit = v
+	}
+
+	list, err := h.service.ListDeadLetters(c.Request.Context(), &q)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list dead letters")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ReplayDeadLetters republishes dead-lettered audit events (admin operation)
+// @Summary Replay dead-lettered audit events
+// @Description Publish the selected messages of the dead letter queue back to the main exchange with a fresh retry count; the replay is recorded in seq_dlq_audit (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.DeadLetterRequest true "Dead letter request"
+// @Security BearerAuth
+// @Success 200 {object} models.DeadLetterResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/dlq/replay [post]
+func (h *Handler) ReplayDeadLetters(c *gin.Context) {
+	var req models.DeadLetterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	result, err := h.service.ReplayDeadLetters(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"message_ids": req.MessageIDs,
+			"all":         req.All,
+		}).Error("Failed to replay dead letters")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PurgeDeadLetters discards dead-lettered audit events (admin operation)
+// @Summary Purge dead-lettered audit events
+// @Description Discard the selected messages of the dead letter queue; the purge is recorded in seq_dlq_audit with its reason (requires admin authentication)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.DeadLetterRequest true "Dead letter request"
+// @Security BearerAuth
+// @Success 200 {object} models.DeadLetterResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/dlq/purge [post]
+func (h *Handler) PurgeDeadLetters(c *gin.Context) {
+	var req models.DeadLetterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	result, err := h.service.PurgeDeadLetters(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"message_ids": req.MessageIDs,
+			"all":         req.All,
+		}).Error("Failed to purge dead letters")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Reserve leases the next number of a gapless prefix
+// @Summary Reserve a gapless number
+// @Description Reserve a provisional number of a gapless prefix. The number is issued only once committed; released or expired numbers are reserved again before the counter advances.
+// @Tags gapless
+// @Accept json
+// @Produce json
+// @Param prefix path string true "Prefix identifier"
+// @Param request body models.ReserveRequest false "Reserve request"
+// @Success 200 {object} models.Reservation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reserve/{prefix} [post]
+func (h *Handler) Reserve(c *gin.Context) {
+	prefix := c.Param("prefix")
+	if prefix == "" {
+		badRequest(c, "prefix", "prefix is required")
+		return
+	}
+
+	// The body is optional
+	var req models.ReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, "", err.Error())
+		return
+	}
+
+	req.Prefix = prefix // Override with path parameter
+
+	res, err := h.service.Reserve(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("prefix", prefix).Error("Failed to reserve gapless number")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// CommitReservation issues a reserved number
+// @Summary Commit a reservation
+// @Description Commit a reserved number of a gapless prefix and record it in the audit log
+// @Tags gapless
+// @Produce json
+// @Param reservation_id path string true "Reservation identifier"
+// @Success 200 {object} models.SequentialID
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 410 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reservations/{reservation_id}/commit [post]
+func (h *Handler) CommitReservation(c *gin.Context) {
+	reservationID := c.Param("reservation_id")
+
+	seqID, err := h.service.CommitReservation(c.Request.Context(), reservationID)
+	if err != nil {
+		h.logger.WithError(err).WithField("reservation_id", reservationID).Error("Failed to commit reservation")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, seqID)
+}
+
+// ReleaseReservation returns a reserved number to the pool
+// @Summary Release a reservation
+// @Description Release a reserved number of a gapless prefix so it is reserved again by the next caller
+// @Tags gapless
+// @Produce json
+// @Param reservation_id path string true "Reservation identifier"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 410 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reservations/{reservation_id}/release [post]
+func (h *Handler) ReleaseReservation(c *gin.Context) {
+	reservationID := c.Param("reservation_id")
+
+	if err := h.service.ReleaseReservation(c.Request.Context(), reservationID); err != nil {
+		h.logger.WithError(err).WithField("reservation_id", reservationID).Error("Failed to release reservation")
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reservation released"})
+}
+
 // HealthCheck returns service health status
 // @Summary Health check
 // @Description Get the health status of the service and its components
@@ -301,17 +971,3 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 		c.JSON(http.StatusServiceUnavailable, health)
 	}
 }
-
-// Metrics returns service metrics (for Prometheus)
-// @Summary Get metrics
-// @Description Get service metrics in Prometheus format
-// @Tags monitoring
-// @Produce text/plain
-// @Success 200 {string} string "Prometheus metrics"
-// @Router /metrics [get]
-func (h *Handler) Metrics(c *gin.Context) {
-	// This would integrate with Prometheus metrics
-	// For now, return a placeholder
-	c.Header("Content-Type", "text/plain")
-	c.String(http.StatusOK, "# Metrics endpoint - to be implemented with Prometheus client\n")
-}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+)
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbDown := errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"invalid argument", fmt.Errorf("%w: count must be between 1 and 1000", service.ErrInvalidCount), http.StatusBadRequest, "INVALID_COUNT", "invalid count: count must be between 1 and 1000"},
+		{"not found", fmt.Errorf("%w: SG", service.ErrPrefixNotFound), http.StatusNotFound, "PREFIX_NOT_CONFIGURED", "prefix not found: SG"},
+		{"conflict", fmt.Errorf("%w: SG", service.ErrPrefixExists), http.StatusConflict, "PREFIX_EXISTS", "prefix already exists: SG"},
+		{"expired", service.ErrReservationExpired, http.StatusGone, "RESERVATION_EXPIRED", "reservation expired or released"},
+		{"unprocessable", service.ErrIdempotencyConflict, http.StatusUnprocessableEntity, service.ErrIdempotencyConflict.Code, service.ErrIdempotencyConflict.Error()},
+		{"aborted", service.ErrIdempotencyInProgress, http.StatusConflict, service.ErrIdempotencyInProgress.Code, service.ErrIdempotencyInProgress.Error()},
+		{"permission denied", fmt.Errorf("prefix SG: %w", auth.ErrForbidden), http.StatusForbidden, "PERMISSION_DENIED", "prefix SG: " + auth.ErrForbidden.Error()},
+		{"canceled", context.Canceled, statusClientClosedRequest, "CANCELED", context.Canceled.Error()},
+		{"deadline exceeded", context.DeadlineExceeded, http.StatusGatewayTimeout, "DEADLINE_EXCEEDED", context.DeadlineExceeded.Error()},
+		// Store failures and unexpected errors must not leak their cause
+		{"unavailable", fmt.Errorf("%w: failed to get prefix config: %w", service.ErrConfigStoreUnavailable, dbDown), http.StatusServiceUnavailable, "CONFIG_STORE_UNAVAILABLE", "config store unavailable"},
+		{"internal", fmt.Errorf("failed to scan row: %w", dbDown), http.StatusInternalServerError, "INTERNAL", "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			respondError(c, tt.err)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+			if body.Code != tt.code || body.Error != tt.message {
+				t.Errorf("body = %+v, want code %s and message %q", body, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusCoversEveryKind(t *testing.T) {
+	for kind := service.KindInternal; kind <= service.KindDeadlineExceeded; kind++ {
+		if _, ok := httpStatus[kind]; !ok {
+			t.Errorf("no HTTP status for kind %d", kind)
+		}
+	}
+}
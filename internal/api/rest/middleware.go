@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// errUnauthenticated is the response to requests without valid credentials
+var errUnauthenticated = models.ErrorResponse{Error: "authentication required", Code: "UNAUTHENTICATED"}
+
+// AuthMiddleware authenticates REST requests and enforces roles. With a nil
+// authenticator (authentication disabled) every check passes.
+type AuthMiddleware struct {
+	authenticator auth.Authenticator
+	logger        *logrus.Logger
+}
+
+// NewAuthMiddleware creates a new authentication middleware
+func NewAuthMiddleware(authenticator auth.Authenticator, logger *logrus.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+// Authenticate resolves the caller from the Authorization or X-API-Key
+// header and stores the principal in the request context
+func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.authenticator == nil {
+			c.Next()
+			return
+		}
+
+		creds := auth.ParseAuthorization(c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		principal, err := m.authenticator.Authenticate(c.Request.Context(), creds)
+		if err != nil {
+			m.logger.WithError(err).WithFields(logrus.Fields{
+				"path":      c.FullPath(),
+				"client_ip": c.ClientIP(),
+			}).Warn("Authentication failed")
+			c.Header("WWW-Authenticate", `Bearer realm="sequential-id"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errUnauthenticated)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// Require rejects requests whose principal holds none of the roles
+func (m *AuthMiddleware) Require(roles ...auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.authenticator == nil {
+			c.Next()
+			return
+		}
+
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errUnauthenticated)
+			return
+		}
+
+		if err := principal.Authorize(roles...); err != nil {
+			m.logger.WithError(err).WithFields(logrus.Fields{
+				"path":    c.FullPath(),
+				"subject": principal.Subject,
+			}).Warn("Authorization failed")
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  service.Classify(err).Code,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+)
+
+// APIKeyAuthenticator authenticates callers by static API keys
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	digest    [sha256.Size]byte
+	principal Principal
+}
+
+// NewAPIKeyAuthenticator creates an authenticator for the configured keys
+func NewAPIKeyAuthenticator(keys []config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+
+	for i, k := range keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("api key %d: key is empty", i)
+		}
+		if k.Subject == "" {
+			return nil, fmt.Errorf("api key %d: subject is required", i)
+		}
+
+		roles, err := parseRoles(k.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", k.Subject, err)
+		}
+
+		if err := ValidatePrefixPatterns(k.Prefixes); err != nil {
+			return nil, fmt.Errorf("api key %s: %w", k.Subject, err)
+		}
+
+		tenant, err := ResolveTenant(k.Tenant)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", k.Subject, err)
+		}
+
+		a.keys = append(a.keys, apiKey{
+			digest: sha256.Sum256([]byte(k.Key)),
+			principal: Principal{
+				Subject:  k.Subject,
+				Method:   "api_key",
+				Roles:    roles,
+				Tenant:   tenant,
+				Prefixes: k.Prefixes,
+			},
+		})
+	}
+
+	return a, nil
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.APIKey == "" {
+		return nil, fmt.Errorf("%w: API key is required", ErrUnauthenticated)
+	}
+
+	// Compare digests in constant time so key length and content do not leak
+	digest := sha256.Sum256([]byte(creds.APIKey))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest[:]) == 1 {
+			p := k.principal
+			return &p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: invalid API key", ErrUnauthenticated)
+}
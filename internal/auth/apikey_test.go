@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+func TestAPIKeyTenants(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Key: "k1", Subject: "billing", Roles: []string{"generator"}, Tenant: "acme"},
+		{Key: "k2", Subject: "legacy", Roles: []string{"generator"}},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator: %v", err)
+	}
+
+	for key, want := range map[string]string{"k1": "acme", "k2": models.DefaultTenant} {
+		p, err := a.Authenticate(context.Background(), Credentials{APIKey: key})
+		if err != nil {
+			t.Fatalf("Authenticate(%s): %v", key, err)
+		}
+		if p.Tenant != want {
+			t.Errorf("tenant of %s = %q, want %q", p.Subject, p.Tenant, want)
+		}
+	}
+
+	for _, tenant := range []string{"acme:eu", "-acme", "a cme"} {
+		_, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{{Key: "k", Subject: "s", Roles: []string{"generator"}, Tenant: tenant}})
+		if err == nil {
+			t.Errorf("NewAPIKeyAuthenticator accepted tenant %q", tenant)
+		}
+	}
+}
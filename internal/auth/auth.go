@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// Role grants access to a group of operations
+type Role string
+
+// Supported roles. Admin implies every other role.
+const (
+	RoleGenerator Role = "generator" // may generate IDs and read counter status
+	RoleAuditor   Role = "auditor"   // may read configuration, status and audit data
+	RoleAdmin     Role = "admin"     // may reset counters and change configuration
+)
+
+var (
+	// ErrUnauthenticated is returned when credentials are missing or invalid
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the principal lacks a required role or prefix scope
+	ErrForbidden = errors.New("permission denied")
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject string // Stable caller identity, recorded in audit tables
+	Method  string // Authentication method ("api_key" or "jwt")
+	Roles   []Role
+	// Tenant namespaces every prefix the principal works with
+	Tenant string
+	// Prefixes restricts ID generation to matching prefixes. Entries may use
+	// wildcards ("PO*", "*"); an empty list places no restriction.
+	Prefixes []string
+}
+
+// HasAnyRole reports whether the principal holds one of the roles
+func (p *Principal) HasAnyRole(roles ...Role) bool {
+	for _, held := range p.Roles {
+		if held == RoleAdmin {
+			return true
+		}
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize checks that the principal holds one of the roles
+func (p *Principal) Authorize(roles ...Role) error {
+	if p.HasAnyRole(roles...) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s requires one of the roles %v", ErrForbidden, p.Subject, roles)
+}
+
+// CanGenerate reports whether the principal may draw IDs for the prefix
+func (p *Principal) CanGenerate(prefix string) bool {
+	if len(p.Prefixes) == 0 {
+		return true
+	}
+	for _, pattern := range p.Prefixes {
+		if ok, _ := path.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizePrefix checks that the principal may draw IDs for the prefix
+func (p *Principal) AuthorizePrefix(prefix string) error {
+	if p.CanGenerate(prefix) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s may not generate IDs for prefix %s", ErrForbidden, p.Subject, prefix)
+}
+
+// ValidatePrefixPatterns checks that prefix allow-list entries are valid patterns
+func ValidatePrefixPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("empty prefix pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid prefix pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// tenantPattern matches valid tenant names. Tenants are part of counter
+// names, so they may not contain ':'.
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,49}$`)
+
+// ResolveTenant validates a configured or claimed tenant name. An empty name
+// resolves to the default tenant.
+func ResolveTenant(name string) (string, error) {
+	if name == "" {
+		return models.DefaultTenant, nil
+	}
+	if !tenantPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid tenant %q", name)
+	}
+	return name, nil
+}
+
+// Credentials are the raw credentials presented by a caller
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+}
+
+// Empty reports whether no credentials were presented
+func (c Credentials) Empty() bool {
+	return c.APIKey == "" && c.BearerToken == ""
+}
+
+// ParseAuthorization extracts credentials from an Authorization header value
+// and an explicit API key header value
+func ParseAuthorization(authorization, apiKey string) Credentials {
+	creds := Credentials{APIKey: strings.TrimSpace(apiKey)}
+
+	scheme, value, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok {
+		return creds
+	}
+
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		creds.BearerToken = strings.TrimSpace(value)
+	case "apikey":
+		creds.APIKey = strings.TrimSpace(value)
+	}
+
+	return creds
+}
+
+// Authenticator resolves credentials to a principal
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (*Principal, error)
+}
+
+// chain tries the API key and JWT authenticators according to the
+// credentials presented
+type chain struct {
+	apiKeys *APIKeyAuthenticator
+	jwt     *JWTAuthenticator
+}
+
+// New builds the authenticator described by the configuration. It returns
+// nil when authentication is disabled.
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	c := &chain{}
+
+	keys := cfg.APIKeys
+	if cfg.APIKey != "" {
+		keys = append(keys, config.APIKeyConfig{Key: cfg.APIKey, Subject: "api-key", Roles: []string{string(RoleAdmin)}})
+	}
+	if len(keys) > 0 {
+		apiKeys, err := NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			return nil, err
+		}
+		c.apiKeys = apiKeys
+	}
+
+	if cfg.JWKSFile != "" {
+		jwtAuth, err := NewJWTAuthenticator(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.jwt = jwtAuth
+	}
+
+	return c, nil
+}
+
+// Authenticate implements Authenticator
+func (c *chain) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	switch {
+	case creds.Empty():
+		return nil, fmt.Errorf("%w: no credentials presented", ErrUnauthenticated)
+	case creds.BearerToken != "" && c.jwt != nil && strings.Count(creds.BearerToken, ".") == 2:
+		return c.jwt.Authenticate(ctx, creds)
+	case c.apiKeys != nil:
+		// Bearer tokens that are not JWTs are treated as API keys
+		if creds.APIKey == "" {
+			creds.APIKey = creds.BearerToken
+		}
+		return c.apiKeys.Authenticate(ctx, creds)
+	default:
+		return nil, fmt.Errorf("%w: unsupported credentials", ErrUnauthenticated)
+	}
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored in the context, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// parseRoles converts configured role names, rejecting unknown ones
+func parseRoles(names []string) ([]Role, error) {
+	roles := make([]Role, 0, len(names))
+	for _, name := range names {
+		role := Role(strings.ToLower(strings.TrimSpace(name)))
+		switch role {
+		case RoleGenerator, RoleAuditor, RoleAdmin:
+			roles = append(roles, role)
+		default:
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+)
+
+// JWTAuthenticator verifies bearer JWTs against keys from a local JWKS file
+type JWTAuthenticator struct {
+	keys          map[string]interface{}
+	parser        *jwt.Parser
+	rolesClaim    string
+	prefixesClaim string
+	tenantClaim   string
+}
+
+// NewJWTAuthenticator loads the JWKS file and creates the authenticator
+func NewJWTAuthenticator(cfg config.AuthConfig) (*JWTAuthenticator, error) {
+	keys, err := loadJWKS(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	rolesClaim := cfg.JWTRolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+
+	prefixesClaim := cfg.JWTPrefixesClaim
+	if prefixesClaim == "" {
+		prefixesClaim = "prefixes"
+	}
+
+	tenantClaim := cfg.JWTTenantClaim
+	if tenantClaim == "" {
+		tenantClaim = "tenant"
+	}
+
+	return &JWTAuthenticator{
+		keys:          keys,
+		parser:        jwt.NewParser(opts...),
+		rolesClaim:    rolesClaim,
+		prefixesClaim: prefixesClaim,
+		tenantClaim:   tenantClaim,
+	}, nil
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.BearerToken == "" {
+		return nil, fmt.Errorf("%w: bearer token is required", ErrUnauthenticated)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(creds.BearerToken, claims, a.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	prefixes := claimStrings(claims[a.prefixesClaim])
+	if err := ValidatePrefixPatterns(prefixes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	claimedTenant, _ := claims[a.tenantClaim].(string)
+	tenant, err := ResolveTenant(claimedTenant)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	return &Principal{
+		Subject:  subject,
+		Method:   "jwt",
+		Roles:    claimRoles(claims[a.rolesClaim]),
+		Tenant:   tenant,
+		Prefixes: prefixes,
+	}, nil
+}
+
+// keyFunc selects the verification key by the token's kid header
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(a.keys) != 1 {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+		for _, key := range a.keys {
+			return key, nil
+		}
+	}
+
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// claimStrings reads a space separated string or string array claim
+func claimStrings(claim interface{}) []string {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// claimRoles reads roles from a string or string array claim, ignoring
+// roles this service does not know about
+func claimRoles(claim interface{}) []Role {
+	var roles []Role
+	for _, name := range claimStrings(claim) {
+		if parsed, err := parseRoles([]string{name}); err == nil {
+			roles = append(roles, parsed...)
+		}
+	}
+	return roles
+}
+
+// jwk is the subset of RFC 7517 fields needed for RSA and EC public keys
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadJWKS reads the public keys of a JWKS document indexed by kid
+func loadJWKS(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var key interface{}
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			err = fmt.Errorf("unsupported key type %q", k.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%s): %w", i, k.Kid, err)
+		}
+
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s contains no signing keys", path)
+	}
+
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil || !e.IsInt64() {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable pointing at an optional
+// YAML or TOML configuration file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Config holds the service configuration. Values are layered: built-in
+// defaults, then the optional configuration file, then environment variables.
+type Config struct {
+	Environment string `yaml:"environment" toml:"environment" env:"ENVIRONMENT"`
+	LogLevel    string `yaml:"log_level" toml:"log_level" env:"LOG_LEVEL"`
+	Port        string `yaml:"port" toml:"port" env:"PORT"`
+	GRPCPort    string `yaml:"grpc_port" toml:"grpc_port" env:"GRPC_PORT"`
+	HealthPort  string `yaml:"health_port" toml:"health_port" env:"HEALTH_CHECK_PORT"`
+	MetricsPort string `yaml:"metrics_port" toml:"metrics_port" env:"METRICS_PORT"`
+
+	// GRPCReflection enables gRPC server reflection for tools like grpcurl
+	GRPCReflection bool `yaml:"grpc_reflection" toml:"grpc_reflection" env:"GRPC_REFLECTION"`
+	// HealthInterval is how often the gRPC health service re-checks components
+	HealthInterval time.Duration `yaml:"health_interval" toml:"health_interval" env:"HEALTH_CHECK_INTERVAL"`
+
+	Counter  CounterConfig  `yaml:"counter" toml:"counter"`
+	Redis    RedisConfig    `yaml:"redis" toml:"redis"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	RabbitMQ RabbitMQConfig `yaml:"rabbitmq" toml:"rabbitmq"`
+	Worker   WorkerConfig   `yaml:"worker" toml:"worker"`
+	Outbox   OutboxConfig   `yaml:"outbox" toml:"outbox"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Gapless     GaplessConfig     `yaml:"gapless" toml:"gapless"`
+	ConfigCache ConfigCacheConfig `yaml:"config_cache" toml:"config_cache"`
+}
+
+// Counter backends selectable with CounterConfig.Backend
+const (
+	CounterBackendRedis    = "redis"
+	CounterBackendPostgres = "postgres"
+)
+
+// CounterConfig selects where counters and idempotency records are kept
+type CounterConfig struct {
+	Backend string `yaml:"backend" toml:"backend" env:"COUNTER_BACKEND"`
+}
+
+// RedisConfig holds Redis connection settings
+type RedisConfig struct {
+	URL         string `yaml:"url" toml:"url" env:"REDIS_URL"`
+	Password    string `yaml:"password" toml:"password" env:"REDIS_PASSWORD"`
+	DB          int    `yaml:"db" toml:"db" env:"REDIS_DB"`
+	ClusterMode bool   `yaml:"cluster_mode" toml:"cluster_mode" env:"REDIS_CLUSTER_MODE"`
+}
+
+// DatabaseConfig holds PostgreSQL connection settings
+type DatabaseConfig struct {
+	URL          string `yaml:"url" toml:"url" env:"DB_URL"`
+	MaxOpenConns int    `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns int    `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+}
+
+// RabbitMQConfig holds RabbitMQ connection settings
+type RabbitMQConfig struct {
+	URL      string `yaml:"url" toml:"url" env:"RABBITMQ_URL"`
+	Exchange string `yaml:"exchange" toml:"exchange" env:"RABBITMQ_EXCHANGE"`
+	Queue    string `yaml:"queue" toml:"queue" env:"RABBITMQ_QUEUE"`
+}
+
+// WorkerConfig holds audit worker settings
+type WorkerConfig struct {
+	// Concurrency is the number of events processed at once; it is also
+	// the consumer's prefetch count
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"WORKER_CONCURRENCY"`
+	// MaxRetries is how often a failed event is retried before it is
+	// dead-lettered
+	MaxRetries int `yaml:"max_retries" toml:"max_retries" env:"WORKER_MAX_RETRIES"`
+	// RetryBaseDelay is the delay before the first retry; it doubles with
+	// every further retry up to RetryMaxDelay
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay" toml:"retry_base_delay" env:"WORKER_RETRY_BASE_DELAY"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay" toml:"retry_max_delay" env:"WORKER_RETRY_MAX_DELAY"`
+}
+
+// OutboxConfig holds settings of the relay that drains the audit outbox
+// into RabbitMQ
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	// Lease is how long a claimed event is hidden from other relays
+	Lease      time.Duration `yaml:"lease" toml:"lease" env:"OUTBOX_LEASE"`
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"OUTBOX_MAX_BACKOFF"`
+}
+
+// IdempotencyConfig holds settings for replaying requests by idempotency key
+type IdempotencyConfig struct {
+	// TTL is how long a completed response is kept for replay
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL"`
+}
+
+// GaplessConfig holds lease settings for reservations of gapless prefixes
+type GaplessConfig struct {
+	DefaultLease time.Duration `yaml:"default_lease" toml:"default_lease" env:"GAPLESS_DEFAULT_LEASE"`
+	MaxLease     time.Duration `yaml:"max_lease" toml:"max_lease" env:"GAPLESS_MAX_LEASE"`
+}
+
+// ConfigCacheConfig holds settings of the in-process prefix config cache
+type ConfigCacheConfig struct {
+	// TTL is how long a loaded config is used; 0 disables the cache
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"CONFIG_CACHE_TTL"`
+	// MaxStale is how long past the TTL a config is served while the
+	// database is unavailable
+	MaxStale time.Duration `yaml:"max_stale" toml:"max_stale" env:"CONFIG_CACHE_MAX_STALE"`
+}
+
+// AuthConfig holds authentication settings shared by REST and gRPC
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"AUTH_ENABLED"`
+	// APIKey is a single admin key, kept for deployments that only set API_KEY
+	APIKey  string         `yaml:"api_key" toml:"api_key" env:"API_KEY"`
+	APIKeys []APIKeyConfig `yaml:"api_keys" toml:"api_keys"`
+
+	JWKSFile      string `yaml:"jwks_file" toml:"jwks_file" env:"AUTH_JWKS_FILE"`
+	JWTIssuer     string `yaml:"jwt_issuer" toml:"jwt_issuer" env:"AUTH_JWT_ISSUER"`
+	JWTAudience   string `yaml:"jwt_audience" toml:"jwt_audience" env:"AUTH_JWT_AUDIENCE"`
+	JWTRolesClaim string `yaml:"jwt_roles_claim" toml:"jwt_roles_claim" env:"AUTH_JWT_ROLES_CLAIM"`
+	// JWTPrefixesClaim names the claim holding the prefix allow-list
+	JWTPrefixesClaim string `yaml:"jwt_prefixes_claim" toml:"jwt_prefixes_claim" env:"AUTH_JWT_PREFIXES_CLAIM"`
+	// JWTTenantClaim names the claim holding the caller's tenant
+	JWTTenantClaim string `yaml:"jwt_tenant_claim" toml:"jwt_tenant_claim" env:"AUTH_JWT_TENANT_CLAIM"`
+}
+
+// APIKeyConfig describes a static API key and the principal it maps to
+type APIKeyConfig struct {
+	Key     string   `yaml:"key" toml:"key"`
+	Subject string   `yaml:"subject" toml:"subject"`
+	Roles   []string `yaml:"roles" toml:"roles"`
+	// Prefixes limits ID generation to matching prefixes (wildcards allowed)
+	Prefixes []string `yaml:"prefixes" toml:"prefixes"`
+	// Tenant namespaces the prefixes of the key; empty means the default tenant
+	Tenant string `yaml:"tenant" toml:"tenant"`
+}
+
+// Default returns the built-in configuration defaults
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		LogLevel:    "info",
+		Port:        "8080",
+		GRPCPort:    "9090",
+		HealthPort:  "8081",
+		MetricsPort: "2112",
+
+		GRPCReflection: true,
+		HealthInterval: 10 * time.Second,
+
+		Counter: CounterConfig{
+			Backend: CounterBackendRedis,
+		},
+		Redis: RedisConfig{
+			URL: "redis://localhost:6379",
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+		},
+		RabbitMQ: RabbitMQConfig{
+			Exchange: "seq_exchange",
+			Queue:    "seq_log_queue",
+		},
+		Worker: WorkerConfig{
+			Concurrency:    5,
+			MaxRetries:     5,
+			RetryBaseDelay: time.Second,
+			RetryMaxDelay:  5 * time.Minute,
+		},
+		Outbox: OutboxConfig{
+			PollInterval: 200 * time.Millisecond,
+			BatchSize:    100,
+			Lease:        30 * time.Second,
+			MaxBackoff:   time.Minute,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Gapless: GaplessConfig{
+			DefaultLease: 5 * time.Minute,
+			MaxLease:     time.Hour,
+		},
+		ConfigCache: ConfigCacheConfig{
+			TTL:      30 * time.Second,
+			MaxStale: time.Hour,
+		},
+		Auth: AuthConfig{
+			JWTRolesClaim:    "roles",
+			JWTPrefixesClaim: "prefixes",
+			JWTTenantClaim:   "tenant",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the file named by CONFIG_FILE
+// (if set) and environment variables, and validates the result
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv(ConfigFileEnv))
+}
+
+// LoadFile is like Load but reads the given configuration file. An empty
+// path skips the file layer.
+func LoadFile(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := readFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	problems = append(problems, applyEnv(cfg, os.LookupEnv)...)
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// readFile decodes a YAML or TOML file, chosen by extension, over cfg
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Unknown keys are rejected so typos do not silently fall back to defaults
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	default:
+		return fmt.Errorf("unsupported config file format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ValidationError reports every invalid setting found while loading
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// validate checks the loaded configuration and returns every problem found
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		add("log_level: %v", err)
+	}
+
+	ports := map[string]string{}
+	for _, p := range []struct{ name, value string }{
+		{"port", c.Port},
+		{"grpc_port", c.GRPCPort},
+		{"health_port", c.HealthPort},
+		{"metrics_port", c.MetricsPort},
+	} {
+		n, err := strconv.Atoi(p.value)
+		if err != nil || n < 1 || n > 65535 {
+			add("%s: %q is not a valid port", p.name, p.value)
+			continue
+		}
+		if other, ok := ports[p.value]; ok {
+			add("%s: port %s is already used by %s", p.name, p.value, other)
+			continue
+		}
+		ports[p.value] = p.name
+	}
+
+	if c.HealthInterval <= 0 {
+		add("health_interval: must be positive")
+	}
+
+	switch c.Counter.Backend {
+	case CounterBackendRedis:
+		if c.Redis.ClusterMode {
+			// Cluster mode takes a plain host:port seed address
+			if c.Redis.URL == "" {
+				add("redis.url: is required")
+			}
+		} else if err := validateURL(c.Redis.URL, "redis", "rediss"); err != nil {
+			add("redis.url: %v", err)
+		}
+		if c.Redis.DB < 0 {
+			add("redis.db: must not be negative")
+		}
+	case CounterBackendPostgres:
+		// Redis settings are unused
+	default:
+		add("counter.backend: %q is not one of %q, %q", c.Counter.Backend, CounterBackendRedis, CounterBackendPostgres)
+	}
+
+	if err := validateURL(c.Database.URL, "postgres", "postgresql"); err != nil {
+		add("database.url: %v", err)
+	}
+	if c.Database.MaxOpenConns < 1 {
+		add("database.max_open_conns: must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns: must be between 0 and max_open_conns")
+	}
+
+	if err := validateURL(c.RabbitMQ.URL, "amqp", "amqps"); err != nil {
+		add("rabbitmq.url: %v", err)
+	}
+	if c.RabbitMQ.Exchange == "" {
+		add("rabbitmq.exchange: is required")
+	}
+	if c.RabbitMQ.Queue == "" {
+		add("rabbitmq.queue: is required")
+	}
+
+	if c.Worker.Concurrency < 1 {
+		add("worker.concurrency: must be at least 1")
+	}
+	if c.Worker.MaxRetries < 0 {
+		add("worker.max_retries: must not be negative")
+	}
+	if c.Worker.RetryBaseDelay <= 0 {
+		add("worker.retry_base_delay: must be positive")
+	}
+	if c.Worker.RetryMaxDelay < c.Worker.RetryBaseDelay {
+		add("worker.retry_max_delay: must not be shorter than retry_base_delay")
+	}
+
+	if c.Outbox.PollInterval <= 0 {
+		add("outbox.poll_interval: must be positive")
+	}
+	if c.Outbox.BatchSize < 1 {
+		add("outbox.batch_size: must be at least 1")
+	}
+	if c.Outbox.Lease <= 0 {
+		add("outbox.lease: must be positive")
+	}
+	if c.Outbox.MaxBackoff < c.Outbox.PollInterval {
+		add("outbox.max_backoff: must not be shorter than poll_interval")
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		add("idempotency.ttl: must be positive")
+	}
+
+	if c.Gapless.DefaultLease <= 0 {
+		add("gapless.default_lease: must be positive")
+	}
+	if c.Gapless.MaxLease < c.Gapless.DefaultLease {
+		add("gapless.max_lease: must not be shorter than default_lease")
+	}
+
+	if c.ConfigCache.TTL < 0 {
+		add("config_cache.ttl: must not be negative")
+	}
+	if c.ConfigCache.MaxStale < 0 {
+		add("config_cache.max_stale: must not be negative")
+	}
+
+	if c.Auth.Enabled {
+		if c.Auth.APIKey == "" && len(c.Auth.APIKeys) == 0 && c.Auth.JWKSFile == "" {
+			add("auth: enabled but neither api keys nor jwks_file are configured")
+		}
+		for i, k := range c.Auth.APIKeys {
+			if k.Key == "" || k.Subject == "" || len(k.Roles) == 0 {
+				add("auth.api_keys[%d]: key, subject and roles are required", i)
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateURL checks that raw is a non-empty URL with one of the schemes
+func validateURL(raw string, schemes ...string) error {
+	if raw == "" {
+		return fmt.Errorf("is required")
+	}
+
+	// Parse errors are not wrapped since they would echo credentials
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL")
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported scheme %q (expected %v)", u.Scheme, schemes)
+}
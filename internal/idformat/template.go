@@ -0,0 +1,334 @@
+package idformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported tokens:
+//
+//	{prefix}     the prefix identifier
+//	{seq}        the counter, zero-padded to the configured padding length
+//	{seq:N}      the counter, zero-padded to N digits
+//	{yyyy} {yy}  year of generation (4 or 2 digits)
+//	{mm} {dd}    month and day of generation (2 digits)
+//	{checksum}   Luhn check digit over all digits rendered before it
+//
+// Literal braces are written as "{{" and "}}". Dates are rendered in UTC,
+// matching the boundaries used for counter reset periods.
+
+// MaxSeqWidth is the largest padding accepted for the sequence token
+const MaxSeqWidth = 19
+
+type tokenKind int
+
+const (
+	tokenLiteral tokenKind = iota
+	tokenPrefix
+	tokenSeq
+	tokenYear
+	tokenYearShort
+	tokenMonth
+	tokenDay
+	tokenChecksum
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // literal text
+	width int    // explicit width for {seq:N}, 0 means configured padding
+}
+
+// Template is a parsed ID format template
+type Template struct {
+	source string
+	tokens []token
+}
+
+// Values carries the inputs used to render an ID
+type Values struct {
+	Prefix  string
+	Counter int64
+	Padding int
+	Time    time.Time
+}
+
+// ParseError describes an invalid template
+type ParseError struct {
+	Template string
+	Pos      int
+	Msg      string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid format template %q at position %d: %s", e.Template, e.Pos, e.Msg)
+}
+
+// Parse parses and validates a format template
+func Parse(source string) (*Template, error) {
+	if source == "" {
+		return nil, &ParseError{Template: source, Msg: "template is empty"}
+	}
+
+	t := &Template{source: source}
+	var literal strings.Builder
+	seqCount := 0
+	checksumSeen := false
+
+	flush := func() {
+		if literal.Len() > 0 {
+			t.tokens = append(t.tokens, token{kind: tokenLiteral, text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+
+		switch c {
+		case '}':
+			if i+1 < len(source) && source[i+1] == '}' {
+				literal.WriteByte('}')
+				i++
+				continue
+			}
+			return nil, &ParseError{Template: source, Pos: i, Msg: "unexpected '}' (use '}}' for a literal brace)"}
+		case '{':
+			if i+1 < len(source) && source[i+1] == '{' {
+				literal.WriteByte('{')
+				i++
+				continue
+			}
+
+			end := strings.IndexByte(source[i:], '}')
+			if end < 0 {
+				return nil, &ParseError{Template: source, Pos: i, Msg: "unterminated token"}
+			}
+
+			tok, err := parseToken(source, i, source[i+1:i+end])
+			if err != nil {
+				return nil, err
+			}
+
+			switch tok.kind {
+			case tokenSeq:
+				seqCount++
+				if seqCount > 1 {
+					return nil, &ParseError{Template: source, Pos: i, Msg: "{seq} may only appear once"}
+				}
+			case tokenChecksum:
+				if checksumSeen {
+					return nil, &ParseError{Template: source, Pos: i, Msg: "{checksum} may only appear once"}
+				}
+				if seqCount == 0 {
+					return nil, &ParseError{Template: source, Pos: i, Msg: "{checksum} must follow {seq}"}
+				}
+				checksumSeen = true
+			}
+
+			flush()
+			t.tokens = append(t.tokens, tok)
+			i += end
+		default:
+			if c == '%' {
+				return nil, &ParseError{Template: source, Pos: i, Msg: "printf verbs are not supported, use named tokens such as {prefix}{seq:6}"}
+			}
+			literal.WriteByte(c)
+		}
+	}
+	flush()
+
+	if seqCount == 0 {
+		return nil, &ParseError{Template: source, Pos: len(source), Msg: "template must contain a {seq} token"}
+	}
+
+	return t, nil
+}
+
+// parseToken parses the body of a {...} token starting at pos
+func parseToken(source string, pos int, body string) (token, error) {
+	name, arg, hasArg := strings.Cut(body, ":")
+
+	if name == "seq" {
+		if !hasArg {
+			return token{kind: tokenSeq}, nil
+		}
+		width, err := strconv.Atoi(arg)
+		if err != nil || width < 1 || width > MaxSeqWidth {
+			return token{}, &ParseError{Template: source, Pos: pos, Msg: fmt.Sprintf("{seq} width must be between 1 and %d", MaxSeqWidth)}
+		}
+		return token{kind: tokenSeq, width: width}, nil
+	}
+
+	if hasArg {
+		return token{}, &ParseError{Template: source, Pos: pos, Msg: fmt.Sprintf("token {%s} does not take an argument", name)}
+	}
+
+	switch name {
+	case "prefix":
+		return token{kind: tokenPrefix}, nil
+	case "yyyy":
+		return token{kind: tokenYear}, nil
+	case "yy":
+		return token{kind: tokenYearShort}, nil
+	case "mm":
+		return token{kind: tokenMonth}, nil
+	case "dd":
+		return token{kind: tokenDay}, nil
+	case "checksum":
+		return token{kind: tokenChecksum}, nil
+	case "":
+		return token{}, &ParseError{Template: source, Pos: pos, Msg: "empty token"}
+	default:
+		return token{}, &ParseError{Template: source, Pos: pos, Msg: fmt.Sprintf("unknown token {%s}", name)}
+	}
+}
+
+// String returns the template source
+func (t *Template) String() string {
+	return t.source
+}
+
+// Render formats an ID from the given values
+func (t *Template) Render(v Values) string {
+	var b strings.Builder
+	at := v.Time.UTC()
+
+	for _, tok := range t.tokens {
+		switch tok.kind {
+		case tokenLiteral:
+			b.WriteString(tok.text)
+		case tokenPrefix:
+			b.WriteString(v.Prefix)
+		case tokenSeq:
+			width := tok.width
+			if width == 0 {
+				width = v.Padding
+			}
+			fmt.Fprintf(&b, "%0*d", width, v.Counter)
+		case tokenYear:
+			fmt.Fprintf(&b, "%04d", at.Year())
+		case tokenYearShort:
+			fmt.Fprintf(&b, "%02d", at.Year()%100)
+		case tokenMonth:
+			fmt.Fprintf(&b, "%02d", int(at.Month()))
+		case tokenDay:
+			fmt.Fprintf(&b, "%02d", at.Day())
+		case tokenChecksum:
+			b.WriteByte(luhnDigit(b.String()))
+		}
+	}
+
+	return b.String()
+}
+
+// Match holds the values recovered from an ID by Template.Match. Date
+// fields are zero when the template does not encode them.
+type Match struct {
+	Counter int64
+	Year    int
+	Month   int
+	Day     int
+}
+
+// Match recovers the counter and date parts from an ID rendered by this
+// template for the given prefix and padding. It reports false when the ID
+// could not have been produced by the template, including when the check
+// digit does not verify.
+func (t *Template) Match(id, prefix string, padding int) (Match, bool) {
+	var pattern strings.Builder
+	pattern.WriteByte('^')
+	for _, tok := range t.tokens {
+		switch tok.kind {
+		case tokenLiteral:
+			pattern.WriteString(regexp.QuoteMeta(tok.text))
+		case tokenPrefix:
+			pattern.WriteString(regexp.QuoteMeta(prefix))
+		case tokenSeq:
+			width := tok.width
+			if width == 0 {
+				width = padding
+			}
+			if width < 1 {
+				width = 1
+			}
+			fmt.Fprintf(&pattern, `(\d{%d,})`, width)
+		case tokenYear:
+			pattern.WriteString(`(\d{4})`)
+		case tokenYearShort, tokenMonth, tokenDay:
+			pattern.WriteString(`(\d{2})`)
+		case tokenChecksum:
+			pattern.WriteString(`(\d)`)
+		}
+	}
+	pattern.WriteByte('$')
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return Match{}, false
+	}
+	groups := re.FindStringSubmatch(id)
+	if groups == nil {
+		return Match{}, false
+	}
+
+	var m Match
+	group := 1
+	for _, tok := range t.tokens {
+		if tok.kind == tokenLiteral || tok.kind == tokenPrefix {
+			continue
+		}
+		value, err := strconv.ParseInt(groups[group], 10, 64)
+		group++
+		if err != nil {
+			return Match{}, false
+		}
+
+		switch tok.kind {
+		case tokenSeq:
+			m.Counter = value
+		case tokenYear:
+			m.Year = int(value)
+		case tokenYearShort:
+			m.Year = 2000 + int(value)
+		case tokenMonth:
+			m.Month = int(value)
+		case tokenDay:
+			m.Day = int(value)
+		}
+	}
+
+	// Rendering the recovered values must reproduce the ID exactly; this
+	// rejects invalid dates, wrong padding and bad check digits
+	at := time.Date(max(m.Year, 1), time.Month(max(m.Month, 1)), max(m.Day, 1), 0, 0, 0, 0, time.UTC)
+	if t.Render(Values{Prefix: prefix, Counter: m.Counter, Padding: padding, Time: at}) != id {
+		return Match{}, false
+	}
+
+	return m, true
+}
+
+// luhnDigit computes the Luhn check digit over the digits contained in s
+func luhnDigit(s string) byte {
+	sum := 0
+	double := true
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
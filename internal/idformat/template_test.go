@@ -0,0 +1,149 @@
+package idformat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidTemplates(t *testing.T) {
+	tests := []struct {
+		template string
+		pos      int
+	}{
+		{"", 0},
+		{"{prefix}", 8},        // no {seq}
+		{"{prefix}{seq", 8},    // unterminated
+		{"{seq}}", 5},          // lone closing brace
+		{"{seq}{seq}", 5},      // two sequences
+		{"{checksum}{seq}", 0}, // checksum before seq
+		{"{seq}{checksum}{checksum}", 15},
+		{"{seq:0}", 0},
+		{"{seq:20}", 0},
+		{"{seq:x}", 0},
+		{"{yyyy:4}{seq}", 0}, // argument on a plain token
+		{"{}{seq}", 0},
+		{"{week}{seq}", 0},
+		{"%s%06d", 0}, // printf verbs
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.template)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) error = %v, want *ParseError", tt.template, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos {
+			t.Errorf("Parse(%q) error at %d, want %d: %v", tt.template, parseErr.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		values   Values
+		want     string
+	}{
+		{"{prefix}{seq}", Values{Prefix: "SG", Counter: 42, Padding: 6, Time: at}, "SG000042"},
+		{"{prefix}-{seq:3}", Values{Prefix: "PO", Counter: 7, Padding: 6, Time: at}, "PO-007"},
+		{"{prefix}{seq:2}", Values{Prefix: "PO", Counter: 12345, Time: at}, "PO12345"}, // wider than padding
+		{"{prefix}{yyyy}-{seq:4}", Values{Prefix: "INV", Counter: 12, Time: at}, "INV2026-0012"},
+		{"{yy}{mm}{dd}/{seq:3}", Values{Counter: 5, Time: at}, "260307/005"},
+		{"{{{prefix}}}{seq:2}", Values{Prefix: "X", Counter: 1, Time: at}, "{X}01"},
+		{"{seq:10}{checksum}", Values{Counter: 7992739871, Time: at}, "79927398713"},
+		{"A1-{seq:1}{checksum}", Values{Counter: 0, Time: at}, "A1-09"}, // over the digits "10"
+		// Dates are rendered in UTC, so a New York New Year's Eve evening
+		// already belongs to the next year
+		{"{yyyy}{mm}{dd}-{seq:1}", Values{Counter: 1, Time: time.Date(2025, 12, 31, 23, 30, 0, 0, mustLoad(t, "America/New_York"))}, "20260101-1"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.template)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.template, err)
+		}
+		if got := tmpl.Render(tt.values); got != tt.want {
+			t.Errorf("Render(%q, %+v) = %q, want %q", tt.template, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestLuhnDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{"7992739871", '3'},
+		{"0", '0'},
+		{"", '0'},
+		{"1", '8'},
+		{"INV-12/3", '0'}, // non-digits are skipped: 123 -> 0
+	}
+	for _, tt := range tests {
+		if got := luhnDigit(tt.digits); got != tt.want {
+			t.Errorf("luhnDigit(%q) = %c, want %c", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		template string
+		id       string
+		prefix   string
+		padding  int
+		want     Match
+		ok       bool
+	}{
+		{"{prefix}{seq}", "SG000042", "SG", 6, Match{Counter: 42}, true},
+		{"{prefix}{seq}", "SG1234567", "SG", 6, Match{Counter: 1234567}, true}, // outgrew the padding
+		{"{prefix}{seq}", "SG00042", "SG", 6, Match{}, false},                  // too short
+		{"{prefix}{seq}", "SG0000042", "SG", 6, Match{}, false},                // over-padded
+		{"{prefix}{seq}", "PO000042", "SG", 6, Match{}, false},
+		{"{prefix}{yyyy}-{seq:4}", "INV2026-0012", "INV", 6, Match{Counter: 12, Year: 2026}, true},
+		{"{yy}{mm}{dd}/{seq:3}", "260307/005", "", 6, Match{Counter: 5, Year: 2026, Month: 3, Day: 7}, true},
+		{"{yy}{mm}{dd}/{seq:3}", "260230/005", "", 6, Match{}, false}, // February 30th
+		{"{seq:10}{checksum}", "79927398713", "", 0, Match{Counter: 7992739871}, true},
+		{"{seq:10}{checksum}", "79927398714", "", 0, Match{}, false}, // bad check digit
+		{"{prefix}.{seq:2}", "A.B.01", "A.B", 0, Match{Counter: 1}, true},
+		{"{prefix}.{seq:2}", "AxB.01", "A.B", 0, Match{}, false}, // prefix is quoted
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.template)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.template, err)
+		}
+		got, ok := tmpl.Match(tt.id, tt.prefix, tt.padding)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Match(%q, %q) = %+v, %v; want %+v, %v", tt.template, tt.id, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMatchRoundTripsRender(t *testing.T) {
+	templates := []string{"{prefix}{seq}", "{prefix}-{yyyy}{mm}-{seq:5}{checksum}", "{{{yy}}}{seq:3}{checksum}{dd}"}
+	at := time.Date(2031, time.November, 30, 0, 0, 0, 0, time.UTC)
+	for _, source := range templates {
+		tmpl, err := Parse(source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", source, err)
+		}
+		for _, counter := range []int64{0, 1, 99, 100000, 9223372036854775807} {
+			id := tmpl.Render(Values{Prefix: "REQ", Counter: counter, Padding: 6, Time: at})
+			m, ok := tmpl.Match(id, "REQ", 6)
+			if !ok || m.Counter != counter {
+				t.Errorf("Match(%q, %q) = %+v, %v; want counter %d", source, id, m, ok, counter)
+			}
+		}
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// QueueInspector reports the state of the audit queue
+type QueueInspector interface {
+	GetQueueInfo(ctx context.Context) (map[string]interface{}, error)
+}
+
+// queueCollector reads queue depth at scrape time
+type queueCollector struct {
+	inspector QueueInspector
+	messages  *prometheus.Desc
+	consumers *prometheus.Desc
+	up        *prometheus.Desc
+}
+
+// NewQueueCollector creates a collector exporting the depth and consumer
+// count of the audit queue
+func NewQueueCollector(inspector QueueInspector) prometheus.Collector {
+	return &queueCollector{
+		inspector: inspector,
+		messages: prometheus.NewDesc(namespace+"_queue_messages",
+			"Number of messages ready in the audit queue.", []string{"queue"}, nil),
+		consumers: prometheus.NewDesc(namespace+"_queue_consumers",
+			"Number of consumers attached to the audit queue.", []string{"queue"}, nil),
+		up: prometheus.NewDesc(namespace+"_queue_inspect_up",
+			"Whether the audit queue could be inspected.", nil, nil),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.messages
+	ch <- c.consumers
+	ch <- c.up
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	info, err := c.inspector.GetQueueInfo(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	name, _ := info["name"].(string)
+	if messages, ok := info["messages"].(int); ok {
+		ch <- prometheus.MustNewConstMetric(c.messages, prometheus.GaugeValue, float64(messages), name)
+	}
+	if consumers, ok := info["consumers"].(int); ok {
+		ch <- prometheus.MustNewConstMetric(c.consumers, prometheus.GaugeValue, float64(consumers), name)
+	}
+}
+
+// OutboxInspector reports the backlog of the audit outbox
+type OutboxInspector interface {
+	GetOutboxStats(ctx context.Context) (*models.OutboxStats, error)
+}
+
+// outboxCollector reads the outbox backlog at scrape time
+type outboxCollector struct {
+	inspector OutboxInspector
+	pending   *prometheus.Desc
+	retrying  *prometheus.Desc
+	oldestAge *prometheus.Desc
+	up        *prometheus.Desc
+}
+
+// NewOutboxCollector creates a collector exporting the number and age of
+// audit events waiting in the outbox
+func NewOutboxCollector(inspector OutboxInspector) prometheus.Collector {
+	return &outboxCollector{
+		inspector: inspector,
+		pending: prometheus.NewDesc(namespace+"_outbox_pending",
+			"Number of audit events waiting in the outbox.", nil, nil),
+		retrying: prometheus.NewDesc(namespace+"_outbox_retrying",
+			"Number of outbox events that failed at least one publish attempt.", nil, nil),
+		oldestAge: prometheus.NewDesc(namespace+"_outbox_oldest_age_seconds",
+			"Age of the oldest audit event waiting in the outbox.", nil, nil),
+		up: prometheus.NewDesc(namespace+"_outbox_inspect_up",
+			"Whether the outbox could be inspected.", nil, nil),
+	}
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.retrying
+	ch <- c.oldestAge
+	ch <- c.up
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	stats, err := c.inspector.GetOutboxStats(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	age := 0.0
+	if stats.Oldest != nil {
+		age = time.Since(*stats.Oldest).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(c.retrying, prometheus.GaugeValue, float64(stats.Retrying))
+	ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, age)
+}
+
+// dbStatsCollector exports connection pool statistics at scrape time
+type dbStatsCollector struct {
+	stats        func() sql.DBStats
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+// NewDBStatsCollector creates a collector exporting database pool statistics
+func NewDBStatsCollector(stats func() sql.DBStats) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(namespace+"_db_"+name, help, nil, nil)
+	}
+	return &dbStatsCollector{
+		stats:        stats,
+		maxOpen:      desc("max_open_connections", "Maximum number of open database connections."),
+		open:         desc("open_connections", "Number of established database connections."),
+		inUse:        desc("in_use_connections", "Number of database connections in use."),
+		idle:         desc("idle_connections", "Number of idle database connections."),
+		waitCount:    desc("wait_count_total", "Number of times a caller waited for a database connection."),
+		waitDuration: desc("wait_duration_seconds_total", "Time spent waiting for database connections."),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "seqid"
+
+// Backends reported in the operation metrics
+const (
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+	BackendRabbitMQ = "rabbitmq"
+)
+
+var (
+	// IDsIssued counts IDs handed out per prefix
+	IDsIssued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ids_issued_total",
+		Help:      "Number of IDs issued.",
+	}, []string{"prefix"})
+
+	// BatchSize observes the size of batch requests per prefix
+	BatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "batch_size",
+		Help:      "Number of IDs requested per batch.",
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	}, []string{"prefix"})
+
+	// GenerateDuration observes end-to-end latency of ID generation calls
+	GenerateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "generate_duration_seconds",
+		Help:      "Latency of ID generation calls.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "result"})
+
+	// PublishFailures counts audit events that could not be published
+	PublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "publish_failures_total",
+		Help:      "Number of audit events that failed to publish.",
+	}, []string{"prefix"})
+
+	// OutboxPublished counts audit events relayed from the outbox to RabbitMQ
+	OutboxPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_published_total",
+		Help:      "Number of audit events relayed from the outbox.",
+	}, []string{"prefix"})
+
+	// OutboxRelayFailures counts failed attempts to relay outbox events
+	OutboxRelayFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_relay_failures_total",
+		Help:      "Number of failed attempts to relay audit events from the outbox.",
+	}, []string{"prefix"})
+
+	// BlocksLeased counts counter blocks leased by this instance
+	BlocksLeased = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_leased_total",
+		Help:      "Number of counter blocks leased for in-memory allocation.",
+	}, []string{"prefix"})
+
+	// BlockValuesUnused counts leased counter values never handed out, by
+	// whether they were returned to the counter or recorded as lost
+	BlockValuesUnused = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "block_values_unused_total",
+		Help:      "Number of leased counter values released unused.",
+	}, []string{"prefix", "outcome"})
+
+	// ConfigCacheLookups counts prefix config lookups by result (hit, miss
+	// or stale)
+	ConfigCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_cache_lookups_total",
+		Help:      "Number of prefix config lookups by cache result.",
+	}, []string{"result"})
+
+	// OperationDuration observes the latency of backend operations
+	OperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backend_operation_duration_seconds",
+		Help:      "Latency of Redis, PostgreSQL and RabbitMQ operations.",
+		Buckets:   []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"backend", "operation"})
+
+	// OperationErrors counts failed backend operations
+	OperationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_operation_errors_total",
+		Help:      "Number of failed Redis, PostgreSQL and RabbitMQ operations.",
+	}, []string{"backend", "operation"})
+
+	// WorkerEventDuration observes how long the worker takes per audit event
+	WorkerEventDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_event_duration_seconds",
+		Help:      "Time taken by the worker to process an audit event.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	// WorkerEventFailures counts audit events the worker failed to record, by
+	// whether they were scheduled for retry or dead-lettered
+	WorkerEventFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_event_failures_total",
+		Help:      "Number of audit events the worker failed to process, by outcome.",
+	}, []string{"outcome"})
+
+	// DeadLettersHandled counts dead-lettered audit events replayed or purged
+	// by an operator
+	DeadLettersHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dead_letters_handled_total",
+		Help:      "Number of dead-lettered audit events replayed or purged, by action.",
+	}, []string{"action"})
+
+	// WorkerEventLag observes the delay between ID generation and audit insertion
+	WorkerEventLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_event_lag_seconds",
+		Help:      "Delay between ID generation and the worker recording the audit event.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+	})
+)
+
+// ObserveOperation records the latency and outcome of a backend operation
+func ObserveOperation(backend, operation string, start time.Time, err error) {
+	OperationDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		OperationErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// ObserveGenerate records the latency and outcome of an ID generation call
+func ObserveGenerate(operation string, start time.Time, err error) {
+	GenerateDuration.WithLabelValues(operation, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+// Result returns the result label for an outcome
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// NewServer creates the HTTP server exposing /metrics on the given port
+func NewServer(port string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: mux,
+	}
+}
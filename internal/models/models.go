@@ -4,36 +4,58 @@ import (
 	"time"
 )
 
+// DefaultTenant owns prefixes of callers without a tenant and all prefixes
+// created before tenants were introduced
+const DefaultTenant = "default"
+
 // SequentialID represents a generated sequential ID
 type SequentialID struct {
-	Prefix      string    `json:"prefix"`
-	Counter     int64     `json:"counter"`
-	FullNumber  string    `json:"full_number"`
-	GeneratedBy string    `json:"generated_by,omitempty"`
-	ClientID    string    `json:"client_id,omitempty"`
-	MessageID   string    `json:"message_id"`
-	GeneratedAt time.Time `json:"generated_at"`
+	Tenant      string `json:"tenant"`
+	Prefix      string `json:"prefix"`
+	Counter     int64  `json:"counter"`
+	PeriodKey   string `json:"period_key,omitempty"`
+	FullNumber  string `json:"full_number"`
+	GeneratedBy string `json:"generated_by,omitempty"`
+	ClientID    string `json:"client_id,omitempty"`
+	// CorrelationID is echoed from the request and recorded in the audit log
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	MessageID     string    `json:"message_id"`
+	GeneratedAt   time.Time `json:"generated_at"`
 }
 
+// Reset rules supported by PrefixConfig.ResetRule
+const (
+	ResetRuleNever   = "never"
+	ResetRuleDaily   = "daily"
+	ResetRuleMonthly = "monthly"
+	ResetRuleYearly  = "yearly"
+)
+
 // PrefixConfig represents configuration for a prefix
 type PrefixConfig struct {
 	ID             int64      `json:"id" db:"id"`
+	Tenant         string     `json:"tenant" db:"tenant"`
 	Prefix         string     `json:"prefix" db:"prefix"`
 	PaddingLength  int        `json:"padding_length" db:"padding_length"`
 	FormatTemplate string     `json:"format_template" db:"format_template"`
 	ResetRule      string     `json:"reset_rule" db:"reset_rule"`
+	Gapless        bool       `json:"gapless" db:"gapless"`
+	BlockSize      int        `json:"block_size" db:"block_size"`
 	LastResetAt    *time.Time `json:"last_reset_at,omitempty" db:"last_reset_at"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
 	CreatedBy      *string    `json:"created_by,omitempty" db:"created_by"`
 	UpdatedBy      *string    `json:"updated_by,omitempty" db:"updated_by"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty" db:"archived_at"`
 }
 
 // AuditLog represents an audit log entry
 type AuditLog struct {
 	ID            int64      `json:"id" db:"id"`
+	Tenant        string     `json:"tenant" db:"tenant"`
 	Prefix        string     `json:"prefix" db:"prefix"`
 	CounterValue  int64      `json:"counter_value" db:"counter_value"`
+	PeriodKey     string     `json:"period_key,omitempty" db:"period_key"`
 	FullNumber    string     `json:"full_number" db:"full_number"`
 	GeneratedBy   *string    `json:"generated_by,omitempty" db:"generated_by"`
 	ClientID      *string    `json:"client_id,omitempty" db:"client_id"`
@@ -43,11 +65,51 @@ type AuditLog struct {
 	PublishedAt   *time.Time `json:"published_at,omitempty" db:"published_at"`
 	InsertedAt    time.Time  `json:"inserted_at" db:"inserted_at"`
 	BatchID       *string    `json:"batch_id,omitempty" db:"batch_id"`
+	Status        string     `json:"status" db:"status"`
+}
+
+// Audit log statuses
+const (
+	AuditStatusIssued = "issued" // recorded from the audit event of an issued ID
+	AuditStatusLost   = "lost"   // placeholder for an issued ID whose audit event was lost
+)
+
+// AuditQuery filters audit log entries. Zero values leave a filter unset.
+type AuditQuery struct {
+	// Tenant is resolved from the caller, never from the request
+	Tenant        string     `json:"-"`
+	Prefix        string     `json:"prefix,omitempty"`
+	PeriodKey     *string    `json:"period_key,omitempty"`
+	CounterFrom   *int64     `json:"counter_from,omitempty"`
+	CounterTo     *int64     `json:"counter_to,omitempty"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	ClientID      string     `json:"client_id,omitempty"`
+	GeneratedBy   string     `json:"generated_by,omitempty"`
+	BatchID       string     `json:"batch_id,omitempty"`
+	CorrelationID string     `json:"correlation_id,omitempty"`
+	Limit         int        `json:"limit,omitempty"`
+	Cursor        string     `json:"cursor,omitempty"`
+}
+
+// AuditCursor is the keyset position of the last entry of a page
+type AuditCursor struct {
+	GeneratedAt time.Time
+	ID          int64
+}
+
+// AuditPage represents one page of audit log entries, newest first
+type AuditPage struct {
+	Logs       []AuditLog `json:"logs"`
+	Count      int        `json:"count"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 // Checkpoint represents a counter checkpoint
 type Checkpoint struct {
+	Tenant            string    `json:"tenant" db:"tenant"`
 	Prefix            string    `json:"prefix" db:"prefix"`
+	PeriodKey         string    `json:"period_key,omitempty" db:"period_key"`
 	LastCounterSynced int64     `json:"last_counter_synced" db:"last_counter_synced"`
 	SyncedAt          time.Time `json:"synced_at" db:"synced_at"`
 	SyncedBy          *string   `json:"synced_by,omitempty" db:"synced_by"`
@@ -56,7 +118,9 @@ type Checkpoint struct {
 // ResetLog represents a counter reset operation
 type ResetLog struct {
 	ID        int64     `json:"id" db:"id"`
+	Tenant    string    `json:"tenant" db:"tenant"`
 	Prefix    string    `json:"prefix" db:"prefix"`
+	PeriodKey string    `json:"period_key,omitempty" db:"period_key"`
 	OldValue  int64     `json:"old_value" db:"old_value"`
 	NewValue  int64     `json:"new_value" db:"new_value"`
 	Reason    string    `json:"reason" db:"reason"`
@@ -65,6 +129,113 @@ type ResetLog struct {
 	ResetAt   time.Time `json:"reset_at" db:"reset_at"`
 }
 
+// ID lookup states reported by IDLookup.Status
+const (
+	IDStatusAudited          = "audited"            // recorded in seq_log
+	IDStatusIssuedNotAudited = "issued_not_audited" // handed out by Redis, audit write still pending
+	IDStatusLost             = "lost"               // audit event lost, recorded by reconciliation
+)
+
+// IDLookup describes an issued ID resolved from its full number
+type IDLookup struct {
+	FullNumber   string        `json:"full_number"`
+	Status       string        `json:"status"` // audited, issued_not_audited or lost
+	Tenant       string        `json:"tenant"`
+	Prefix       string        `json:"prefix"`
+	PeriodKey    string        `json:"period_key,omitempty"`
+	CounterValue int64         `json:"counter_value"`
+	Audit        *AuditLog     `json:"audit,omitempty"`
+	Batch        *BatchSummary `json:"batch,omitempty"`
+	Resets       []ResetLog    `json:"resets"`
+}
+
+// BatchSummary describes the batch an ID was issued in
+type BatchSummary struct {
+	BatchID      string `json:"batch_id" db:"batch_id"`
+	Size         int    `json:"size" db:"size"`
+	FirstCounter int64  `json:"first_counter" db:"first_counter"`
+	LastCounter  int64  `json:"last_counter" db:"last_counter"`
+}
+
+// Gap reasons reported by reconciliation
+const (
+	GapReasonMissing  = "missing"  // issued but never audited
+	GapReasonReset    = "reset"    // skipped by a counter reset
+	GapReasonPending  = "pending"  // beyond the last audited value of the active period, may still be in flight
+	GapReasonReserved = "reserved" // held by a gapless reservation, awaiting commit or reuse
+)
+
+// CounterGap is a range of counter values missing from the audit log
+type CounterGap struct {
+	From       int64  `json:"from"`
+	To         int64  `json:"to"`
+	Count      int64  `json:"count"`
+	Reason     string `json:"reason"`
+	Backfilled int64  `json:"backfilled,omitempty"`
+}
+
+// GapBounds is a gap between two audited counter values as found in seq_log
+type GapBounds struct {
+	From     int64      `db:"gap_from"`
+	To       int64      `db:"gap_to"`
+	BeforeAt *time.Time `db:"before_at"` // generated_at of the entry before the gap
+}
+
+// AuditStats summarizes the audited counter values of a prefix period
+type AuditStats struct {
+	Count          int64      `db:"count"`
+	MinCounter     int64      `db:"min_counter"`
+	MaxCounter     int64      `db:"max_counter"`
+	LostCount      int64      `db:"lost_count"`
+	FirstGenerated *time.Time `db:"first_generated"`
+	LastGenerated  *time.Time `db:"last_generated"`
+}
+
+// ReconcileRequest selects the prefixes to reconcile
+type ReconcileRequest struct {
+	// Tenant limits the run to one tenant; empty means all tenants
+	Tenant      string   `json:"-"`
+	Prefixes    []string `json:"prefixes,omitempty"` // empty means all configured prefixes
+	FixGaps     bool     `json:"fix_gaps"`
+	MaxBackfill int64    `json:"max_backfill,omitempty"`
+}
+
+// PeriodReconciliation compares Redis, the checkpoint and seq_log for one
+// counter period of a prefix
+type PeriodReconciliation struct {
+	Tenant            string       `json:"tenant"`
+	Prefix            string       `json:"prefix"`
+	PeriodKey         string       `json:"period_key,omitempty"`
+	Active            bool         `json:"active"`
+	RedisCounter      int64        `json:"redis_counter"` // value in the counter store, whichever the backend
+	CheckpointCounter *int64       `json:"checkpoint_counter,omitempty"`
+	AuditedCount      int64        `json:"audited_count"`
+	AuditedMax        int64        `json:"audited_max"`
+	LostCount         int64        `json:"lost_count"`
+	RedisBehind       bool         `json:"redis_behind"`
+	Missing           int64        `json:"missing"`
+	Gaps              []CounterGap `json:"gaps"`
+}
+
+// ReconcileReport is the result of a reconciliation run
+type ReconcileReport struct {
+	Periods    []PeriodReconciliation `json:"periods"`
+	Missing    int64                  `json:"missing"`
+	Backfilled int64                  `json:"backfilled"`
+	Truncated  bool                   `json:"truncated"`
+	CheckedAt  time.Time              `json:"checked_at"`
+}
+
+// ErrorResponse is the body of every failed REST call
+type ErrorResponse struct {
+	// Error is a human-readable description
+	Error string `json:"error"`
+	// Code is a stable, machine-readable error code such as PREFIX_NOT_CONFIGURED
+	Code string `json:"code"`
+	// Field names the offending request field of INVALID_ARGUMENT errors
+	Field string `json:"field,omitempty"`
+}
+
 // HealthStatus represents service health status
 type HealthStatus struct {
 	Healthy    bool              `json:"healthy"`
@@ -74,10 +245,12 @@ type HealthStatus struct {
 
 // CounterStatus represents the status of a counter
 type CounterStatus struct {
+	Tenant           string `json:"tenant"`
 	Prefix           string `json:"prefix"`
+	PeriodKey        string `json:"period_key,omitempty"`
 	CurrentCounter   int64  `json:"current_counter"`
 	NextCounter      int64  `json:"next_counter"`
-	RedisHealthy     bool   `json:"redis_healthy"`
+	RedisHealthy     bool   `json:"redis_healthy"` // counter store, whichever the backend
 	QueueHealthy     bool   `json:"queue_healthy"`
 	DatabaseHealthy  bool   `json:"database_healthy"`
 	LastAuditCounter int64  `json:"last_audit_counter"`
@@ -85,9 +258,12 @@ type CounterStatus struct {
 
 // Event represents an event to be published to message queue
 type Event struct {
-	MessageID     string    `json:"message_id"`
+	MessageID string `json:"message_id"`
+	// Tenant is empty in events published before tenants were introduced
+	Tenant        string    `json:"tenant,omitempty"`
 	Prefix        string    `json:"prefix"`
 	Counter       int64     `json:"counter"`
+	PeriodKey     string    `json:"period_key,omitempty"`
 	FullNumber    string    `json:"full_number"`
 	GeneratedBy   string    `json:"generated_by"`
 	ClientID      string    `json:"client_id"`
@@ -98,6 +274,107 @@ type Event struct {
 	BatchID       string    `json:"batch_id,omitempty"`
 }
 
+// DeadLetter is an audit event parked in the dead letter queue after the
+// worker gave up on it
+type DeadLetter struct {
+	MessageID  string `json:"message_id"`
+	Tenant     string `json:"tenant,omitempty"` // empty when the payload cannot be parsed
+	Prefix     string `json:"prefix,omitempty"`
+	FullNumber string `json:"full_number,omitempty"`
+	RetryCount int    `json:"retry_count"`
+	// Reason is the last_error header, or the broker's dead letter reason
+	// for messages it dead-lettered itself (e.g. "expired")
+	Reason      string    `json:"reason,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	Event       *Event    `json:"event,omitempty"`
+	// Payload holds the raw body of messages that cannot be parsed
+	Payload string `json:"payload,omitempty"`
+}
+
+// DeadLetterQuery selects the dead letters to list
+type DeadLetterQuery struct {
+	// Tenant limits the listing to one tenant; empty means all tenants
+	Tenant string `json:"-"`
+	Limit  int    `json:"limit,omitempty"` // number of queued messages to inspect
+}
+
+// DeadLetterList is a page of the dead letter queue, oldest first
+type DeadLetterList struct {
+	Messages []DeadLetter `json:"messages"`
+	Count    int          `json:"count"`
+	Scanned  int          `json:"scanned"` // messages inspected, including other tenants'
+	Depth    int          `json:"depth"`   // messages in the queue
+}
+
+// Dead letter actions recorded in seq_dlq_audit
+const (
+	DeadLetterActionReplay = "REPLAY"
+	DeadLetterActionPurge  = "PURGE"
+)
+
+// DeadLetterRequest selects dead letters to replay or purge
+type DeadLetterRequest struct {
+	// Tenant limits the request to one tenant; empty means all tenants
+	Tenant     string   `json:"-"`
+	MessageIDs []string `json:"message_ids,omitempty"`
+	All        bool     `json:"all"`             // select every message instead of message_ids
+	Limit      int      `json:"limit,omitempty"` // number of queued messages to inspect
+	Reason     string   `json:"reason"`
+	AdminUser  string   `json:"admin_user"`
+}
+
+// DeadLetterResult reports the dead letters replayed or purged
+type DeadLetterResult struct {
+	AuditID    int64     `json:"audit_id"`
+	Action     string    `json:"action"`
+	MessageIDs []string  `json:"message_ids"`
+	Count      int       `json:"count"`
+	Scanned    int       `json:"scanned"`
+	Remaining  int       `json:"remaining"` // messages left in the queue
+	HandledAt  time.Time `json:"handled_at"`
+}
+
+// DeadLetterAudit records an operator replaying or purging dead letters
+type DeadLetterAudit struct {
+	ID         int64     `json:"id" db:"id"`
+	Tenant     *string   `json:"tenant,omitempty" db:"tenant"` // nil for all tenants
+	Action     string    `json:"action" db:"action"`
+	MessageIDs []string  `json:"message_ids" db:"-"`
+	Count      int       `json:"count" db:"message_count"`
+	Reason     string    `json:"reason" db:"reason"`
+	AdminUser  string    `json:"admin_user" db:"admin_user"`
+	HandledAt  time.Time `json:"handled_at" db:"handled_at"`
+}
+
+// OutboxEntry is an audit event waiting in the outbox to be published
+type OutboxEntry struct {
+	ID            int64     `db:"id"`
+	MessageID     string    `db:"message_id"`
+	Prefix        string    `db:"prefix"`
+	Payload       []byte    `db:"payload"`
+	Attempts      int       `db:"attempts"`
+	LastError     *string   `db:"last_error"`
+	CreatedAt     time.Time `db:"created_at"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+}
+
+// OutboxStats summarizes the outbox backlog
+type OutboxStats struct {
+	Pending  int64      `db:"pending"`
+	Retrying int64      `db:"retrying"`
+	Oldest   *time.Time `db:"oldest"`
+}
+
+// NextRequest represents a request for the next ID of a prefix
+type NextRequest struct {
+	Prefix        string `json:"prefix"`
+	ClientID      string `json:"client_id"`
+	GeneratedBy   string `json:"generated_by"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// IdempotencyKey is taken from the Idempotency-Key header, not the body
+	IdempotencyKey string `json:"-"`
+}
+
 // BatchRequest represents a request for multiple IDs
 type BatchRequest struct {
 	Prefix        string `json:"prefix"`
@@ -105,6 +382,8 @@ type BatchRequest struct {
 	ClientID      string `json:"client_id"`
 	GeneratedBy   string `json:"generated_by"`
 	CorrelationID string `json:"correlation_id,omitempty"`
+	// IdempotencyKey is taken from the Idempotency-Key header, not the body
+	IdempotencyKey string `json:"-"`
 }
 
 // BatchResponse represents a response with multiple IDs
@@ -115,6 +394,64 @@ type BatchResponse struct {
 	GeneratedAt time.Time      `json:"generated_at"`
 }
 
+// RangeRequest asks for Count IDs of a prefix, allocated as ranges of at
+// most ChunkSize consecutive counter values
+type RangeRequest struct {
+	DemandID      string `json:"demand_id,omitempty"`
+	Prefix        string `json:"prefix"`
+	Count         int    `json:"count"`
+	ChunkSize     int    `json:"chunk_size,omitempty"`
+	ClientID      string `json:"client_id"`
+	GeneratedBy   string `json:"generated_by"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// IDRange is a run of consecutive IDs allocated and audited as one batch
+type IDRange struct {
+	DemandID     string    `json:"demand_id,omitempty"`
+	Tenant       string    `json:"tenant"`
+	Prefix       string    `json:"prefix"`
+	PeriodKey    string    `json:"period_key,omitempty"`
+	StartCounter int64     `json:"start_counter"`
+	EndCounter   int64     `json:"end_counter"`
+	Count        int       `json:"count"`
+	FullNumbers  []string  `json:"full_numbers"`
+	BatchID      string    `json:"batch_id"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	// Remaining is the number of IDs of the demand still to be allocated
+	Remaining int `json:"remaining"`
+}
+
+// Reservation statuses
+const (
+	ReservationStatusReserved = "reserved" // leased to a caller until expires_at
+	ReservationStatusReleased = "released" // returned to the pool for reuse
+)
+
+// Reservation is a provisional number of a gapless prefix
+type Reservation struct {
+	ID            int64     `json:"-" db:"id"`
+	ReservationID string    `json:"reservation_id" db:"reservation_id"`
+	Tenant        string    `json:"tenant" db:"tenant"`
+	Prefix        string    `json:"prefix" db:"prefix"`
+	PeriodKey     string    `json:"period_key,omitempty" db:"period_key"`
+	CounterValue  int64     `json:"counter" db:"counter_value"`
+	FullNumber    string    `json:"full_number" db:"full_number"`
+	Status        string    `json:"status" db:"status"`
+	ClientID      *string   `json:"client_id,omitempty" db:"client_id"`
+	GeneratedBy   *string   `json:"generated_by,omitempty" db:"generated_by"`
+	ReservedAt    time.Time `json:"reserved_at" db:"reserved_at"`
+	ExpiresAt     time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// ReserveRequest represents a request to reserve a number of a gapless prefix
+type ReserveRequest struct {
+	Prefix       string `json:"prefix"`
+	ClientID     string `json:"client_id"`
+	GeneratedBy  string `json:"generated_by"`
+	LeaseSeconds int    `json:"lease_seconds,omitempty"` // 0 uses the configured default
+}
+
 // ResetRequest represents a request to reset a counter
 type ResetRequest struct {
 	SetTo     int64  `json:"set_to"`
@@ -137,6 +474,83 @@ type ConfigUpdateRequest struct {
 	PaddingLength     *int    `json:"padding_length,omitempty"`
 	FormatTemplate    *string `json:"format_template,omitempty"`
 	ResetRule         *string `json:"reset_rule,omitempty"`
+	Gapless           *bool   `json:"gapless,omitempty"`
+	BlockSize         *int    `json:"block_size,omitempty"`
 	AdminUser         string  `json:"admin_user"`
 	CreateIfNotExists bool    `json:"create_if_not_exists,omitempty"`
 }
+
+// Change types recorded in seq_config_audit
+const (
+	ConfigChangeCreate   = "CREATE"
+	ConfigChangeUpdate   = "UPDATE"
+	ConfigChangeDelete   = "DELETE"
+	ConfigChangeRollback = "ROLLBACK"
+)
+
+// ConfigFieldChange is the old and new value of one configuration setting
+type ConfigFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// ConfigChange represents a recorded change of a prefix configuration. The
+// version identifies the configuration as it was after the change.
+type ConfigChange struct {
+	Version    int64                        `json:"version"`
+	Tenant     string                       `json:"tenant"`
+	Prefix     string                       `json:"prefix"`
+	ChangeType string                       `json:"change_type"`
+	OldConfig  *PrefixConfig                `json:"old_config,omitempty"`
+	NewConfig  *PrefixConfig                `json:"new_config,omitempty"`
+	Diff       map[string]ConfigFieldChange `json:"diff"`
+	AdminUser  string                       `json:"admin_user"`
+	Reason     string                       `json:"reason,omitempty"`
+	ChangedAt  time.Time                    `json:"changed_at"`
+}
+
+// ConfigRollbackRequest represents a request to restore a previous
+// configuration version of a prefix
+type ConfigRollbackRequest struct {
+	Version   int64  `json:"version"`
+	AdminUser string `json:"admin_user"`
+}
+
+// PrefixSummary represents a configured prefix with its counter state
+type PrefixSummary struct {
+	PrefixConfig
+	PeriodKey      string     `json:"period_key,omitempty"`
+	CurrentCounter int64      `json:"current_counter"`
+	LastIssuedAt   *time.Time `json:"last_issued_at,omitempty"`
+	Archived       bool       `json:"archived"`
+}
+
+// PrefixArchiveRequest represents a request to archive or unarchive a prefix
+type PrefixArchiveRequest struct {
+	AdminUser string `json:"admin_user"`
+}
+
+// PrefixDeleteRequest represents a request to delete a prefix. Prefixes that
+// have issued IDs are only deleted with force and a reason.
+type PrefixDeleteRequest struct {
+	AdminUser string `json:"admin_user"`
+	Force     bool   `json:"force,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// FormatPreviewRequest represents a request to render sample IDs for a format template
+type FormatPreviewRequest struct {
+	FormatTemplate string     `json:"format_template"`
+	PaddingLength  *int       `json:"padding_length,omitempty"`
+	StartCounter   int64      `json:"start_counter,omitempty"`
+	Count          int        `json:"count,omitempty"`
+	At             *time.Time `json:"at,omitempty"`
+}
+
+// FormatPreviewResponse represents sample IDs rendered for a format template
+type FormatPreviewResponse struct {
+	Prefix         string   `json:"prefix"`
+	FormatTemplate string   `json:"format_template"`
+	PaddingLength  int      `json:"padding_length"`
+	Samples        []string `json:"samples"`
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// prefixConfigColumns lists the seq_config columns of models.PrefixConfig
+const prefixConfigColumns = `id, tenant, prefix, padding_length, format_template, reset_rule, gapless, block_size,
+		       last_reset_at, created_at, updated_at, created_by, updated_by, archived_at`
+
+// configBookkeepingFields are maintained by the service rather than set by
+// administrators, so they are left out of configuration diffs
+var configBookkeepingFields = map[string]bool{
+	"id":            true,
+	"created_at":    true,
+	"updated_at":    true,
+	"created_by":    true,
+	"updated_by":    true,
+	"last_reset_at": true,
+}
+
+// configChangeRow is a seq_config_audit row with its JSONB columns undecoded
+type configChangeRow struct {
+	ID         int64     `db:"id"`
+	Tenant     string    `db:"tenant"`
+	Prefix     string    `db:"prefix"`
+	ChangeType string    `db:"change_type"`
+	OldConfig  []byte    `db:"old_config"`
+	NewConfig  []byte    `db:"new_config"`
+	Diff       []byte    `db:"diff"`
+	AdminUser  string    `db:"admin_user"`
+	Reason     *string   `db:"reason"`
+	ChangedAt  time.Time `db:"changed_at"`
+}
+
+// selectConfigChangeQuery reads seq_config_audit rows
+const selectConfigChangeQuery = `
+	SELECT id, tenant, prefix, change_type, old_config, new_config, diff, admin_user, reason, changed_at
+	FROM seq_config_audit
+`
+
+// GetConfigHistory returns the most recent configuration changes of a
+// prefix of a tenant, newest first
+func (r *PostgresRepository) GetConfigHistory(ctx context.Context, tenant, prefix string, limit int) (_ []models.ConfigChange, err error) {
+	defer observeDB("get_config_history", time.Now(), &err)
+
+	var rows []configChangeRow
+	query := selectConfigChangeQuery + `
+		WHERE tenant = $1 AND prefix = $2
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, tenant, prefix, limit); err != nil {
+		return nil, fmt.Errorf("failed to get config history for %s: %w", prefix, err)
+	}
+
+	changes := make([]models.ConfigChange, 0, len(rows))
+	for i := range rows {
+		change, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+
+	return changes, nil
+}
+
+// GetConfigChange retrieves one configuration change by its version
+func (r *PostgresRepository) GetConfigChange(ctx context.Context, version int64) (_ *models.ConfigChange, err error) {
+	defer observeDB("get_config_change", time.Now(), &err)
+
+	var row configChangeRow
+	err = r.db.GetContext(ctx, &row, selectConfigChangeQuery+` WHERE id = $1`, version)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config change %d: %w", version, err)
+	}
+
+	return row.decode()
+}
+
+// selectPrefixConfigForUpdate reads and locks the configuration of a prefix
+// within a transaction; it returns nil when the prefix is not configured
+func selectPrefixConfigForUpdate(ctx context.Context, tx *sqlx.Tx, tenant, prefix string) (*models.PrefixConfig, error) {
+	var config models.PrefixConfig
+	query := `SELECT ` + prefixConfigColumns + `
+		FROM seq_config
+		WHERE tenant = $1 AND prefix = $2
+		FOR UPDATE
+	`
+
+	err := tx.GetContext(ctx, &config, query, tenant, prefix)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock prefix config for %s: %w", prefix, err)
+	}
+
+	return &config, nil
+}
+
+// insertConfigChange records a configuration change in seq_config_audit
+// within the transaction that made it. old is nil for CREATE and new is nil
+// for DELETE; an empty reason is stored as NULL.
+func insertConfigChange(ctx context.Context, tx *sqlx.Tx, tenant, prefix, changeType, adminUser, reason string, old, new *models.PrefixConfig) error {
+	oldJSON, err := configJSON(old)
+	if err != nil {
+		return err
+	}
+	newJSON, err := configJSON(new)
+	if err != nil {
+		return err
+	}
+
+	diff, err := configDiff(old, new)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("failed to encode config diff: %w", err)
+	}
+
+	query := `
+		INSERT INTO seq_config_audit (tenant, prefix, old_config, new_config, diff, change_type, admin_user, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+	`
+
+	if _, err := tx.ExecContext(ctx, query, tenant, prefix, oldJSON, newJSON, string(diffJSON), changeType, adminUser, reason); err != nil {
+		return fmt.Errorf("failed to record config change: %w", err)
+	}
+
+	return nil
+}
+
+// configJSON encodes a configuration for a JSONB column, nil as NULL
+func configJSON(config *models.PrefixConfig) (interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prefix config: %w", err)
+	}
+	return string(data), nil
+}
+
+// configFields returns the JSON fields of a configuration
+func configFields(config *models.PrefixConfig) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if config == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prefix config: %w", err)
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode prefix config: %w", err)
+	}
+	return fields, nil
+}
+
+// configDiff returns the settings that differ between two configurations,
+// keyed by their JSON field name
+func configDiff(old, new *models.PrefixConfig) (map[string]models.ConfigFieldChange, error) {
+	oldFields, err := configFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := configFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]models.ConfigFieldChange)
+	for name, value := range oldFields {
+		if !configBookkeepingFields[name] && !reflect.DeepEqual(value, newFields[name]) {
+			diff[name] = models.ConfigFieldChange{Old: value, New: newFields[name]}
+		}
+	}
+	for name, value := range newFields {
+		if _, ok := oldFields[name]; !ok && !configBookkeepingFields[name] {
+			diff[name] = models.ConfigFieldChange{Old: nil, New: value}
+		}
+	}
+
+	return diff, nil
+}
+
+// decode converts the JSONB columns of a row
+func (row *configChangeRow) decode() (*models.ConfigChange, error) {
+	change := &models.ConfigChange{
+		Version:    row.ID,
+		Tenant:     row.Tenant,
+		Prefix:     row.Prefix,
+		ChangeType: row.ChangeType,
+		AdminUser:  row.AdminUser,
+		ChangedAt:  row.ChangedAt,
+		Diff:       make(map[string]models.ConfigFieldChange),
+	}
+
+	if row.Reason != nil {
+		change.Reason = *row.Reason
+	}
+	if row.OldConfig != nil {
+		change.OldConfig = &models.PrefixConfig{}
+		if err := json.Unmarshal(row.OldConfig, change.OldConfig); err != nil {
+			return nil, fmt.Errorf("failed to decode config change %d: %w", row.ID, err)
+		}
+	}
+	if row.NewConfig != nil {
+		change.NewConfig = &models.PrefixConfig{}
+		if err := json.Unmarshal(row.NewConfig, change.NewConfig); err != nil {
+			return nil, fmt.Errorf("failed to decode config change %d: %w", row.ID, err)
+		}
+	}
+	if err := json.Unmarshal(row.Diff, &change.Diff); err != nil {
+		return nil, fmt.Errorf("failed to decode config change %d: %w", row.ID, err)
+	}
+
+	return change, nil
+}
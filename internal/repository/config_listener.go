@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// configChangedChannel is notified by the seq_config trigger with the
+// changed tenant and prefix as "tenant:prefix" payload, and by counter
+// resets with a "tenant:prefix:reset" payload
+const configChangedChannel = "seq_config_changed"
+
+// counterResetEvent marks notifications of counter resets
+const counterResetEvent = "reset"
+
+// configListenerPingInterval is how often an idle listener connection is
+// checked so a silently dropped connection is noticed
+const configListenerPingInterval = 90 * time.Second
+
+// ConfigListener receives prefix configuration change notifications over a
+// dedicated PostgreSQL connection
+type ConfigListener struct {
+	listener *pq.Listener
+}
+
+// NewConfigListener creates a listener that reconnects with backoff
+func NewConfigListener(cfg config.DatabaseConfig) *ConfigListener {
+	return &ConfigListener{
+		listener: pq.NewListener(cfg.URL, time.Second, time.Minute, nil),
+	}
+}
+
+// Run delivers changed tenant prefixes to onChange and reset counters to
+// onReset until the context is cancelled.
+// Notifications sent while the connection was down are lost, so onReconnect
+// is called after every reconnect.
+func (l *ConfigListener) Run(ctx context.Context, onChange, onReset func(tenant, prefix string), onReconnect func()) error {
+	if err := l.listener.Listen(configChangedChannel); err != nil {
+		return fmt.Errorf("failed to listen for config changes: %w", err)
+	}
+
+	ticker := time.NewTicker(configListenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-l.listener.Notify:
+			if n == nil {
+				onReconnect()
+				continue
+			}
+			tenant, prefix, ok := strings.Cut(n.Extra, ":")
+			if !ok {
+				// Sent by the trigger of a schema without tenants
+				tenant, prefix = models.DefaultTenant, n.Extra
+			}
+			if prefix, event, _ := strings.Cut(prefix, ":"); event == counterResetEvent {
+				onReset(tenant, prefix)
+				continue
+			}
+			onChange(tenant, prefix)
+		case <-ticker.C:
+			// Errors are handled by the listener's reconnect loop
+			_ = l.listener.Ping()
+		}
+	}
+}
+
+// Close closes the listener connection
+func (l *ConfigListener) Close() error {
+	return l.listener.Close()
+}
+
+// NotifyCounterReset tells the config listeners of every instance that a
+// counter of a prefix was reset
+func (r *PostgresRepository) NotifyCounterReset(ctx context.Context, tenant, prefix string) (err error) {
+	defer observeDB("notify_counter_reset", time.Now(), &err)
+
+	payload := tenant + ":" + prefix + ":" + counterResetEvent
+	if _, err = r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, configChangedChannel, payload); err != nil {
+		return fmt.Errorf("failed to notify counter reset: %w", err)
+	}
+	return nil
+}
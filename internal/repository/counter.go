@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+)
+
+// CounterStore holds the atomic counters IDs are issued from. Counter names
+// are derived by the service from the prefix and reset period.
+type CounterStore interface {
+	// IncrementCounter atomically increments a counter and returns the new value
+	IncrementCounter(ctx context.Context, name string) (int64, error)
+	// IncrementCounterBy atomically increments a counter by a specific amount
+	IncrementCounterBy(ctx context.Context, name string, increment int64) (int64, error)
+	// GetCounter returns the current value, or 0 for unknown counters
+	GetCounter(ctx context.Context, name string) (int64, error)
+	// SetCounter sets the counter to a specific value
+	SetCounter(ctx context.Context, name string, value int64) error
+	// ResetCounter sets the counter and returns the previous value atomically
+	ResetCounter(ctx context.Context, name string, newValue int64) (int64, error)
+	// CompareAndSetCounter sets the counter to newValue only while it still
+	// holds oldValue and reports whether it did
+	CompareAndSetCounter(ctx context.Context, name string, oldValue, newValue int64) (bool, error)
+
+	// Backend names the store in health checks
+	Backend() string
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// IdempotencyStore keeps the records used to replay idempotent requests
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey stores record under key unless the key exists. It
+	// reports whether the key was reserved and otherwise returns the existing
+	// record.
+	ReserveIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) (bool, []byte, error)
+	// StoreIdempotencyKey overwrites the record of a reserved idempotency key
+	StoreIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) error
+	// ReleaseIdempotencyKey deletes a reserved idempotency key
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// CounterBackend is a counter store that also keeps idempotency records, so
+// a deployment needs no further key-value store
+type CounterBackend interface {
+	CounterStore
+	IdempotencyStore
+}
+
+// NewCounterBackend opens the counter backend selected by cfg.Counter.Backend.
+// The Postgres backend shares the connection pool of db.
+func NewCounterBackend(cfg *config.Config, db *PostgresRepository) (CounterBackend, error) {
+	switch cfg.Counter.Backend {
+	case config.CounterBackendRedis:
+		return NewRedisRepository(cfg.Redis)
+	case config.CounterBackendPostgres:
+		return NewPostgresCounterStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown counter backend %q", cfg.Counter.Backend)
+	}
+}
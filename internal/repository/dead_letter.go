@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/streadway/amqp"
+)
+
+// deadLetterAction is what a dead letter scan does with a message
+type deadLetterAction int
+
+const (
+	deadLetterKeep   deadLetterAction = iota // requeue in the DLQ
+	deadLetterReplay                         // publish to the main exchange
+	deadLetterDrop                           // discard
+)
+
+// headerDeath is set by the broker on messages it dead-letters itself
+const headerDeath = "x-death"
+
+// deadLetterQueueName returns the name of the dead letter queue
+func (r *RabbitMQRepository) deadLetterQueueName() string {
+	return r.queueName + "_dlq"
+}
+
+// DeadLetterDepth returns the number of messages in the dead letter queue
+func (r *RabbitMQRepository) DeadLetterDepth(ctx context.Context) (_ int, err error) {
+	defer observeRabbitMQ("dlq_inspect", time.Now(), &err)
+
+	queue, err := r.channel.QueueInspect(r.deadLetterQueueName())
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect dead letter queue: %w", err)
+	}
+	return queue.Messages, nil
+}
+
+// PeekDeadLetters returns the matching messages among the first limit
+// messages of the dead letter queue, leaving them all queued. The second
+// result is the number of messages inspected.
+func (r *RabbitMQRepository) PeekDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return r.scanDeadLetters(ctx, "dlq_peek", limit, match, deadLetterKeep)
+}
+
+// ReplayDeadLetters publishes the matching messages among the first limit
+// messages of the dead letter queue back to the main exchange with a fresh
+// retry count. Other messages stay queued.
+func (r *RabbitMQRepository) ReplayDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return r.scanDeadLetters(ctx, "dlq_replay", limit, match, deadLetterReplay)
+}
+
+// DropDeadLetters discards the matching messages among the first limit
+// messages of the dead letter queue. Other messages stay queued.
+func (r *RabbitMQRepository) DropDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return r.scanDeadLetters(ctx, "dlq_drop", limit, match, deadLetterDrop)
+}
+
+// scanDeadLetters fetches up to limit messages from the dead letter queue,
+// applies action to the matching ones and returns them. Other messages are
+// requeued. On failure the messages handled so far are returned with the
+// error.
+//
+// The scan runs on its own channel and holds every fetched message
+// unacknowledged until it finishes, so no message is fetched twice. The
+// broker returns requeued messages to their original position, and closing
+// the channel requeues whatever is still unacknowledged if the scan fails.
+func (r *RabbitMQRepository) scanDeadLetters(
+	ctx context.Context,
+	op string,
+	limit int,
+	match func(*models.DeadLetter) bool,
+	action deadLetterAction,
+) (_ []models.DeadLetter, scanned int, err error) {
+	defer observeRabbitMQ(op, time.Now(), &err)
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	var (
+		matched []models.DeadLetter
+		kept    []amqp.Delivery
+	)
+	for scanned < limit {
+		if err := ctx.Err(); err != nil {
+			return matched, scanned, err
+		}
+
+		msg, ok, err := ch.Get(r.deadLetterQueueName(), false)
+		if err != nil {
+			return matched, scanned, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		scanned++
+
+		dl := parseDeadLetter(msg)
+		if !match(&dl) {
+			kept = append(kept, msg)
+			continue
+		}
+
+		switch action {
+		case deadLetterKeep:
+			kept = append(kept, msg)
+			matched = append(matched, dl)
+			continue
+		case deadLetterReplay:
+			if err := r.replay(ch, msg); err != nil {
+				return matched, scanned, err
+			}
+		}
+		if err := msg.Ack(false); err != nil {
+			return matched, scanned, fmt.Errorf("failed to acknowledge dead letter %s: %w", dl.MessageID, err)
+		}
+		matched = append(matched, dl)
+	}
+
+	for _, msg := range kept {
+		if err := msg.Nack(false, true); err != nil {
+			return matched, scanned, fmt.Errorf("failed to requeue dead letter: %w", err)
+		}
+	}
+
+	return matched, scanned, nil
+}
+
+// replay publishes a dead-lettered message to the main exchange with its
+// retry state cleared
+func (r *RabbitMQRepository) replay(ch amqpChannel, msg amqp.Delivery) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	delete(headers, headerLastError)
+	delete(headers, headerDeath)
+	headers[headerRetryCount] = int32(0)
+
+	err := ch.Publish(
+		r.exchangeName, // exchange
+		"seq.log",      // routing key
+		false,          // mandatory
+		false,          // immediate
+		amqp.Publishing{
+			DeliveryMode:  amqp.Persistent,
+			ContentType:   msg.ContentType,
+			Body:          msg.Body,
+			MessageId:     msg.MessageId,
+			Timestamp:     msg.Timestamp,
+			CorrelationId: msg.CorrelationId,
+			Headers:       headers,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to replay dead letter %s: %w", msg.MessageId, err)
+	}
+	return nil
+}
+
+// parseDeadLetter describes a dead-lettered message
+func parseDeadLetter(msg amqp.Delivery) models.DeadLetter {
+	dl := models.DeadLetter{
+		MessageID:   msg.MessageId,
+		RetryCount:  retryCount(msg.Headers),
+		Reason:      deadLetterReason(msg.Headers),
+		PublishedAt: msg.Timestamp,
+	}
+
+	var event models.Event
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		dl.Payload = string(msg.Body)
+		return dl
+	}
+
+	dl.Event = &event
+	dl.Tenant = event.Tenant
+	if dl.Tenant == "" {
+		dl.Tenant = models.DefaultTenant
+	}
+	dl.Prefix = event.Prefix
+	dl.FullNumber = event.FullNumber
+	if dl.MessageID == "" {
+		dl.MessageID = event.MessageID
+	}
+	return dl
+}
+
+// deadLetterReason returns the last_error header, falling back to the
+// reason the broker recorded when it dead-lettered the message itself
+func deadLetterReason(headers amqp.Table) string {
+	if reason, ok := headers[headerLastError].(string); ok {
+		return reason
+	}
+	if deaths, ok := headers[headerDeath].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if reason, ok := death["reason"].(string); ok {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// InsertDeadLetterAudit records a replay or purge of dead letters
+func (r *PostgresRepository) InsertDeadLetterAudit(ctx context.Context, audit *models.DeadLetterAudit) (err error) {
+	defer observeDB("insert_dlq_audit", time.Now(), &err)
+
+	messageIDs, err := json.Marshal(audit.MessageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message IDs: %w", err)
+	}
+
+	query := `
+		INSERT INTO seq_dlq_audit (tenant, action, message_ids, message_count, reason, admin_user)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, handled_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		audit.Tenant,
+		audit.Action,
+		string(messageIDs),
+		audit.Count,
+		audit.Reason,
+		audit.AdminUser,
+	).Scan(&audit.ID, &audit.HandledAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter audit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/streadway/amqp"
+)
+
+func TestParseDeadLetter(t *testing.T) {
+	published := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		msg  amqp.Delivery
+		want models.DeadLetter
+	}{
+		{
+			name: "retries exhausted",
+			msg: amqp.Delivery{
+				MessageId: "m1",
+				Timestamp: published,
+				Headers:   amqp.Table{headerRetryCount: int32(5), headerLastError: "pq: connection refused"},
+				Body:      []byte(`{"message_id":"m1","tenant":"acme","prefix":"SG","full_number":"SG000001"}`),
+			},
+			want: models.DeadLetter{MessageID: "m1", Tenant: "acme", Prefix: "SG", FullNumber: "SG000001", RetryCount: 5, Reason: "pq: connection refused", PublishedAt: published},
+		},
+		{
+			name: "expired by the broker",
+			msg: amqp.Delivery{
+				Headers: amqp.Table{headerDeath: []interface{}{amqp.Table{"reason": "expired"}}},
+				Body:    []byte(`{"message_id":"m2","prefix":"SG"}`),
+			},
+			want: models.DeadLetter{MessageID: "m2", Tenant: models.DefaultTenant, Prefix: "SG", Reason: "expired"},
+		},
+		{
+			name: "malformed payload",
+			msg:  amqp.Delivery{MessageId: "m3", Headers: amqp.Table{headerLastError: "failed to parse event"}, Body: []byte("{")},
+			want: models.DeadLetter{MessageID: "m3", Reason: "failed to parse event", Payload: "{"},
+		},
+	}
+	for _, tt := range tests {
+		got := parseDeadLetter(tt.msg)
+		if (got.Event != nil) != (tt.want.Payload == "") {
+			t.Errorf("%s: event = %+v, want one only for parseable payloads", tt.name, got.Event)
+		}
+		got.Event = nil
+		if got != tt.want {
+			t.Errorf("%s: parseDeadLetter = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplayResetsRetryState(t *testing.T) {
+	r, ch := newTestRabbitMQRepository()
+	msg := amqp.Delivery{
+		MessageId: "m1",
+		Headers: amqp.Table{
+			headerRetryCount: int32(5),
+			headerLastError:  "pq: connection refused",
+			headerDeath:      []interface{}{amqp.Table{"reason": "rejected"}},
+			"prefix":         "SG",
+		},
+		Body: []byte(`{"message_id":"m1"}`),
+	}
+
+	if err := r.replay(ch, msg); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	replayed := ch.published["seq.log"]
+	if len(replayed) != 1 {
+		t.Fatalf("published %v, want one message routed to seq.log", ch.published)
+	}
+	headers := replayed[0].Headers
+	if headers[headerRetryCount] != int32(0) || headers["prefix"] != "SG" {
+		t.Errorf("headers = %v, want retry_count 0 and the other headers kept", headers)
+	}
+	for _, cleared := range []string{headerLastError, headerDeath} {
+		if _, ok := headers[cleared]; ok {
+			t.Errorf("replayed message still has header %s", cleared)
+		}
+	}
+	if string(replayed[0].Body) != string(msg.Body) || replayed[0].MessageId != "m1" {
+		t.Errorf("replayed %+v, want a copy of the message", replayed[0])
+	}
+
+	ch.failing["seq.log"] = true
+	if err := r.replay(ch, msg); err == nil {
+		t.Error("replay with the exchange unavailable = nil, want an error")
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// InsertOutboxEvents durably stores audit events for the outbox relay
+func (r *PostgresRepository) InsertOutboxEvents(ctx context.Context, events []*models.Event) (err error) {
+	defer observeDB("insert_outbox_events", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO seq_outbox (message_id, prefix, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare outbox insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, event.MessageID, event.Prefix, payload); err != nil {
+			return fmt.Errorf("failed to insert outbox event %s: %w", event.MessageID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit outbox events: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimOutboxEvents leases up to limit due events, oldest first. Claimed
+// events are hidden from other relays until the lease expires.
+func (r *PostgresRepository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) (_ []models.OutboxEntry, err error) {
+	defer observeDB("claim_outbox_events", time.Now(), &err)
+
+	var entries []models.OutboxEntry
+	query := `
+		UPDATE seq_outbox
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id
+			FROM seq_outbox
+			WHERE next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, message_id, prefix, payload, attempts, last_error, created_at, next_attempt_at
+	`
+
+	err = r.db.SelectContext(ctx, &entries, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteOutboxEvents removes published events from the outbox
+func (r *PostgresRepository) DeleteOutboxEvents(ctx context.Context, ids []int64) (err error) {
+	defer observeDB("delete_outbox_events", time.Now(), &err)
+
+	_, err = r.db.ExecContext(ctx, `DELETE FROM seq_outbox WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to delete outbox events: %w", err)
+	}
+
+	return nil
+}
+
+// RescheduleOutboxEvent records a failed publish and the time of the next attempt
+func (r *PostgresRepository) RescheduleOutboxEvent(ctx context.Context, id int64, nextAttempt time.Time, lastError string) (err error) {
+	defer observeDB("reschedule_outbox_event", time.Now(), &err)
+
+	query := `
+		UPDATE seq_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	_, err = r.db.ExecContext(ctx, query, id, lastError, nextAttempt)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox event %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// GetOutboxStats summarizes the outbox backlog
+func (r *PostgresRepository) GetOutboxStats(ctx context.Context) (_ *models.OutboxStats, err error) {
+	defer observeDB("get_outbox_stats", time.Now(), &err)
+
+	var stats models.OutboxStats
+	query := `
+		SELECT COUNT(*) AS pending,
+		       COUNT(*) FILTER (WHERE attempts > 0) AS retrying,
+		       MIN(created_at) AS oldest
+		FROM seq_outbox
+	`
+
+	err = r.db.GetContext(ctx, &stats, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+
+	return &stats, nil
+}
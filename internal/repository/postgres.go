@@ -3,15 +3,21 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
 )
 
+// uniqueViolation is the SQLSTATE of inserts conflicting with a unique index
+const uniqueViolation = "23505"
+
 // PostgresRepository handles PostgreSQL operations
 type PostgresRepository struct {
 	db *sqlx.DB
@@ -39,17 +45,18 @@ func NewPostgresRepository(cfg config.DatabaseConfig) (*PostgresRepository, erro
 	}, nil
 }
 
-// GetPrefixConfig retrieves configuration for a prefix
-func (r *PostgresRepository) GetPrefixConfig(ctx context.Context, prefix string) (*models.PrefixConfig, error) {
+// GetPrefixConfig retrieves configuration for a prefix of a tenant
+func (r *PostgresRepository) GetPrefixConfig(ctx context.Context, tenant, prefix string) (_ *models.PrefixConfig, err error) {
+	defer observeDB("get_prefix_config", time.Now(), &err)
+
 	var config models.PrefixConfig
 	query := `
-		SELECT id, prefix, padding_length, format_template, reset_rule, 
-		       last_reset_at, created_at, updated_at, created_by, updated_by
+		SELECT ` + prefixConfigColumns + `
 		FROM seq_config 
-		WHERE prefix = $1
+		WHERE tenant = $1 AND prefix = $2
 	`
 
-	err := r.db.GetContext(ctx, &config, query, prefix)
+	err = r.db.GetContext(ctx, &config, query, tenant, prefix)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
 	}
@@ -60,31 +67,76 @@ func (r *PostgresRepository) GetPrefixConfig(ctx context.Context, prefix string)
 	return &config, nil
 }
 
-// CreatePrefixConfig creates a new prefix configuration
-func (r *PostgresRepository) CreatePrefixConfig(ctx context.Context, config *models.PrefixConfig) error {
+// CreatePrefixConfig creates a new prefix configuration and records the
+// change in seq_config_audit
+func (r *PostgresRepository) CreatePrefixConfig(ctx context.Context, config *models.PrefixConfig) (err error) {
+	defer observeDB("create_prefix_config", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO seq_config (prefix, padding_length, format_template, reset_rule, created_by)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO seq_config (tenant, prefix, padding_length, format_template, reset_rule, gapless, block_size, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
+		config.Tenant,
 		config.Prefix,
 		config.PaddingLength,
 		config.FormatTemplate,
 		config.ResetRule,
+		config.Gapless,
+		config.BlockSize,
 		config.CreatedBy,
 	).Scan(&config.ID, &config.CreatedAt, &config.UpdatedAt)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrPrefixExists, config.Prefix)
+		}
 		return fmt.Errorf("failed to create prefix config: %w", err)
 	}
 
+	adminUser := ""
+	if config.CreatedBy != nil {
+		adminUser = *config.CreatedBy
+	}
+	if err := insertConfigChange(ctx, tx, config.Tenant, config.Prefix, models.ConfigChangeCreate, adminUser, "", nil, config); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prefix config: %w", err)
+	}
+
 	return nil
 }
 
-// UpdatePrefixConfig updates an existing prefix configuration
-func (r *PostgresRepository) UpdatePrefixConfig(ctx context.Context, prefix string, updates map[string]interface{}) error {
+// UpdatePrefixConfig updates an existing prefix configuration and records
+// the change, with the changed fields, in seq_config_audit
+func (r *PostgresRepository) UpdatePrefixConfig(ctx context.Context, tenant, prefix string, updates map[string]interface{}, changeType, adminUser string) (err error) {
+	defer observeDB("update_prefix_config", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := selectPrefixConfigForUpdate(ctx, tx, tenant, prefix)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	}
+
 	// Build dynamic update query
 	setParts := []string{}
 	args := []interface{}{}
@@ -102,45 +154,113 @@ func (r *PostgresRepository) UpdatePrefixConfig(ctx context.Context, prefix stri
 	argIndex++
 
 	// Add WHERE clause
-	args = append(args, prefix)
+	args = append(args, tenant, prefix)
 
 	query := fmt.Sprintf(`
 		UPDATE seq_config 
 		SET %s
-		WHERE prefix = $%d
+		WHERE tenant = $%d AND prefix = $%d
+		RETURNING %s
 	`,
-		fmt.Sprintf("%s", setParts),
+		strings.Join(setParts, ", "),
 		argIndex,
+		argIndex+1,
+		prefixConfigColumns,
 	)
 
-	result, err := r.db.ExecContext(ctx, query, args...)
-	if err != nil {
+	var updated models.PrefixConfig
+	if err := tx.GetContext(ctx, &updated, query, args...); err != nil {
 		return fmt.Errorf("failed to update prefix config: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err := insertConfigChange(ctx, tx, tenant, prefix, changeType, adminUser, "", old, &updated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prefix config update: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePrefixConfig deletes the configuration of a prefix and records the
+// deletion in seq_config_audit. Unless force is set, a prefix with entries in
+// seq_log is kept and deleted is false. Counters and seq_log are left in
+// place so a recreated prefix continues its sequence.
+func (r *PostgresRepository) DeletePrefixConfig(ctx context.Context, tenant, prefix string, force bool, adminUser, reason string) (deleted bool, err error) {
+	defer observeDB("delete_prefix_config", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to check affected rows: %w", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	old, err := selectPrefixConfigForUpdate(ctx, tx, tenant, prefix)
+	if err != nil {
+		return false, err
+	}
+	if old == nil {
+		return false, fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	}
+
+	if !force {
+		var issued bool
+		query := `SELECT EXISTS (SELECT 1 FROM seq_log WHERE tenant = $1 AND prefix = $2)`
+		if err := tx.GetContext(ctx, &issued, query, tenant, prefix); err != nil {
+			return false, fmt.Errorf("failed to check audit logs of prefix %s: %w", prefix, err)
+		}
+		if issued {
+			return false, nil
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM seq_config WHERE tenant = $1 AND prefix = $2`, tenant, prefix); err != nil {
+		return false, fmt.Errorf("failed to delete prefix config: %w", err)
+	}
+
+	if err := insertConfigChange(ctx, tx, tenant, prefix, models.ConfigChangeDelete, adminUser, reason, old, nil); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit prefix config deletion: %w", err)
+	}
+
+	return true, nil
+}
+
+// MarkPeriodReset advances last_reset_at to the start of a new counter period.
+// The update is conditional so concurrent instances only move it forward.
+func (r *PostgresRepository) MarkPeriodReset(ctx context.Context, tenant, prefix string, periodStart time.Time) (err error) {
+	defer observeDB("mark_period_reset", time.Now(), &err)
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("prefix %s not found", prefix)
+	query := `
+		UPDATE seq_config
+		SET last_reset_at = $3
+		WHERE tenant = $1 AND prefix = $2 AND (last_reset_at IS NULL OR last_reset_at < $3)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, tenant, prefix, periodStart); err != nil {
+		return fmt.Errorf("failed to mark period reset for prefix %s: %w", prefix, err)
 	}
 
 	return nil
 }
 
-// GetAllPrefixConfigs retrieves all prefix configurations
-func (r *PostgresRepository) GetAllPrefixConfigs(ctx context.Context) ([]models.PrefixConfig, error) {
+// GetAllPrefixConfigs retrieves the prefix configurations of all tenants
+func (r *PostgresRepository) GetAllPrefixConfigs(ctx context.Context) (_ []models.PrefixConfig, err error) {
+	defer observeDB("get_all_prefix_configs", time.Now(), &err)
+
 	var configs []models.PrefixConfig
 	query := `
-		SELECT id, prefix, padding_length, format_template, reset_rule,
-		       last_reset_at, created_at, updated_at, created_by, updated_by
+		SELECT ` + prefixConfigColumns + `
 		FROM seq_config
-		ORDER BY prefix
+		ORDER BY tenant, prefix
 	`
 
-	err := r.db.SelectContext(ctx, &configs, query)
+	err = r.db.SelectContext(ctx, &configs, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all prefix configs: %w", err)
 	}
@@ -148,19 +268,91 @@ func (r *PostgresRepository) GetAllPrefixConfigs(ctx context.Context) ([]models.
 	return configs, nil
 }
 
-// InsertAuditLog inserts an audit log entry
-func (r *PostgresRepository) InsertAuditLog(ctx context.Context, log *models.AuditLog) error {
+// GetTenantPrefixConfigs retrieves the prefix configurations of a tenant
+func (r *PostgresRepository) GetTenantPrefixConfigs(ctx context.Context, tenant string) (_ []models.PrefixConfig, err error) {
+	defer observeDB("get_tenant_prefix_configs", time.Now(), &err)
+
+	var configs []models.PrefixConfig
+	query := `
+		SELECT ` + prefixConfigColumns + `
+		FROM seq_config
+		WHERE tenant = $1
+		ORDER BY prefix
+	`
+
+	err = r.db.SelectContext(ctx, &configs, query, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prefix configs of tenant %s: %w", tenant, err)
+	}
+
+	return configs, nil
+}
+
+// GetLastIssuedTimes returns the generation time of the newest issued
+// audit entry of every configured prefix of a tenant that has one
+func (r *PostgresRepository) GetLastIssuedTimes(ctx context.Context, tenant string) (_ map[string]time.Time, err error) {
+	defer observeDB("get_last_issued_times", time.Now(), &err)
+
+	var rows []struct {
+		Prefix      string    `db:"prefix"`
+		GeneratedAt time.Time `db:"generated_at"`
+	}
 	query := `
-		INSERT INTO seq_log (prefix, counter_value, full_number, generated_by, client_id,
-		                    correlation_id, message_id, generated_at, published_at, batch_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (prefix, counter_value) DO NOTHING
-		RETURNING id, inserted_at
+		SELECT c.prefix, l.generated_at
+		FROM seq_config c
+		CROSS JOIN LATERAL (
+			SELECT generated_at
+			FROM seq_log
+			WHERE tenant = c.tenant AND prefix = c.prefix AND status = 'issued'
+			ORDER BY generated_at DESC
+			LIMIT 1
+		) l
+		WHERE c.tenant = $1
 	`
 
-	err := r.db.QueryRowContext(ctx, query,
+	if err := r.db.SelectContext(ctx, &rows, query, tenant); err != nil {
+		return nil, fmt.Errorf("failed to get last issued times: %w", err)
+	}
+
+	times := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		times[row.Prefix] = row.GeneratedAt
+	}
+
+	return times, nil
+}
+
+// insertAuditLogQuery records an issued ID. A late event replaces the
+// placeholder written for it by reconciliation; other duplicates are ignored,
+// as are events voided by a placeholder carrying their own message ID.
+const insertAuditLogQuery = `
+	INSERT INTO seq_log (tenant, prefix, counter_value, period_key, full_number, generated_by, client_id,
+	                    correlation_id, message_id, generated_at, published_at, batch_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (tenant, prefix, period_key, counter_value) DO UPDATE SET
+		full_number = EXCLUDED.full_number,
+		generated_by = EXCLUDED.generated_by,
+		client_id = EXCLUDED.client_id,
+		correlation_id = EXCLUDED.correlation_id,
+		message_id = EXCLUDED.message_id,
+		generated_at = EXCLUDED.generated_at,
+		published_at = EXCLUDED.published_at,
+		inserted_at = NOW(),
+		batch_id = EXCLUDED.batch_id,
+		status = 'issued'
+	WHERE seq_log.status = 'lost' AND seq_log.message_id IS DISTINCT FROM EXCLUDED.message_id
+	RETURNING id, inserted_at
+`
+
+// InsertAuditLog inserts an audit log entry
+func (r *PostgresRepository) InsertAuditLog(ctx context.Context, log *models.AuditLog) (err error) {
+	defer observeDB("insert_audit_log", time.Now(), &err)
+
+	err = r.db.QueryRowContext(ctx, insertAuditLogQuery,
+		log.Tenant,
 		log.Prefix,
 		log.CounterValue,
+		log.PeriodKey,
 		log.FullNumber,
 		log.GeneratedBy,
 		log.ClientID,
@@ -174,7 +366,7 @@ func (r *PostgresRepository) InsertAuditLog(ctx context.Context, log *models.Aud
 	if err != nil {
 		// Check if it's a conflict (duplicate)
 		if err == sql.ErrNoRows {
-			// ON CONFLICT DO NOTHING was triggered
+			// The ID is already audited
 			return nil
 		}
 		return fmt.Errorf("failed to insert audit log: %w", err)
@@ -183,16 +375,19 @@ func (r *PostgresRepository) InsertAuditLog(ctx context.Context, log *models.Aud
 	return nil
 }
 
-// GetMaxCounter retrieves the maximum counter value for a prefix
-func (r *PostgresRepository) GetMaxCounter(ctx context.Context, prefix string) (int64, error) {
+// GetMaxCounter retrieves the maximum counter value for a prefix within a
+// counter period ("" for prefixes that never reset)
+func (r *PostgresRepository) GetMaxCounter(ctx context.Context, tenant, prefix, periodKey string) (_ int64, err error) {
+	defer observeDB("get_max_counter", time.Now(), &err)
+
 	var maxCounter sql.NullInt64
 	query := `
 		SELECT MAX(counter_value)
 		FROM seq_log
-		WHERE prefix = $1
+		WHERE tenant = $1 AND prefix = $2 AND period_key = $3
 	`
 
-	err := r.db.QueryRowContext(ctx, query, prefix).Scan(&maxCounter)
+	err = r.db.QueryRowContext(ctx, query, tenant, prefix, periodKey).Scan(&maxCounter)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get max counter for prefix %s: %w", prefix, err)
 	}
@@ -205,19 +400,24 @@ func (r *PostgresRepository) GetMaxCounter(ctx context.Context, prefix string) (
 }
 
 // UpdateCheckpoint updates or creates a checkpoint
-func (r *PostgresRepository) UpdateCheckpoint(ctx context.Context, checkpoint *models.Checkpoint) error {
+func (r *PostgresRepository) UpdateCheckpoint(ctx context.Context, checkpoint *models.Checkpoint) (err error) {
+	defer observeDB("update_checkpoint", time.Now(), &err)
+
 	query := `
-		INSERT INTO seq_checkpoint (prefix, last_counter_synced, synced_by)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (prefix) 
+		INSERT INTO seq_checkpoint (tenant, prefix, period_key, last_counter_synced, synced_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant, prefix) 
 		DO UPDATE SET 
+			period_key = EXCLUDED.period_key,
 			last_counter_synced = EXCLUDED.last_counter_synced,
 			synced_at = NOW(),
 			synced_by = EXCLUDED.synced_by
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
+		checkpoint.Tenant,
 		checkpoint.Prefix,
+		checkpoint.PeriodKey,
 		checkpoint.LastCounterSynced,
 		checkpoint.SyncedBy,
 	)
@@ -229,16 +429,18 @@ func (r *PostgresRepository) UpdateCheckpoint(ctx context.Context, checkpoint *m
 	return nil
 }
 
-// GetCheckpoint retrieves a checkpoint for a prefix
-func (r *PostgresRepository) GetCheckpoint(ctx context.Context, prefix string) (*models.Checkpoint, error) {
+// GetCheckpoint retrieves a checkpoint for a prefix of a tenant
+func (r *PostgresRepository) GetCheckpoint(ctx context.Context, tenant, prefix string) (_ *models.Checkpoint, err error) {
+	defer observeDB("get_checkpoint", time.Now(), &err)
+
 	var checkpoint models.Checkpoint
 	query := `
-		SELECT prefix, last_counter_synced, synced_at, synced_by
+		SELECT tenant, prefix, period_key, last_counter_synced, synced_at, synced_by
 		FROM seq_checkpoint
-		WHERE prefix = $1
+		WHERE tenant = $1 AND prefix = $2
 	`
 
-	err := r.db.GetContext(ctx, &checkpoint, query, prefix)
+	err = r.db.GetContext(ctx, &checkpoint, query, tenant, prefix)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
 	}
@@ -250,15 +452,19 @@ func (r *PostgresRepository) GetCheckpoint(ctx context.Context, prefix string) (
 }
 
 // InsertResetLog logs a counter reset operation
-func (r *PostgresRepository) InsertResetLog(ctx context.Context, resetLog *models.ResetLog) error {
+func (r *PostgresRepository) InsertResetLog(ctx context.Context, resetLog *models.ResetLog) (err error) {
+	defer observeDB("insert_reset_log", time.Now(), &err)
+
 	query := `
-		INSERT INTO seq_reset_log (prefix, old_value, new_value, reason, admin_user, reset_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO seq_reset_log (tenant, prefix, period_key, old_value, new_value, reason, admin_user, reset_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, reset_at
 	`
 
-	err := r.db.QueryRowContext(ctx, query,
+	err = r.db.QueryRowContext(ctx, query,
+		resetLog.Tenant,
 		resetLog.Prefix,
+		resetLog.PeriodKey,
 		resetLog.OldValue,
 		resetLog.NewValue,
 		resetLog.Reason,
@@ -273,28 +479,295 @@ func (r *PostgresRepository) InsertResetLog(ctx context.Context, resetLog *model
 	return nil
 }
 
-// GetAuditLogs retrieves audit logs with pagination
-func (r *PostgresRepository) GetAuditLogs(ctx context.Context, prefix string, limit, offset int) ([]models.AuditLog, error) {
+// QueryAuditLogs retrieves audit logs matching the query, newest first.
+// Pagination is keyset based on (generated_at, id) so deep pages stay cheap;
+// after is the position of the last entry of the previous page.
+func (r *PostgresRepository) QueryAuditLogs(ctx context.Context, q *models.AuditQuery, after *models.AuditCursor, limit int) (_ []models.AuditLog, err error) {
+	defer observeDB("query_audit_logs", time.Now(), &err)
+
+	conditions := []string{}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.Tenant != "" {
+		add("tenant = $%d", q.Tenant)
+	}
+	if q.Prefix != "" {
+		add("prefix = $%d", q.Prefix)
+	}
+	if q.PeriodKey != nil {
+		add("period_key = $%d", *q.PeriodKey)
+	}
+	if q.CounterFrom != nil {
+		add("counter_value >= $%d", *q.CounterFrom)
+	}
+	if q.CounterTo != nil {
+		add("counter_value <= $%d", *q.CounterTo)
+	}
+	if q.From != nil {
+		add("generated_at >= $%d", *q.From)
+	}
+	if q.To != nil {
+		add("generated_at < $%d", *q.To)
+	}
+	if q.ClientID != "" {
+		add("client_id = $%d", q.ClientID)
+	}
+	if q.GeneratedBy != "" {
+		add("generated_by = $%d", q.GeneratedBy)
+	}
+	if q.BatchID != "" {
+		add("batch_id = $%d", q.BatchID)
+	}
+	if q.CorrelationID != "" {
+		add("correlation_id = $%d", q.CorrelationID)
+	}
+	if after != nil {
+		args = append(args, after.GeneratedAt, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(generated_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT id, tenant, prefix, counter_value, period_key, full_number, generated_by, client_id,
+		       correlation_id, message_id, generated_at, published_at, inserted_at, batch_id, status
+		FROM seq_log
+		%s
+		ORDER BY generated_at DESC, id DESC
+		LIMIT $%d
+	`, where, len(args))
+
+	var logs []models.AuditLog
+	if err := r.db.SelectContext(ctx, &logs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+// GetAuditLogsByFullNumber retrieves the audit log entries recorded for a
+// full number within a tenant, newest first. Prefixes that reset may reuse a
+// number across periods, so more than one entry can match.
+func (r *PostgresRepository) GetAuditLogsByFullNumber(ctx context.Context, tenant, fullNumber string) (_ []models.AuditLog, err error) {
+	defer observeDB("get_audit_logs_by_full_number", time.Now(), &err)
+
 	var logs []models.AuditLog
 	query := `
-		SELECT id, prefix, counter_value, full_number, generated_by, client_id,
-		       correlation_id, message_id, generated_at, published_at, inserted_at, batch_id
+		SELECT id, tenant, prefix, counter_value, period_key, full_number, generated_by, client_id,
+		       correlation_id, message_id, generated_at, published_at, inserted_at, batch_id, status
 		FROM seq_log
-		WHERE prefix = $1
-		ORDER BY counter_value DESC
-		LIMIT $2 OFFSET $3
+		WHERE tenant = $1 AND full_number = $2
+		ORDER BY generated_at DESC, id DESC
 	`
 
-	err := r.db.SelectContext(ctx, &logs, query, prefix, limit, offset)
+	err = r.db.SelectContext(ctx, &logs, query, tenant, fullNumber)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get audit logs for prefix %s: %w", prefix, err)
+		return nil, fmt.Errorf("failed to get audit logs for %s: %w", fullNumber, err)
 	}
 
 	return logs, nil
 }
 
+// GetBatchSummary summarizes the audited entries of a batch
+func (r *PostgresRepository) GetBatchSummary(ctx context.Context, batchID string) (_ *models.BatchSummary, err error) {
+	defer observeDB("get_batch_summary", time.Now(), &err)
+
+	var summary models.BatchSummary
+	query := `
+		SELECT batch_id, COUNT(*) AS size,
+		       MIN(counter_value) AS first_counter, MAX(counter_value) AS last_counter
+		FROM seq_log
+		WHERE batch_id = $1
+		GROUP BY batch_id
+	`
+
+	err = r.db.GetContext(ctx, &summary, query, batchID)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch %s: %w", batchID, err)
+	}
+
+	return &summary, nil
+}
+
+// GetResetLogs retrieves the reset history of a prefix within a counter
+// period, newest first
+func (r *PostgresRepository) GetResetLogs(ctx context.Context, tenant, prefix, periodKey string) (_ []models.ResetLog, err error) {
+	defer observeDB("get_reset_logs", time.Now(), &err)
+
+	var resets []models.ResetLog
+	query := `
+		SELECT id, tenant, prefix, period_key, old_value, new_value, reason, admin_user, reset_id, reset_at
+		FROM seq_reset_log
+		WHERE tenant = $1 AND prefix = $2 AND period_key = $3
+		ORDER BY reset_at DESC, id DESC
+	`
+
+	err = r.db.SelectContext(ctx, &resets, query, tenant, prefix, periodKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reset logs for prefix %s: %w", prefix, err)
+	}
+
+	return resets, nil
+}
+
+// GetResetHistory retrieves the most recent resets of a prefix across all
+// counter periods, or within one period when periodKey is set, newest first
+func (r *PostgresRepository) GetResetHistory(ctx context.Context, tenant, prefix string, periodKey *string, limit int) (_ []models.ResetLog, err error) {
+	defer observeDB("get_reset_history", time.Now(), &err)
+
+	resets := []models.ResetLog{}
+	query := `
+		SELECT id, tenant, prefix, period_key, old_value, new_value, reason, admin_user, reset_id, reset_at
+		FROM seq_reset_log
+		WHERE tenant = $1 AND prefix = $2 AND ($3::text IS NULL OR period_key = $3)
+		ORDER BY reset_at DESC, id DESC
+		LIMIT $4
+	`
+
+	err = r.db.SelectContext(ctx, &resets, query, tenant, prefix, periodKey, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reset history for prefix %s: %w", prefix, err)
+	}
+
+	return resets, nil
+}
+
+// GetAuditPeriods lists the counter periods with audit entries for a prefix
+func (r *PostgresRepository) GetAuditPeriods(ctx context.Context, tenant, prefix string) (_ []string, err error) {
+	defer observeDB("get_audit_periods", time.Now(), &err)
+
+	var periods []string
+	query := `
+		SELECT DISTINCT period_key
+		FROM seq_log
+		WHERE tenant = $1 AND prefix = $2
+		ORDER BY period_key
+	`
+
+	err = r.db.SelectContext(ctx, &periods, query, tenant, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit periods for prefix %s: %w", prefix, err)
+	}
+
+	return periods, nil
+}
+
+// GetAuditStats summarizes the audit entries of a prefix within a counter period
+func (r *PostgresRepository) GetAuditStats(ctx context.Context, tenant, prefix, periodKey string) (_ *models.AuditStats, err error) {
+	defer observeDB("get_audit_stats", time.Now(), &err)
+
+	var stats models.AuditStats
+	query := `
+		SELECT COUNT(*) AS count,
+		       COALESCE(MIN(counter_value), 0) AS min_counter,
+		       COALESCE(MAX(counter_value), 0) AS max_counter,
+		       COUNT(*) FILTER (WHERE status = 'lost') AS lost_count,
+		       MIN(generated_at) AS first_generated,
+		       MAX(generated_at) AS last_generated
+		FROM seq_log
+		WHERE tenant = $1 AND prefix = $2 AND period_key = $3
+	`
+
+	err = r.db.GetContext(ctx, &stats, query, tenant, prefix, periodKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit stats for prefix %s: %w", prefix, err)
+	}
+
+	return &stats, nil
+}
+
+// FindCounterGaps returns the ranges of counter values missing between
+// audited entries of a prefix period, in counter order
+func (r *PostgresRepository) FindCounterGaps(ctx context.Context, tenant, prefix, periodKey string) (_ []models.GapBounds, err error) {
+	defer observeDB("find_counter_gaps", time.Now(), &err)
+
+	var gaps []models.GapBounds
+	query := `
+		SELECT counter_value + 1 AS gap_from, next_value - 1 AS gap_to, generated_at AS before_at
+		FROM (
+			SELECT counter_value, generated_at,
+			       LEAD(counter_value) OVER (ORDER BY counter_value) AS next_value
+			FROM seq_log
+			WHERE tenant = $1 AND prefix = $2 AND period_key = $3
+		) audited
+		WHERE next_value > counter_value + 1
+		ORDER BY gap_from
+	`
+
+	err = r.db.SelectContext(ctx, &gaps, query, tenant, prefix, periodKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find counter gaps for prefix %s: %w", prefix, err)
+	}
+
+	return gaps, nil
+}
+
+// InsertLostAuditLogs writes placeholder entries for counter values whose
+// audit events were lost. Values audited in the meantime are left untouched,
+// except by a placeholder carrying the message ID of the audited event: the
+// ID was never returned to its caller, so the entry is marked lost again.
+func (r *PostgresRepository) InsertLostAuditLogs(ctx context.Context, logs []models.AuditLog) (_ int64, err error) {
+	defer observeDB("insert_lost_audit_logs", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO seq_log (tenant, prefix, counter_value, period_key, full_number, generated_by,
+		                    message_id, generated_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'lost')
+		ON CONFLICT (tenant, prefix, period_key, counter_value) DO UPDATE SET status = 'lost'
+		WHERE seq_log.message_id = EXCLUDED.message_id AND seq_log.status = 'issued'
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare placeholder insert: %w", err)
+	}
+	defer stmt.Close()
+
+	var inserted int64
+	for _, log := range logs {
+		result, err := stmt.ExecContext(ctx,
+			log.Tenant,
+			log.Prefix,
+			log.CounterValue,
+			log.PeriodKey,
+			log.FullNumber,
+			log.GeneratedBy,
+			log.MessageID,
+			log.GeneratedAt,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert placeholder for %s counter %d: %w", log.Prefix, log.CounterValue, err)
+		}
+		n, _ := result.RowsAffected()
+		inserted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit placeholders: %w", err)
+	}
+
+	return inserted, nil
+}
+
 // Ping checks database connectivity
-func (r *PostgresRepository) Ping(ctx context.Context) error {
+func (r *PostgresRepository) Ping(ctx context.Context) (err error) {
+	defer observeDB("ping", time.Now(), &err)
+
 	return r.db.PingContext(ctx)
 }
 
@@ -312,3 +785,8 @@ func (r *PostgresRepository) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
 func (r *PostgresRepository) GetStats() sql.DBStats {
 	return r.db.Stats()
 }
+
+// observeDB records the latency and outcome of a database operation
+func observeDB(operation string, start time.Time, err *error) {
+	metrics.ObserveOperation(metrics.BackendPostgres, operation, start, *err)
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+)
+
+// idempotencyPurgeLimit bounds the expired idempotency records removed with
+// each new reservation
+const idempotencyPurgeLimit = 10
+
+// PostgresCounterStore keeps counters in the seq_counter table for
+// deployments without Redis. Increments lock the counter row for the rest of
+// the statement, so concurrent callers are serialized per counter and get
+// the same unique, gap-free values as with Redis INCR.
+type PostgresCounterStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresCounterStore creates a counter store on the repository's
+// connection pool
+func NewPostgresCounterStore(repo *PostgresRepository) *PostgresCounterStore {
+	return &PostgresCounterStore{
+		db: repo.db,
+	}
+}
+
+// IncrementCounter atomically increments a counter and returns the new value
+func (s *PostgresCounterStore) IncrementCounter(ctx context.Context, name string) (int64, error) {
+	return s.IncrementCounterBy(ctx, name, 1)
+}
+
+// IncrementCounterBy atomically increments a counter by a specific amount
+func (s *PostgresCounterStore) IncrementCounterBy(ctx context.Context, name string, increment int64) (_ int64, err error) {
+	defer observeDB("increment_counter", time.Now(), &err)
+
+	var value int64
+	query := `
+		INSERT INTO seq_counter (name, value)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE
+		SET value = seq_counter.value + EXCLUDED.value, updated_at = NOW()
+		RETURNING value
+	`
+
+	err = s.db.QueryRowContext(ctx, query, name, increment).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter for prefix %s by %d: %w", name, increment, err)
+	}
+
+	return value, nil
+}
+
+// GetCounter gets the current counter value
+func (s *PostgresCounterStore) GetCounter(ctx context.Context, name string) (_ int64, err error) {
+	defer observeDB("get_counter", time.Now(), &err)
+
+	var value int64
+	err = s.db.GetContext(ctx, &value, `SELECT value FROM seq_counter WHERE name = $1`, name)
+	if err == sql.ErrNoRows {
+		return 0, nil // Counter doesn't exist, return 0
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter for prefix %s: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// SetCounter sets the counter to a specific value
+func (s *PostgresCounterStore) SetCounter(ctx context.Context, name string, value int64) (err error) {
+	defer observeDB("set_counter", time.Now(), &err)
+
+	query := `
+		INSERT INTO seq_counter (name, value)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE
+		SET value = EXCLUDED.value, updated_at = NOW()
+	`
+
+	if _, err = s.db.ExecContext(ctx, query, name, value); err != nil {
+		return fmt.Errorf("failed to set counter for prefix %s to %d: %w", name, value, err)
+	}
+
+	return nil
+}
+
+// ResetCounter resets a counter to a specific value (used for admin operations)
+func (s *PostgresCounterStore) ResetCounter(ctx context.Context, name string, newValue int64) (_ int64, err error) {
+	defer observeDB("reset_counter", time.Now(), &err)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Create the row first so the old value can be read under its lock
+	_, err = tx.ExecContext(ctx, `INSERT INTO seq_counter (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset counter for prefix %s: %w", name, err)
+	}
+
+	var oldValue int64
+	err = tx.GetContext(ctx, &oldValue, `SELECT value FROM seq_counter WHERE name = $1 FOR UPDATE`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset counter for prefix %s: %w", name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE seq_counter SET value = $2, updated_at = NOW() WHERE name = $1`, name, newValue)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset counter for prefix %s: %w", name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to reset counter for prefix %s: %w", name, err)
+	}
+
+	return oldValue, nil
+}
+
+// CompareAndSetCounter sets the counter to newValue only while it still
+// holds oldValue
+func (s *PostgresCounterStore) CompareAndSetCounter(ctx context.Context, name string, oldValue, newValue int64) (_ bool, err error) {
+	defer observeDB("compare_and_set_counter", time.Now(), &err)
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE seq_counter
+		SET value = $3, updated_at = NOW()
+		WHERE name = $1 AND value = $2
+	`, name, oldValue, newValue)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare and set counter for prefix %s: %w", name, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// ReserveIdempotencyKey stores record under key unless an unexpired record
+// exists. It reports whether the key was reserved and otherwise returns the
+// existing record. Each reservation purges a few expired records.
+func (s *PostgresCounterStore) ReserveIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) (_ bool, _ []byte, err error) {
+	defer observeDB("reserve_idempotency_key", time.Now(), &err)
+
+	query := `
+		WITH purged AS (
+			DELETE FROM seq_idempotency
+			WHERE key IN (
+				SELECT key
+				FROM seq_idempotency
+				WHERE expires_at <= NOW() AND key <> $1
+				LIMIT $4
+				FOR UPDATE SKIP LOCKED
+			)
+		)
+		INSERT INTO seq_idempotency (key, record, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at
+		WHERE seq_idempotency.expires_at <= NOW()
+		RETURNING key
+	`
+
+	var reservedKey string
+	err = s.db.QueryRowContext(ctx, query, key, record, time.Now().Add(ttl), idempotencyPurgeLimit).Scan(&reservedKey)
+	if err == nil {
+		return true, nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var existing []byte
+	err = s.db.GetContext(ctx, &existing, `SELECT record FROM seq_idempotency WHERE key = $1`, key)
+	if err == sql.ErrNoRows {
+		// Released between the insert and the read; the caller may retry
+		return false, nil, fmt.Errorf("idempotency key expired while being read")
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+
+	return false, existing, nil
+}
+
+// StoreIdempotencyKey overwrites the record of a reserved idempotency key
+func (s *PostgresCounterStore) StoreIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) (err error) {
+	defer observeDB("store_idempotency_key", time.Now(), &err)
+
+	query := `
+		INSERT INTO seq_idempotency (key, record, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at
+	`
+
+	if _, err = s.db.ExecContext(ctx, query, key, record, time.Now().Add(ttl)); err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a reserved idempotency key
+func (s *PostgresCounterStore) ReleaseIdempotencyKey(ctx context.Context, key string) (err error) {
+	defer observeDB("release_idempotency_key", time.Now(), &err)
+
+	if _, err = s.db.ExecContext(ctx, `DELETE FROM seq_idempotency WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Backend names the store in health checks
+func (s *PostgresCounterStore) Backend() string {
+	return metrics.BackendPostgres
+}
+
+// Ping checks database connectivity
+func (s *PostgresCounterStore) Ping(ctx context.Context) (err error) {
+	defer observeDB("ping", time.Now(), &err)
+
+	return s.db.PingContext(ctx)
+}
+
+// Close is a no-op; the connection pool is closed by PostgresRepository
+func (s *PostgresCounterStore) Close() error {
+	return nil
+}
@@ -7,14 +7,26 @@ import (
 	"time"
 
 	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
 	"github.com/streadway/amqp"
 )
 
+// amqpChannel is the part of *amqp.Channel used by the repository
+type amqpChannel interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueInspect(name string) (amqp.Queue, error)
+	QueueDelete(name string, ifUnused, ifEmpty, noWait bool) (int, error)
+	Close() error
+}
+
 // RabbitMQRepository handles RabbitMQ operations
 type RabbitMQRepository struct {
 	conn         *amqp.Connection
-	channel      *amqp.Channel
+	channel      amqpChannel
 	exchangeName string
 	queueName    string
 }
@@ -105,7 +117,9 @@ func NewRabbitMQRepository(cfg config.RabbitMQConfig) (*RabbitMQRepository, erro
 }
 
 // PublishEvent publishes an event to the queue
-func (r *RabbitMQRepository) PublishEvent(ctx context.Context, event *models.Event) error {
+func (r *RabbitMQRepository) PublishEvent(ctx context.Context, event *models.Event) (err error) {
+	defer observeRabbitMQ("publish", time.Now(), &err)
+
 	// Set published timestamp
 	event.PublishedAt = time.Now()
 
@@ -129,9 +143,9 @@ func (r *RabbitMQRepository) PublishEvent(ctx context.Context, event *models.Eve
 			Timestamp:     event.PublishedAt,
 			CorrelationId: event.CorrelationID,
 			Headers: amqp.Table{
-				"prefix":      event.Prefix,
-				"counter":     event.Counter,
-				"retry_count": event.RetryCount,
+				"prefix":         event.Prefix,
+				"counter":        event.Counter,
+				headerRetryCount: int32(event.RetryCount),
 			},
 		},
 	)
@@ -143,13 +157,23 @@ func (r *RabbitMQRepository) PublishEvent(ctx context.Context, event *models.Eve
 	return nil
 }
 
-// ConsumeEvents starts consuming events from the queue
-func (r *RabbitMQRepository) ConsumeEvents(ctx context.Context, handler func(*models.Event) error) error {
-	// Set QoS to limit unacknowledged messages
+// ConsumeEvents starts consuming events from the queue on
+// policy.Concurrency goroutines, each holding at most one unacknowledged
+// message. Events the handler fails on are retried with exponential backoff
+// through per-delay retry queues and dead-lettered to the DLQ after
+// policy.MaxRetries retries. The retry count travels in the retry_count
+// header, so it survives redelivery; the last error is recorded in the
+// last_error header. ConsumeEvents returns once every goroutine has stopped.
+func (r *RabbitMQRepository) ConsumeEvents(ctx context.Context, policy config.WorkerConfig, handler func(*models.Event) error) error {
+	if err := r.declareRetryQueues(policy); err != nil {
+		return err
+	}
+
+	// Set QoS to limit unacknowledged messages to one per goroutine
 	err := r.channel.Qos(
-		10,    // prefetch count
-		0,     // prefetch size
-		false, // global
+		policy.Concurrency, // prefetch count
+		0,                  // prefetch size
+		false,              // global
 	)
 	if err != nil {
 		return fmt.Errorf("failed to set QoS: %w", err)
@@ -169,7 +193,23 @@ func (r *RabbitMQRepository) ConsumeEvents(ctx context.Context, handler func(*mo
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
-	// Process messages
+	// Every goroutine stops on the same cancellation or closed channel
+	errs := make(chan error, policy.Concurrency)
+	for i := 0; i < policy.Concurrency; i++ {
+		go func() {
+			errs <- r.consume(ctx, msgs, policy, handler)
+		}()
+	}
+	err = <-errs
+	for i := 1; i < policy.Concurrency; i++ {
+		<-errs
+	}
+	return err
+}
+
+// consume processes deliveries until the context is cancelled or the
+// channel is closed
+func (r *RabbitMQRepository) consume(ctx context.Context, msgs <-chan amqp.Delivery, policy config.WorkerConfig, handler func(*models.Event) error) error {
 	for {
 		select {
 		case <-ctx.Done():
@@ -179,24 +219,22 @@ func (r *RabbitMQRepository) ConsumeEvents(ctx context.Context, handler func(*mo
 				return fmt.Errorf("channel closed")
 			}
 
-			// Parse event
+			retries := retryCount(msg.Headers)
+
+			// Parse event; malformed messages never succeed, so skip retries
 			var event models.Event
 			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				msg.Nack(false, false) // Send to DLQ
+				r.deadLetter(msg, retries, fmt.Errorf("failed to parse event: %w", err))
 				continue
 			}
+			event.RetryCount = retries
 
 			// Handle event
 			if err := handler(&event); err != nil {
-				// Increment retry count
-				event.RetryCount++
-
-				// If retry count exceeds limit, reject to DLQ
-				if event.RetryCount >= 3 {
-					msg.Nack(false, false)
+				if retries >= policy.MaxRetries {
+					r.deadLetter(msg, retries, err)
 				} else {
-					// Requeue with delay (simplified - in production use a delay exchange)
-					msg.Nack(false, true)
+					r.retry(msg, retries+1, retryDelay(policy, retries+1), err)
 				}
 				continue
 			}
@@ -207,8 +245,149 @@ func (r *RabbitMQRepository) ConsumeEvents(ctx context.Context, handler func(*mo
 	}
 }
 
+// Headers carrying the retry state of a message
+const (
+	headerRetryCount = "retry_count"
+	headerLastError  = "last_error"
+)
+
+// Outcomes of failed events
+const (
+	failureOutcomeRetried      = "retried"
+	failureOutcomeDeadLettered = "dead_lettered"
+)
+
+// declareRetryQueues declares one retry queue per distinct backoff delay.
+// Messages wait there until their TTL expires and are then dead-lettered
+// back onto the main queue. A queue per delay keeps every message in a
+// queue at the same TTL, so none waits behind a longer one.
+func (r *RabbitMQRepository) declareRetryQueues(policy config.WorkerConfig) error {
+	declared := make(map[string]bool)
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		delay := retryDelay(policy, attempt)
+		name := r.retryQueueName(delay)
+		if declared[name] {
+			continue
+		}
+
+		_, err := r.channel.QueueDeclare(
+			name,  // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": r.queueName,
+			}, // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue %s: %w", name, err)
+		}
+		declared[name] = true
+	}
+	return nil
+}
+
+// retry schedules a failed message for redelivery after delay. The copy is
+// published before the original is acknowledged, so a crash in between
+// leads to a duplicate, which the worker ignores, rather than a loss.
+// A message that cannot be scheduled is dead-lettered rather than requeued,
+// since an immediate redelivery would bypass the backoff and the retry
+// count; it can be replayed from the DLQ.
+func (r *RabbitMQRepository) retry(msg amqp.Delivery, attempt int, delay time.Duration, cause error) {
+	err := r.republish(msg, r.retryQueueName(delay), attempt, cause)
+	if err != nil {
+		r.deadLetter(msg, attempt-1, fmt.Errorf("failed to schedule retry: %v; %w", err, cause))
+		return
+	}
+
+	metrics.WorkerEventFailures.WithLabelValues(failureOutcomeRetried).Inc()
+	msg.Ack(false)
+}
+
+// deadLetter moves a message that exhausted its retries to the DLQ with the
+// error that stopped it
+func (r *RabbitMQRepository) deadLetter(msg amqp.Delivery, retries int, cause error) {
+	metrics.WorkerEventFailures.WithLabelValues(failureOutcomeDeadLettered).Inc()
+
+	if err := r.republish(msg, r.deadLetterQueueName(), retries, cause); err != nil {
+		// Fall back to the queue's dead letter routing, losing the error
+		msg.Nack(false, false)
+		return
+	}
+	msg.Ack(false)
+}
+
+// republish publishes a copy of msg to a queue through the default exchange
+// with updated retry headers
+func (r *RabbitMQRepository) republish(msg amqp.Delivery, queue string, retries int, cause error) (err error) {
+	defer observeRabbitMQ("republish", time.Now(), &err)
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerRetryCount] = int32(retries)
+	headers[headerLastError] = cause.Error()
+
+	return r.channel.Publish(
+		"",    // default exchange routes by queue name
+		queue, // routing key
+		false, // mandatory
+		false, // immediate
+		amqp.Publishing{
+			DeliveryMode:  amqp.Persistent,
+			ContentType:   msg.ContentType,
+			Body:          msg.Body,
+			MessageId:     msg.MessageId,
+			Timestamp:     msg.Timestamp,
+			CorrelationId: msg.CorrelationId,
+			Headers:       headers,
+		},
+	)
+}
+
+// retryQueueName returns the name of the retry queue for a delay
+func (r *RabbitMQRepository) retryQueueName(delay time.Duration) string {
+	return fmt.Sprintf("%s_retry_%dms", r.queueName, delay.Milliseconds())
+}
+
+// retryDelay returns the backoff before the given retry attempt (1-based):
+// the base delay doubled per earlier attempt, capped at the maximum
+func retryDelay(policy config.WorkerConfig, attempt int) time.Duration {
+	delay := policy.RetryBaseDelay
+	for i := 1; i < attempt && delay < policy.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.RetryMaxDelay {
+		delay = policy.RetryMaxDelay
+	}
+	return delay
+}
+
+// retryCount reads the retry count header of a message; messages without
+// one have not been retried
+func retryCount(headers amqp.Table) int {
+	switch v := headers[headerRetryCount].(type) {
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
 // GetQueueInfo returns information about the queue
-func (r *RabbitMQRepository) GetQueueInfo(ctx context.Context) (map[string]interface{}, error) {
+func (r *RabbitMQRepository) GetQueueInfo(ctx context.Context) (_ map[string]interface{}, err error) {
+	defer observeRabbitMQ("queue_inspect", time.Now(), &err)
+
 	queue, err := r.channel.QueueInspect(r.queueName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inspect queue: %w", err)
@@ -278,3 +457,8 @@ func (r *RabbitMQRepository) GetStats() map[string]interface{} {
 
 	return stats
 }
+
+// observeRabbitMQ records the latency and outcome of a broker operation
+func observeRabbitMQ(operation string, start time.Time, err *error) {
+	metrics.ObserveOperation(metrics.BackendRabbitMQ, operation, start, *err)
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/streadway/amqp"
+)
+
+// fakeChannel records what the repository publishes and declares. Publishes
+// to the queues in failing return an error.
+type fakeChannel struct {
+	amqpChannel
+	mu        sync.Mutex
+	published map[string][]amqp.Publishing
+	declared  map[string]amqp.Table
+	failing   map[string]bool
+	prefetch  int
+	deliver   chan amqp.Delivery
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{
+		published: make(map[string][]amqp.Publishing),
+		declared:  make(map[string]amqp.Table),
+		failing:   make(map[string]bool),
+	}
+}
+
+func (c *fakeChannel) Publish(_, key string, _, _ bool, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failing[key] {
+		return errors.New("channel closed")
+	}
+	c.published[key] = append(c.published[key], msg)
+	return nil
+}
+
+func (c *fakeChannel) Qos(prefetchCount, _ int, _ bool) error {
+	c.prefetch = prefetchCount
+	return nil
+}
+
+func (c *fakeChannel) Consume(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+	return c.deliver, nil
+}
+
+func (c *fakeChannel) QueueDeclare(name string, _, _, _, _ bool, args amqp.Table) (amqp.Queue, error) {
+	c.declared[name] = args
+	return amqp.Queue{Name: name}, nil
+}
+
+// fakeAcknowledger records how deliveries were settled
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	settled map[uint64]string
+}
+
+func (a *fakeAcknowledger) settle(tag uint64, outcome string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.settled[tag] = outcome
+	return nil
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, _ bool) error { return a.settle(tag, "ack") }
+
+func (a *fakeAcknowledger) Nack(tag uint64, _, requeue bool) error {
+	if requeue {
+		return a.settle(tag, "requeue")
+	}
+	return a.settle(tag, "nack")
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error { return a.Nack(tag, false, requeue) }
+
+var testPolicy = config.WorkerConfig{
+	Concurrency:    1,
+	MaxRetries:     3,
+	RetryBaseDelay: time.Second,
+	RetryMaxDelay:  4 * time.Second,
+}
+
+func newTestRabbitMQRepository() (*RabbitMQRepository, *fakeChannel) {
+	ch := newFakeChannel()
+	return &RabbitMQRepository{channel: ch, exchangeName: "seq_exchange", queueName: "seq_log_queue"}, ch
+}
+
+func TestRetryAndDeadLetterRouting(t *testing.T) {
+	event := []byte(`{"message_id":"m1","prefix":"SG","counter":1}`)
+	tests := []struct {
+		name    string
+		body    []byte
+		retries interface{} // retry_count header; nil when absent
+		fails   bool        // handler result
+		failing string      // queue whose publishes fail
+		queue   string      // where the message is republished; "" for none
+		count   int32       // retry_count of the republished message
+		lastErr string      // substring of its last_error
+		settled string
+	}{
+		{name: "processed", body: event, settled: "ack"},
+		{name: "first failure", body: event, fails: true, queue: "seq_log_queue_retry_1000ms", count: 1, lastErr: "db down", settled: "ack"},
+		{name: "third failure", body: event, retries: int32(2), fails: true, queue: "seq_log_queue_retry_4000ms", count: 3, lastErr: "db down", settled: "ack"},
+		{name: "retries exhausted", body: event, retries: int64(3), fails: true, queue: "seq_log_queue_dlq", count: 3, lastErr: "db down", settled: "ack"},
+		{name: "malformed event", body: []byte("{"), retries: int32(1), queue: "seq_log_queue_dlq", count: 1, lastErr: "failed to parse event", settled: "ack"},
+		{name: "retry queue unavailable", body: event, fails: true, failing: "seq_log_queue_retry_1000ms", queue: "seq_log_queue_dlq", count: 0, lastErr: "failed to schedule retry", settled: "ack"},
+		{name: "dead letter queue unavailable", body: event, retries: int32(3), fails: true, failing: "seq_log_queue_dlq", settled: "nack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ch := newTestRabbitMQRepository()
+			if tt.failing != "" {
+				ch.failing[tt.failing] = true
+			}
+			ack := &fakeAcknowledger{settled: make(map[uint64]string)}
+			headers := amqp.Table{}
+			if tt.retries != nil {
+				headers[headerRetryCount] = tt.retries
+			}
+			msgs := make(chan amqp.Delivery, 1)
+			msgs <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1, Headers: headers, Body: tt.body, MessageId: "m1"}
+			close(msgs)
+
+			var handled *models.Event
+			err := r.consume(context.Background(), msgs, testPolicy, func(event *models.Event) error {
+				handled = event
+				if tt.fails {
+					return errors.New("db down")
+				}
+				return nil
+			})
+			if err == nil || !strings.Contains(err.Error(), "channel closed") {
+				t.Errorf("consume = %v, want channel closed", err)
+			}
+			if handled != nil && handled.RetryCount != retryCount(headers) {
+				t.Errorf("handler saw retry count %d, want %d", handled.RetryCount, retryCount(headers))
+			}
+			if got := ack.settled[1]; got != tt.settled {
+				t.Errorf("delivery settled with %q, want %q", got, tt.settled)
+			}
+
+			var republished []amqp.Publishing
+			for queue, msgs := range ch.published {
+				if queue != tt.queue {
+					t.Errorf("published %d messages to %s", len(msgs), queue)
+				}
+				republished = append(republished, msgs...)
+			}
+			if tt.queue == "" {
+				return
+			}
+			if len(republished) != 1 {
+				t.Fatalf("published %d messages to %s, want 1", len(republished), tt.queue)
+			}
+			msg := republished[0]
+			if got := msg.Headers[headerRetryCount]; got != tt.count {
+				t.Errorf("retry_count = %v, want %d", got, tt.count)
+			}
+			if got, _ := msg.Headers[headerLastError].(string); !strings.Contains(got, tt.lastErr) {
+				t.Errorf("last_error = %q, want it to mention %q", got, tt.lastErr)
+			}
+			if string(msg.Body) != string(tt.body) || msg.MessageId != "m1" || msg.DeliveryMode != amqp.Persistent {
+				t.Errorf("republished %+v, want a persistent copy of the message", msg)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  4 * time.Second,
+		50: 4 * time.Second,
+	} {
+		if got := retryDelay(testPolicy, attempt); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		header interface{}
+		want   int
+	}{
+		{nil, 0},
+		{int16(2), 2},
+		{int32(3), 3},
+		{int64(4), 4},
+		{"5", 0},
+	}
+	for _, tt := range tests {
+		headers := amqp.Table{}
+		if tt.header != nil {
+			headers[headerRetryCount] = tt.header
+		}
+		if got := retryCount(headers); got != tt.want {
+			t.Errorf("retryCount(%#v) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDeclareRetryQueues(t *testing.T) {
+	r, ch := newTestRabbitMQRepository()
+	policy := testPolicy
+	policy.MaxRetries = 5
+
+	if err := r.declareRetryQueues(policy); err != nil {
+		t.Fatalf("declareRetryQueues: %v", err)
+	}
+	// One queue per distinct delay; later retries share the capped one
+	want := map[string]int64{
+		"seq_log_queue_retry_1000ms": 1000,
+		"seq_log_queue_retry_2000ms": 2000,
+		"seq_log_queue_retry_4000ms": 4000,
+	}
+	if len(ch.declared) != len(want) {
+		t.Errorf("declared %v, want %d retry queues", ch.declared, len(want))
+	}
+	for name, ttl := range want {
+		args, ok := ch.declared[name]
+		if !ok {
+			t.Errorf("retry queue %s not declared", name)
+			continue
+		}
+		if args["x-message-ttl"] != ttl || args["x-dead-letter-routing-key"] != "seq_log_queue" {
+			t.Errorf("retry queue %s has arguments %v, want TTL %d back to seq_log_queue", name, args, ttl)
+		}
+	}
+}
+
+func TestConsumeEventsConcurrency(t *testing.T) {
+	r, ch := newTestRabbitMQRepository()
+	policy := testPolicy
+	policy.Concurrency = 4
+
+	ack := &fakeAcknowledger{settled: make(map[uint64]string)}
+	ch.deliver = make(chan amqp.Delivery, 20)
+	for tag := uint64(1); tag <= 20; tag++ {
+		ch.deliver <- amqp.Delivery{Acknowledger: ack, DeliveryTag: tag, Body: []byte(`{"prefix":"SG"}`)}
+	}
+	close(ch.deliver)
+
+	var mu sync.Mutex
+	handled := 0
+	err := r.ConsumeEvents(context.Background(), policy, func(*models.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	})
+	if err == nil {
+		t.Error("ConsumeEvents = nil after the channel closed, want an error")
+	}
+	if ch.prefetch != policy.Concurrency {
+		t.Errorf("prefetch = %d, want %d", ch.prefetch, policy.Concurrency)
+	}
+	if handled != 20 || len(ack.settled) != 20 {
+		t.Errorf("handled %d and settled %d deliveries, want 20", handled, len(ack.settled))
+	}
+}
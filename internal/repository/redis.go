@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 )
 
 // RedisRepository handles Redis operations for counters
@@ -39,6 +40,8 @@ func NewRedisRepository(cfg config.RedisConfig) (*RedisRepository, error) {
 		client = redis.NewClient(opt)
 	}
 
+	client.AddHook(redisMetricsHook{})
+
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -145,6 +148,11 @@ func (r *RedisRepository) GetMultipleCounters(ctx context.Context, prefixes []st
 	return result, nil
 }
 
+// Backend names the store in health checks
+func (r *RedisRepository) Backend() string {
+	return metrics.BackendRedis
+}
+
 // Ping checks if Redis is accessible
 func (r *RedisRepository) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
@@ -160,6 +168,51 @@ func (r *RedisRepository) counterKey(prefix string) string {
 	return fmt.Sprintf("seq:%s", prefix)
 }
 
+// ReserveIdempotencyKey stores record under key unless the key exists. It
+// reports whether the key was reserved and otherwise returns the existing
+// record.
+func (r *RedisRepository) ReserveIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) (bool, []byte, error) {
+	reserved, err := r.client.SetNX(ctx, r.idempotencyKey(key), record, ttl).Result()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return true, nil, nil
+	}
+
+	existing, err := r.client.Get(ctx, r.idempotencyKey(key)).Bytes()
+	if err == redis.Nil {
+		// Expired between SETNX and GET; the caller may retry
+		return false, nil, fmt.Errorf("idempotency key expired while being read")
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+
+	return false, existing, nil
+}
+
+// StoreIdempotencyKey overwrites the record of a reserved idempotency key
+func (r *RedisRepository) StoreIdempotencyKey(ctx context.Context, key string, record []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.idempotencyKey(key), record, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a reserved idempotency key
+func (r *RedisRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.idempotencyKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// idempotencyKey generates the Redis key for an idempotency record
+func (r *RedisRepository) idempotencyKey(key string) string {
+	return fmt.Sprintf("idem:%s", key)
+}
+
 // ResetCounter resets a counter to a specific value (used for admin operations)
 func (r *RedisRepository) ResetCounter(ctx context.Context, prefix string, newValue int64) (int64, error) {
 	key := r.counterKey(prefix)
@@ -195,6 +248,26 @@ func (r *RedisRepository) ResetCounter(ctx context.Context, prefix string, newVa
 	return oldValue, nil
 }
 
+// compareAndSetScript sets KEYS[1] to ARGV[2] while it holds ARGV[1]
+var compareAndSetScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// CompareAndSetCounter sets the counter to newValue only while it still
+// holds oldValue
+func (r *RedisRepository) CompareAndSetCounter(ctx context.Context, prefix string, oldValue, newValue int64) (bool, error) {
+	key := r.counterKey(prefix)
+	swapped, err := compareAndSetScript.Run(ctx, r.client, []string{key}, strconv.FormatInt(oldValue, 10), newValue).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to compare and set counter for prefix %s: %w", prefix, err)
+	}
+	return swapped == 1, nil
+}
+
 // GetInfo returns Redis information for monitoring
 func (r *RedisRepository) GetInfo(ctx context.Context) (map[string]string, error) {
 	info, err := r.client.Info(ctx).Result()
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// reservationColumns lists the columns scanned into models.Reservation
+const reservationColumns = `id, reservation_id, tenant, prefix, period_key, counter_value, full_number, status,
+	client_id, generated_by, reserved_at, expires_at`
+
+// ReuseReservation leases the lowest released or expired number of a prefix
+// period to res. It reports false when the pool is empty.
+func (r *PostgresRepository) ReuseReservation(ctx context.Context, res *models.Reservation) (_ bool, err error) {
+	defer observeDB("reuse_reservation", time.Now(), &err)
+
+	query := `
+		UPDATE seq_reservation
+		SET reservation_id = $4, status = 'reserved', client_id = $5, generated_by = $6,
+		    reserved_at = NOW(), expires_at = $7
+		WHERE id = (
+			SELECT id
+			FROM seq_reservation
+			WHERE tenant = $1 AND prefix = $2 AND period_key = $3 AND (status = 'released' OR expires_at <= NOW())
+			ORDER BY counter_value
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, counter_value, full_number, reserved_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		res.Tenant,
+		res.Prefix,
+		res.PeriodKey,
+		res.ReservationID,
+		res.ClientID,
+		res.GeneratedBy,
+		res.ExpiresAt,
+	).Scan(&res.ID, &res.CounterValue, &res.FullNumber, &res.ReservedAt)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to reuse reservation for prefix %s: %w", res.Prefix, err)
+	}
+
+	return true, nil
+}
+
+// InsertReservation records a reservation of a freshly issued number
+func (r *PostgresRepository) InsertReservation(ctx context.Context, res *models.Reservation) (err error) {
+	defer observeDB("insert_reservation", time.Now(), &err)
+
+	query := `
+		INSERT INTO seq_reservation (reservation_id, tenant, prefix, period_key, counter_value, full_number,
+		                            status, client_id, generated_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, reserved_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		res.ReservationID,
+		res.Tenant,
+		res.Prefix,
+		res.PeriodKey,
+		res.CounterValue,
+		res.FullNumber,
+		res.Status,
+		res.ClientID,
+		res.GeneratedBy,
+		res.ExpiresAt,
+	).Scan(&res.ID, &res.ReservedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert reservation: %w", err)
+	}
+
+	return nil
+}
+
+// GetReservation retrieves a reservation by its ID
+func (r *PostgresRepository) GetReservation(ctx context.Context, reservationID string) (_ *models.Reservation, err error) {
+	defer observeDB("get_reservation", time.Now(), &err)
+
+	var res models.Reservation
+	query := `SELECT ` + reservationColumns + ` FROM seq_reservation WHERE reservation_id = $1`
+
+	err = r.db.GetContext(ctx, &res, query, reservationID)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation %s: %w", reservationID, err)
+	}
+
+	return &res, nil
+}
+
+// CommitReservation records a reserved number in seq_log and removes the
+// reservation in one transaction. It reports false when the lease is no
+// longer held.
+func (r *PostgresRepository) CommitReservation(ctx context.Context, reservationID string, log *models.AuditLog) (_ bool, err error) {
+	defer observeDB("commit_reservation", time.Now(), &err)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM seq_reservation
+		WHERE reservation_id = $1 AND status = 'reserved' AND expires_at > NOW()
+	`, reservationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete reservation %s: %w", reservationID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	err = tx.QueryRowContext(ctx, insertAuditLogQuery,
+		log.Tenant,
+		log.Prefix,
+		log.CounterValue,
+		log.PeriodKey,
+		log.FullNumber,
+		log.GeneratedBy,
+		log.ClientID,
+		log.CorrelationID,
+		log.MessageID,
+		log.GeneratedAt,
+		log.PublishedAt,
+		log.BatchID,
+	).Scan(&log.ID, &log.InsertedAt)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("counter %d of prefix %s is already audited", log.CounterValue, log.Prefix)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit reservation %s: %w", reservationID, err)
+	}
+
+	return true, nil
+}
+
+// ReleaseReservation returns a reserved number to the pool. It reports false
+// when the reservation is not held.
+func (r *PostgresRepository) ReleaseReservation(ctx context.Context, reservationID string) (_ bool, err error) {
+	defer observeDB("release_reservation", time.Now(), &err)
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE seq_reservation
+		SET status = 'released'
+		WHERE reservation_id = $1 AND status = 'reserved'
+	`, reservationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to release reservation %s: %w", reservationID, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// GetReservedCounters lists the numbers of a prefix period held by
+// reservations or waiting in the pool, in counter order
+func (r *PostgresRepository) GetReservedCounters(ctx context.Context, tenant, prefix, periodKey string) (_ []int64, err error) {
+	defer observeDB("get_reserved_counters", time.Now(), &err)
+
+	var counters []int64
+	query := `
+		SELECT counter_value
+		FROM seq_reservation
+		WHERE tenant = $1 AND prefix = $2 AND period_key = $3
+		ORDER BY counter_value
+	`
+
+	err = r.db.SelectContext(ctx, &counters, query, tenant, prefix, periodKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved counters for prefix %s: %w", prefix, err)
+	}
+
+	return counters, nil
+}
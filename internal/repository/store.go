@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// Errors returned by ConfigStore writes
+var (
+	// ErrPrefixExists is returned when creating a prefix that is already configured
+	ErrPrefixExists = errors.New("prefix already exists")
+	// ErrPrefixNotFound is returned when updating or deleting an unknown prefix
+	ErrPrefixNotFound = errors.New("prefix not found")
+)
+
+// ConfigStore keeps prefix configurations and their change history
+type ConfigStore interface {
+	GetPrefixConfig(ctx context.Context, tenant, prefix string) (*models.PrefixConfig, error)
+	GetAllPrefixConfigs(ctx context.Context) ([]models.PrefixConfig, error)
+	GetTenantPrefixConfigs(ctx context.Context, tenant string) ([]models.PrefixConfig, error)
+	// CreatePrefixConfig returns ErrPrefixExists when the prefix is already configured
+	CreatePrefixConfig(ctx context.Context, config *models.PrefixConfig) error
+	// UpdatePrefixConfig returns ErrPrefixNotFound for unknown prefixes
+	UpdatePrefixConfig(ctx context.Context, tenant, prefix string, updates map[string]interface{}, changeType, adminUser string) error
+	// DeletePrefixConfig returns ErrPrefixNotFound for unknown prefixes
+	DeletePrefixConfig(ctx context.Context, tenant, prefix string, force bool, adminUser, reason string) (bool, error)
+	MarkPeriodReset(ctx context.Context, tenant, prefix string, periodStart time.Time) error
+	GetConfigHistory(ctx context.Context, tenant, prefix string, limit int) ([]models.ConfigChange, error)
+	GetConfigChange(ctx context.Context, version int64) (*models.ConfigChange, error)
+	NotifyCounterReset(ctx context.Context, tenant, prefix string) error
+}
+
+// AuditStore keeps seq_log together with the checkpoints, reset logs and
+// dead letter records kept alongside it
+type AuditStore interface {
+	InsertAuditLog(ctx context.Context, log *models.AuditLog) error
+	InsertLostAuditLogs(ctx context.Context, logs []models.AuditLog) (int64, error)
+	QueryAuditLogs(ctx context.Context, q *models.AuditQuery, after *models.AuditCursor, limit int) ([]models.AuditLog, error)
+	GetAuditLogsByFullNumber(ctx context.Context, tenant, fullNumber string) ([]models.AuditLog, error)
+	GetBatchSummary(ctx context.Context, batchID string) (*models.BatchSummary, error)
+	GetLastIssuedTimes(ctx context.Context, tenant string) (map[string]time.Time, error)
+	GetMaxCounter(ctx context.Context, tenant, prefix, periodKey string) (int64, error)
+	GetAuditPeriods(ctx context.Context, tenant, prefix string) ([]string, error)
+	GetAuditStats(ctx context.Context, tenant, prefix, periodKey string) (*models.AuditStats, error)
+	FindCounterGaps(ctx context.Context, tenant, prefix, periodKey string) ([]models.GapBounds, error)
+	UpdateCheckpoint(ctx context.Context, checkpoint *models.Checkpoint) error
+	GetCheckpoint(ctx context.Context, tenant, prefix string) (*models.Checkpoint, error)
+	InsertResetLog(ctx context.Context, resetLog *models.ResetLog) error
+	GetResetLogs(ctx context.Context, tenant, prefix, periodKey string) ([]models.ResetLog, error)
+	GetResetHistory(ctx context.Context, tenant, prefix string, periodKey *string, limit int) ([]models.ResetLog, error)
+	InsertDeadLetterAudit(ctx context.Context, audit *models.DeadLetterAudit) error
+}
+
+// ReservationStore keeps the reserved numbers of gapless prefixes
+type ReservationStore interface {
+	ReuseReservation(ctx context.Context, res *models.Reservation) (bool, error)
+	InsertReservation(ctx context.Context, res *models.Reservation) error
+	GetReservation(ctx context.Context, reservationID string) (*models.Reservation, error)
+	CommitReservation(ctx context.Context, reservationID string, log *models.AuditLog) (bool, error)
+	ReleaseReservation(ctx context.Context, reservationID string) (bool, error)
+	GetReservedCounters(ctx context.Context, tenant, prefix, periodKey string) ([]int64, error)
+}
+
+// OutboxStore keeps audit events until the relay has published them
+type OutboxStore interface {
+	InsertOutboxEvents(ctx context.Context, events []*models.Event) error
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEntry, error)
+	DeleteOutboxEvents(ctx context.Context, ids []int64) error
+	RescheduleOutboxEvent(ctx context.Context, id int64, nextAttempt time.Time, lastError string) error
+}
+
+// Database is the relational store behind the service
+type Database interface {
+	ConfigStore
+	AuditStore
+	ReservationStore
+	OutboxStore
+	Ping(ctx context.Context) error
+}
+
+// EventBroker carries audit events to the worker and holds the events the
+// worker gave up on
+type EventBroker interface {
+	PublishEvent(ctx context.Context, event *models.Event) error
+	PeekDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error)
+	ReplayDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error)
+	DropDeadLetters(ctx context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error)
+	DeadLetterDepth(ctx context.Context) (int, error)
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Database    = (*PostgresRepository)(nil)
+	_ EventBroker = (*RabbitMQRepository)(nil)
+)
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// Audit query page sizes
+const (
+	DefaultAuditPageSize = 100
+	MaxAuditPageSize     = 1000
+)
+
+// ErrInvalidAuditQuery is returned for malformed audit queries and cursors
+var ErrInvalidAuditQuery = newError(KindInvalidArgument, "INVALID_AUDIT_QUERY", "invalid audit query")
+
+// QueryAuditLogs returns one page of audit log entries matching the query
+func (s *SequentialIDService) QueryAuditLogs(ctx context.Context, q *models.AuditQuery) (*models.AuditPage, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxAuditPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidAuditQuery, MaxAuditPageSize)
+	}
+	if q.CounterFrom != nil && q.CounterTo != nil && *q.CounterFrom > *q.CounterTo {
+		return nil, fmt.Errorf("%w: counter_from must not be greater than counter_to", ErrInvalidAuditQuery)
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditQuery)
+	}
+
+	// Callers only see entries of their own tenant
+	q.Tenant = tenantOf(ctx)
+
+	var after *models.AuditCursor
+	if q.Cursor != "" {
+		cursor, err := decodeAuditCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = cursor
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	logs, err := s.dbRepo.QueryAuditLogs(ctx, q, after, q.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to query audit logs: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	page := &models.AuditPage{Logs: logs}
+	if len(logs) > q.Limit {
+		page.Logs = logs[:q.Limit]
+		last := page.Logs[q.Limit-1]
+		page.NextCursor = encodeAuditCursor(&models.AuditCursor{GeneratedAt: last.GeneratedAt, ID: last.ID})
+	}
+	if page.Logs == nil {
+		page.Logs = []models.AuditLog{}
+	}
+	page.Count = len(page.Logs)
+
+	return page, nil
+}
+
+// encodeAuditCursor renders an opaque pagination cursor
+func encodeAuditCursor(c *models.AuditCursor) string {
+	raw := strconv.FormatInt(c.GeneratedAt.UnixNano(), 10) + "." + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeAuditCursor parses a cursor produced by encodeAuditCursor
+func decodeAuditCursor(cursor string) (*models.AuditCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidAuditQuery)
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidAuditQuery)
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidAuditQuery)
+	}
+	i, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidAuditQuery)
+	}
+
+	return &models.AuditCursor{GeneratedAt: time.Unix(0, n), ID: i}, nil
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxBlockSize bounds the number of counter values leased at once
+const MaxBlockSize = 10000
+
+// blockReleaseActor is recorded as generated_by on placeholders for leased
+// values that were never handed out
+const blockReleaseActor = "block-release"
+
+// errGaplessBlocks rejects configurations combining both allocation modes
+var errGaplessBlocks = fmt.Errorf("%w: gapless prefixes cannot allocate counters in blocks", ErrInvalidConfig)
+
+// Outcomes of unused leased values
+const (
+	blockOutcomeReturned = "returned"
+	blockOutcomeLost     = "lost"
+)
+
+// counterBlock is a range of counter values leased from the counter store.
+// Values next..end have not been handed out yet. A retired block was
+// removed from the allocator and must not be leased into again.
+type counterBlock struct {
+	mu        sync.Mutex
+	tenant    string
+	prefix    string
+	periodKey string
+	size      int
+	next      int64
+	end       int64
+	retired   bool
+}
+
+// blockAllocator serves counter values from blocks leased per counter name
+// (tenant, prefix and period). Each instance holds its own blocks, so values are
+// unique across instances but only increase within one instance. Batches do
+// not use blocks: each batch leases its own consecutive range from the
+// counter store, past the end of the current block, so single IDs handed
+// out after a batch may be lower than the batch's values.
+type blockAllocator struct {
+	mu     sync.Mutex
+	blocks map[string]*counterBlock
+}
+
+// newBlockAllocator creates an empty block allocator
+func newBlockAllocator() *blockAllocator {
+	return &blockAllocator{
+		blocks: make(map[string]*counterBlock),
+	}
+}
+
+// block returns the block of a counter, creating an empty one. Creating the
+// block of a new period removes and returns the blocks the prefix holds for
+// other periods, which are no longer allocated from.
+func (a *blockAllocator) block(name string, config *models.PrefixConfig, period counterPeriod) (*counterBlock, map[string]*counterBlock) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if b, ok := a.blocks[name]; ok {
+		return b, nil
+	}
+
+	var stale map[string]*counterBlock
+	for other, b := range a.blocks {
+		if b.tenant == config.Tenant && b.prefix == config.Prefix && b.periodKey != period.Key {
+			if stale == nil {
+				stale = make(map[string]*counterBlock)
+			}
+			stale[other] = b
+			delete(a.blocks, other)
+		}
+	}
+
+	b := &counterBlock{tenant: config.Tenant, prefix: config.Prefix, periodKey: period.Key, size: config.BlockSize, next: 1}
+	a.blocks[name] = b
+	return b, stale
+}
+
+// lookup returns the block of a counter, or nil
+func (a *blockAllocator) lookup(name string) *counterBlock {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.blocks[name]
+}
+
+// remove removes the block of a counter unless it was already replaced
+func (a *blockAllocator) remove(name string, b *counterBlock) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.blocks[name] != b {
+		return false
+	}
+	delete(a.blocks, name)
+	return true
+}
+
+// removeWhere removes and returns the blocks matching match
+func (a *blockAllocator) removeWhere(match func(*counterBlock) bool) map[string]*counterBlock {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	removed := make(map[string]*counterBlock)
+	for name, b := range a.blocks {
+		if match(b) {
+			removed[name] = b
+			delete(a.blocks, name)
+		}
+	}
+	return removed
+}
+
+// nextCounter returns the next counter value for a prefix, from a leased
+// block when the prefix allocates in blocks. A block leased with another
+// block size than the prefix now has is released first.
+func (s *SequentialIDService) nextCounter(ctx context.Context, config *models.PrefixConfig, period counterPeriod) (int64, error) {
+	name := counterName(config.Tenant, config.Prefix, period)
+	for {
+		if b := s.blocks.lookup(name); b != nil && b.size != config.BlockSize {
+			s.retireBlock(ctx, name, b)
+		}
+		if config.BlockSize <= 1 {
+			return s.counters.IncrementCounter(ctx, name)
+		}
+
+		b, stale := s.blocks.block(name, config, period)
+		s.releaseBlocks(ctx, stale)
+
+		counter, ok, err := s.nextFromBlock(ctx, name, b, config, period)
+		if err != nil || ok {
+			return counter, err
+		}
+		// The block was retired while waiting for it
+	}
+}
+
+// nextFromBlock hands out the next value of a block, leasing a new range
+// when it is used up. ok is false when the block was retired.
+func (s *SequentialIDService) nextFromBlock(ctx context.Context, name string, b *counterBlock, config *models.PrefixConfig, period counterPeriod) (counter int64, ok bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.retired {
+		return 0, false, nil
+	}
+	if b.next > b.end {
+		end, err := s.counters.IncrementCounterBy(ctx, name, int64(b.size))
+		if err != nil {
+			return 0, false, err
+		}
+		b.next = end - int64(b.size) + 1
+		b.end = end
+		metrics.BlocksLeased.WithLabelValues(config.Prefix).Inc()
+
+		s.logger.WithFields(logrus.Fields{
+			"tenant": config.Tenant,
+			"prefix": config.Prefix,
+			"period": period.Key,
+			"from":   b.next,
+			"to":     b.end,
+		}).Debug("Leased counter block")
+	}
+
+	counter = b.next
+	b.next++
+	return counter, true, nil
+}
+
+// retireBlock removes a block and releases its unused values; failures are
+// logged since the caller carries on with a new block
+func (s *SequentialIDService) retireBlock(ctx context.Context, name string, b *counterBlock) {
+	if !s.blocks.remove(name, b) {
+		return
+	}
+	if err := s.releaseBlock(ctx, name, b); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"tenant": b.tenant,
+			"prefix": b.prefix,
+			"period": b.periodKey,
+		}).Error("Failed to release counter block of a previous block size")
+	}
+}
+
+// releasePrefixBlocks releases this instance's blocks of a prefix, e.g.
+// after its block size changed
+func (s *SequentialIDService) releasePrefixBlocks(ctx context.Context, tenant, prefix string) {
+	s.releaseBlocks(ctx, s.blocks.removeWhere(func(b *counterBlock) bool {
+		return b.tenant == tenant && b.prefix == prefix
+	}))
+}
+
+// releaseBlocks releases removed blocks, e.g. those of a period that ended;
+// failures are logged since the caller carries on without them
+func (s *SequentialIDService) releaseBlocks(ctx context.Context, removed map[string]*counterBlock) {
+	for name, b := range removed {
+		if err := s.releaseBlock(ctx, name, b); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"tenant": b.tenant,
+				"prefix": b.prefix,
+				"period": b.periodKey,
+			}).Error("Failed to release counter block")
+		}
+	}
+}
+
+// DiscardBlocks drops this instance's blocks of a prefix of a tenant after
+// one of its counters was reset, so no value leased before the reset is
+// handed out after it. The unused values are not returned or recorded: the
+// counter no longer continues from the block, and after a backward reset
+// they will be issued again.
+func (s *SequentialIDService) DiscardBlocks(tenant, prefix string) {
+	s.discardBlocks(func(b *counterBlock) bool {
+		return b.tenant == tenant && b.prefix == prefix
+	})
+}
+
+// DiscardAllBlocks drops every leased block, e.g. after reset
+// notifications may have been missed
+func (s *SequentialIDService) DiscardAllBlocks() {
+	s.discardBlocks(func(*counterBlock) bool { return true })
+}
+
+func (s *SequentialIDService) discardBlocks(match func(*counterBlock) bool) {
+	for _, b := range s.blocks.removeWhere(match) {
+		b.mu.Lock()
+		b.retired = true
+		if b.next <= b.end {
+			s.logger.WithFields(logrus.Fields{
+				"tenant": b.tenant,
+				"prefix": b.prefix,
+				"period": b.periodKey,
+				"from":   b.next,
+				"to":     b.end,
+			}).Warn("Discarded counter block")
+		}
+		b.mu.Unlock()
+	}
+}
+
+// ReleaseBlocks gives back the counter values leased by this instance that
+// were never handed out. A block is returned to the counter when no other
+// instance has allocated after it; otherwise its values are recorded as
+// "lost" placeholders in seq_log so the audit trail accounts for them.
+func (s *SequentialIDService) ReleaseBlocks(ctx context.Context) error {
+	var failed int
+	for name, b := range s.blocks.removeWhere(func(*counterBlock) bool { return true }) {
+		if err := s.releaseBlock(ctx, name, b); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"tenant": b.tenant,
+				"prefix": b.prefix,
+				"period": b.periodKey,
+			}).Error("Failed to release unused counter block")
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to release %d counter blocks", failed)
+	}
+	return nil
+}
+
+// releaseBlock returns or accounts for the unused values of one block
+func (s *SequentialIDService) releaseBlock(ctx context.Context, name string, b *counterBlock) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.retired = true
+	if b.next > b.end {
+		return nil
+	}
+
+	unused := b.end - b.next + 1
+	fields := logrus.Fields{
+		"tenant": b.tenant,
+		"prefix": b.prefix,
+		"period": b.periodKey,
+		"from":   b.next,
+		"to":     b.end,
+	}
+
+	returned, err := s.counters.CompareAndSetCounter(ctx, name, b.end, b.next-1)
+	if err != nil {
+		return fmt.Errorf("%w: failed to return counter block: %w", ErrCounterUnavailable, err)
+	}
+	if returned {
+		metrics.BlockValuesUnused.WithLabelValues(b.prefix, blockOutcomeReturned).Add(float64(unused))
+		s.logger.WithFields(fields).Info("Returned unused counter block")
+		return nil
+	}
+
+	config, err := s.dbRepo.GetPrefixConfig(ctx, b.tenant, b.prefix)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+	if config == nil {
+		return fmt.Errorf("%w: %s", ErrPrefixNotFound, b.prefix)
+	}
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid configuration for prefix %s: %w", b.prefix, err)
+	}
+
+	now := time.Now()
+	generatedBy := blockReleaseActor
+	logs := make([]models.AuditLog, 0, unused)
+	for counter := b.next; counter <= b.end; counter++ {
+		logs = append(logs, models.AuditLog{
+			Tenant:       b.tenant,
+			Prefix:       b.prefix,
+			CounterValue: counter,
+			PeriodKey:    b.periodKey,
+			FullNumber:   formatID(tmpl, config, counter, now),
+			GeneratedBy:  &generatedBy,
+			MessageID:    uuid.New().String(),
+			GeneratedAt:  now,
+			Status:       models.AuditStatusLost,
+		})
+	}
+
+	if _, err := s.dbRepo.InsertLostAuditLogs(ctx, logs); err != nil {
+		return fmt.Errorf("%w: failed to record unused values as lost: %w", ErrAuditStoreUnavailable, err)
+	}
+	metrics.BlockValuesUnused.WithLabelValues(b.prefix, blockOutcomeLost).Add(float64(unused))
+	s.logger.WithFields(fields).Warn("Recorded unused counter block as lost")
+
+	return nil
+}
+
+// validateBlockSize checks a per-prefix block size
+func validateBlockSize(size int) error {
+	if size < 0 || size > MaxBlockSize {
+		return fmt.Errorf("%w: block size must be between 0 and %d", ErrInvalidConfig, MaxBlockSize)
+	}
+	return nil
+}
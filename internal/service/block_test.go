@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newBlockTestService creates a service with prefix PO leasing blocks of 3
+func newBlockTestService() (*SequentialIDService, *memoryCounters, *memoryDatabase) {
+	counters, db := newMemoryCounters(), newMemoryDatabase()
+	db.addConfig(models.PrefixConfig{Prefix: "PO", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever, BlockSize: 3})
+	return newTestService(counters, db, nil), counters, db
+}
+
+func nextPO(t *testing.T, s *SequentialIDService) int64 {
+	t.Helper()
+	id, err := s.GetNext(context.Background(), &models.NextRequest{Prefix: "PO"})
+	if err != nil {
+		t.Fatalf("GetNext: %v", err)
+	}
+	return id.Counter
+}
+
+func TestBlockLeasing(t *testing.T) {
+	s, counters, db := newBlockTestService()
+
+	// The counter store only advances once per block
+	stored := []int64{3, 3, 3, 6, 6, 6, 9}
+	for i, want := range stored {
+		if got := nextPO(t, s); got != int64(i+1) {
+			t.Fatalf("GetNext = %d, want %d", got, i+1)
+		}
+		if got := counters.value("PO"); got != want {
+			t.Errorf("after ID %d the stored counter = %d, want %d", i+1, got, want)
+		}
+	}
+	if events := db.outboxEvents(); len(events) != len(stored) {
+		t.Errorf("outbox holds %d events, want %d", len(events), len(stored))
+	}
+}
+
+func TestReleaseBlocksReturnsUnusedValues(t *testing.T) {
+	s, counters, db := newBlockTestService()
+	nextPO(t, s)
+
+	if err := s.ReleaseBlocks(context.Background()); err != nil {
+		t.Fatalf("ReleaseBlocks: %v", err)
+	}
+	if got := counters.value("PO"); got != 1 {
+		t.Errorf("stored counter after release = %d, want 1", got)
+	}
+	if logs := db.auditLogs(); len(logs) != 0 {
+		t.Errorf("audit logs = %+v, want none for returned values", logs)
+	}
+	if got := nextPO(t, s); got != 2 {
+		t.Errorf("GetNext after release = %d, want 2", got)
+	}
+}
+
+func TestReleaseBlocksRecordsLostPlaceholders(t *testing.T) {
+	s, counters, db := newBlockTestService()
+	nextPO(t, s)
+
+	// Another instance leases the next block, so values 2 and 3 cannot be
+	// returned to the counter
+	if _, err := counters.IncrementCounterBy(context.Background(), "PO", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ReleaseBlocks(context.Background()); err != nil {
+		t.Fatalf("ReleaseBlocks: %v", err)
+	}
+	if got := counters.value("PO"); got != 6 {
+		t.Errorf("stored counter = %d, want 6", got)
+	}
+	logs := db.auditLogs()
+	if len(logs) != 2 {
+		t.Fatalf("audit logs = %+v, want placeholders for 2 and 3", logs)
+	}
+	for i, log := range logs {
+		want := int64(i + 2)
+		if log.CounterValue != want || log.Status != models.AuditStatusLost || stringValue(log.GeneratedBy) != blockReleaseActor {
+			t.Errorf("placeholder %d = counter %d, status %s by %s; want counter %d lost by %s",
+				i, log.CounterValue, log.Status, stringValue(log.GeneratedBy), want, blockReleaseActor)
+		}
+	}
+	if logs[0].FullNumber != "PO000002" {
+		t.Errorf("placeholder full number = %s, want PO000002", logs[0].FullNumber)
+	}
+}
+
+func TestReleaseBlocksFailures(t *testing.T) {
+	tests := []struct {
+		counters string
+		database string
+		want     error
+	}{
+		{counters: "CompareAndSetCounter", want: ErrCounterUnavailable},
+		{database: "InsertLostAuditLogs", want: ErrAuditStoreUnavailable},
+	}
+	for _, tt := range tests {
+		s, counters, db := newBlockTestService()
+		nextPO(t, s)
+		if _, err := counters.IncrementCounterBy(context.Background(), "PO", 3); err != nil {
+			t.Fatal(err)
+		}
+		dbDown := errors.New("connection refused")
+		if tt.counters != "" {
+			counters.failWith(tt.counters, dbDown)
+		}
+		if tt.database != "" {
+			db.failWith(tt.database, dbDown)
+		}
+
+		if err := s.ReleaseBlocks(context.Background()); err == nil {
+			t.Errorf("ReleaseBlocks with %s%s failing = nil, want an error", tt.counters, tt.database)
+		}
+		// The failed block is not released twice
+		if err := s.ReleaseBlocks(context.Background()); err != nil {
+			t.Errorf("second ReleaseBlocks = %v, want nil", err)
+		}
+
+		// The cause reaches callers releasing a single block
+		b := &counterBlock{tenant: models.DefaultTenant, prefix: "PO", next: 7, end: 9}
+		if err := s.releaseBlock(context.Background(), "PO", b); !errors.Is(err, tt.want) {
+			t.Errorf("releaseBlock = %v, want %v", err, tt.want)
+		}
+	}
+}
+
+func TestBlockSizeChangeReleasesBlocks(t *testing.T) {
+	s, counters, _ := newBlockTestService()
+	nextPO(t, s)
+
+	size := 5
+	if err := s.UpdateConfig(context.Background(), "PO", &models.ConfigUpdateRequest{BlockSize: &size, AdminUser: "admin"}); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if got := counters.value("PO"); got != 1 {
+		t.Errorf("stored counter after the size change = %d, want 1", got)
+	}
+	if got := nextPO(t, s); got != 2 {
+		t.Errorf("GetNext = %d, want 2", got)
+	}
+	if got := counters.value("PO"); got != 6 {
+		t.Errorf("stored counter = %d, want 6 after a block of 5", got)
+	}
+}
+
+func TestBlocksOfEndedPeriodsAreReleased(t *testing.T) {
+	s, counters, _ := newBlockTestService()
+	ctx := context.Background()
+	config := &models.PrefixConfig{Tenant: models.DefaultTenant, Prefix: "PO", BlockSize: 3}
+	old, current := counterPeriod{Key: "2025"}, counterPeriod{Key: "2026"}
+
+	if got, err := s.nextCounter(ctx, config, old); err != nil || got != 1 {
+		t.Fatalf("nextCounter(2025) = %d, %v; want 1", got, err)
+	}
+	if got, err := s.nextCounter(ctx, config, current); err != nil || got != 1 {
+		t.Fatalf("nextCounter(2026) = %d, %v; want 1", got, err)
+	}
+
+	if got := counters.value("PO:2025"); got != 1 {
+		t.Errorf("stored 2025 counter = %d, want 1 after its block was released", got)
+	}
+	if b := s.blocks.lookup("PO:2025"); b != nil {
+		t.Error("the block of 2025 is still held")
+	}
+	if b := s.blocks.lookup("PO:2026"); b == nil {
+		t.Error("the block of 2026 is not held")
+	}
+}
+
+func TestBatchesBypassBlocks(t *testing.T) {
+	s, _, _ := newBlockTestService()
+
+	if got := nextPO(t, s); got != 1 {
+		t.Fatalf("GetNext = %d, want 1", got)
+	}
+	batch, err := s.GetNextBatch(context.Background(), &models.BatchRequest{Prefix: "PO", Count: 2})
+	if err != nil {
+		t.Fatalf("GetNextBatch: %v", err)
+	}
+	if batch.IDs[0].Counter != 4 || batch.IDs[1].Counter != 5 {
+		t.Errorf("batch = %d..%d, want 4..5 past the leased block", batch.IDs[0].Counter, batch.IDs[1].Counter)
+	}
+	for _, want := range []int64{2, 3, 6} {
+		if got := nextPO(t, s); got != want {
+			t.Errorf("GetNext = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestReleaseBlocksWhileAllocating(t *testing.T) {
+	s, counters, db := newBlockTestService()
+	ctx := context.Background()
+
+	const workers, perWorker = 8, 50
+	issued := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := s.GetNext(ctx, &models.NextRequest{Prefix: "PO"})
+				if err != nil {
+					t.Errorf("GetNext: %v", err)
+					return
+				}
+				issued <- id.Counter
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := s.ReleaseBlocks(ctx); err != nil {
+				t.Errorf("ReleaseBlocks: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+	close(issued)
+	if err := s.ReleaseBlocks(ctx); err != nil {
+		t.Fatalf("ReleaseBlocks: %v", err)
+	}
+
+	// Every value up to the stored counter was issued or recorded as lost,
+	// exactly once
+	seen := make(map[int64]string)
+	for counter := range issued {
+		if seen[counter] != "" {
+			t.Fatalf("counter %d issued twice", counter)
+		}
+		seen[counter] = "issued"
+	}
+	for _, log := range db.auditLogs() {
+		if seen[log.CounterValue] != "" {
+			t.Fatalf("counter %d both %s and recorded as lost", log.CounterValue, seen[log.CounterValue])
+		}
+		seen[log.CounterValue] = "lost"
+	}
+	stored := counters.value("PO")
+	for counter := int64(1); counter <= stored; counter++ {
+		if seen[counter] == "" {
+			t.Errorf("counter %d neither issued nor recorded as lost", counter)
+		}
+	}
+	if int64(len(seen)) != stored {
+		t.Errorf("%d values accounted for, want %d", len(seen), stored)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Results of config cache lookups
+const (
+	configCacheHit   = "hit"
+	configCacheMiss  = "miss"
+	configCacheStale = "stale"
+)
+
+// cachedConfig is a prefix configuration as loaded at fetchedAt; a nil
+// config records an unknown prefix
+type cachedConfig struct {
+	config    *models.PrefixConfig
+	fetchedAt time.Time
+}
+
+// configCache keeps prefix configurations in memory so ID generation does
+// not query PostgreSQL on every call. Entries are keyed by configKey, expire
+// after the TTL and are dropped early when another instance announces a
+// change.
+type configCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedConfig
+	// generation advances with every invalidation so a load that raced with
+	// one is not stored
+	generation uint64
+	cfg        config.ConfigCacheConfig
+}
+
+// newConfigCache creates an empty config cache
+func newConfigCache(cfg config.ConfigCacheConfig) *configCache {
+	return &configCache{
+		entries: make(map[string]cachedConfig),
+		cfg:     cfg,
+	}
+}
+
+// configKey returns the cache key of a prefix of a tenant
+func configKey(tenant, prefix string) string {
+	return tenant + ":" + prefix
+}
+
+// get returns the cached entry of a key and the current generation
+func (c *configCache) get(key string) (cachedConfig, bool, uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	return entry, ok, c.generation
+}
+
+// put stores a configuration loaded in the given generation
+func (c *configCache) put(key string, cfg *models.PrefixConfig, fetchedAt time.Time, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	c.entries[key] = cachedConfig{config: cfg, fetchedAt: fetchedAt}
+}
+
+// invalidate drops the entry of a key
+func (c *configCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	c.generation++
+}
+
+// invalidateAll drops every entry
+func (c *configCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cachedConfig)
+	c.generation++
+}
+
+// prefixConfig returns the configuration of a prefix of a tenant, or nil
+// when the prefix is not configured. While the database is unavailable an
+// expired entry is served for up to MaxStale. Callers get their own copy.
+func (s *SequentialIDService) prefixConfig(ctx context.Context, tenant, prefix string) (*models.PrefixConfig, error) {
+	if s.configs.cfg.TTL <= 0 {
+		return s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	}
+
+	key := configKey(tenant, prefix)
+	now := time.Now()
+	entry, cached, generation := s.configs.get(key)
+	if cached && now.Sub(entry.fetchedAt) < s.configs.cfg.TTL {
+		metrics.ConfigCacheLookups.WithLabelValues(configCacheHit).Inc()
+		return copyConfig(entry.config), nil
+	}
+
+	config, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		if cached && now.Sub(entry.fetchedAt) < s.configs.cfg.TTL+s.configs.cfg.MaxStale {
+			metrics.ConfigCacheLookups.WithLabelValues(configCacheStale).Inc()
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"tenant": tenant,
+				"prefix": prefix,
+				"age":    now.Sub(entry.fetchedAt).String(),
+			}).Warn("Serving cached prefix config while the database is unavailable")
+			return copyConfig(entry.config), nil
+		}
+		return nil, err
+	}
+
+	metrics.ConfigCacheLookups.WithLabelValues(configCacheMiss).Inc()
+	s.configs.put(key, config, now, generation)
+	return copyConfig(config), nil
+}
+
+// InvalidateConfig drops the cached configuration of a prefix of a tenant
+func (s *SequentialIDService) InvalidateConfig(tenant, prefix string) {
+	s.configs.invalidate(configKey(tenant, prefix))
+}
+
+// InvalidateAllConfigs drops every cached prefix configuration, e.g. after
+// change notifications may have been missed
+func (s *SequentialIDService) InvalidateAllConfigs() {
+	s.logger.Debug("Dropping all cached prefix configs")
+	s.configs.invalidateAll()
+}
+
+// copyConfig returns a shallow copy so callers cannot modify cached entries
+func copyConfig(config *models.PrefixConfig) *models.PrefixConfig {
+	if config == nil {
+		return nil
+	}
+	c := *config
+	return &c
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// countingDatabase counts the prefix config loads that reach the database
+type countingDatabase struct {
+	*memoryDatabase
+	loads int
+}
+
+func (d *countingDatabase) GetPrefixConfig(ctx context.Context, tenant, prefix string) (*models.PrefixConfig, error) {
+	d.loads++
+	return d.memoryDatabase.GetPrefixConfig(ctx, tenant, prefix)
+}
+
+// newCacheTestService creates a service with prefix SG padded to 6 digits
+func newCacheTestService() (*SequentialIDService, *countingDatabase) {
+	db := &countingDatabase{memoryDatabase: newMemoryDatabase()}
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	return newTestService(newMemoryCounters(), db, nil), db
+}
+
+func padding(t *testing.T, s *SequentialIDService) int {
+	t.Helper()
+	config, err := s.prefixConfig(context.Background(), models.DefaultTenant, "SG")
+	if err != nil {
+		t.Fatalf("prefixConfig: %v", err)
+	}
+	return config.PaddingLength
+}
+
+func TestConfigCacheServesRepeatedLookups(t *testing.T) {
+	s, db := newCacheTestService()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		padding(t, s)
+		if _, err := s.prefixConfig(ctx, models.DefaultTenant, "PO"); err != nil {
+			t.Fatalf("prefixConfig(PO): %v", err)
+		}
+	}
+	// One load for SG and one for the unknown PO
+	if db.loads != 2 {
+		t.Errorf("database loads = %d, want 2", db.loads)
+	}
+
+	// Callers get their own copy
+	config, _ := s.prefixConfig(ctx, models.DefaultTenant, "SG")
+	config.PaddingLength = 9
+	if got := padding(t, s); got != 6 {
+		t.Errorf("cached padding = %d after a caller modified its copy, want 6", got)
+	}
+
+	// Tenants are cached separately
+	if config, _ := s.prefixConfig(ctx, "acme", "SG"); config != nil {
+		t.Errorf("prefixConfig(acme, SG) = %+v, want nil", config)
+	}
+}
+
+func TestConfigCacheInvalidation(t *testing.T) {
+	s, db := newCacheTestService()
+	padding(t, s)
+
+	// Changed by another instance
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 7, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	if got := padding(t, s); got != 6 {
+		t.Errorf("padding before the change notification = %d, want the cached 6", got)
+	}
+	s.InvalidateConfig(models.DefaultTenant, "SG")
+	if got := padding(t, s); got != 7 {
+		t.Errorf("padding after InvalidateConfig = %d, want 7", got)
+	}
+
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 8, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	s.InvalidateAllConfigs()
+	if got := padding(t, s); got != 8 {
+		t.Errorf("padding after InvalidateAllConfigs = %d, want 8", got)
+	}
+
+	// Changes made through this instance apply at once
+	width := 5
+	if err := s.UpdateConfig(context.Background(), "SG", &models.ConfigUpdateRequest{PaddingLength: &width, AdminUser: "admin"}); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if got := padding(t, s); got != 5 {
+		t.Errorf("padding after UpdateConfig = %d, want 5", got)
+	}
+}
+
+func TestConfigCacheServesStaleWhileDatabaseDown(t *testing.T) {
+	s, db := newCacheTestService()
+	padding(t, s)
+	db.failWith("GetPrefixConfig", errors.New("pq: connection refused"))
+	key := configKey(models.DefaultTenant, "SG")
+	cfg := s.configs.cfg
+
+	// Expired, but within MaxStale
+	entry, _, generation := s.configs.get(key)
+	s.configs.put(key, entry.config, time.Now().Add(-cfg.TTL-cfg.MaxStale/2), generation)
+	if got := padding(t, s); got != 6 {
+		t.Errorf("stale padding = %d, want 6", got)
+	}
+
+	// Past MaxStale
+	s.configs.put(key, entry.config, time.Now().Add(-cfg.TTL-cfg.MaxStale), generation)
+	if _, err := s.prefixConfig(context.Background(), models.DefaultTenant, "SG"); err == nil {
+		t.Error("prefixConfig past MaxStale = nil error, want the database error")
+	}
+}
+
+func TestConfigCacheDropsLoadsRacingInvalidation(t *testing.T) {
+	s, _ := newCacheTestService()
+	key := configKey(models.DefaultTenant, "SG")
+
+	_, _, generation := s.configs.get(key)
+	s.InvalidateConfig(models.DefaultTenant, "SG")
+	s.configs.put(key, &models.PrefixConfig{Prefix: "SG", PaddingLength: 6}, time.Now(), generation)
+	if _, cached, _ := s.configs.get(key); cached {
+		t.Error("a config loaded before an invalidation was cached")
+	}
+}
+
+func TestConfigCacheDisabled(t *testing.T) {
+	s, db := newCacheTestService()
+	s.configs.cfg.TTL = 0
+
+	padding(t, s)
+	padding(t, s)
+	if db.loads != 2 {
+		t.Errorf("database loads = %d, want 2 with the cache disabled", db.loads)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Config history page sizes
+const (
+	DefaultConfigHistoryLimit = 50
+	MaxConfigHistoryLimit     = 500
+)
+
+// Config history errors
+var (
+	// ErrInvalidConfigHistory is returned for history limits outside the allowed range
+	ErrInvalidConfigHistory = newError(KindInvalidArgument, "INVALID_CONFIG_HISTORY_QUERY", "invalid config history query")
+	// ErrConfigVersionNotFound is returned when rolling back to an unknown version
+	ErrConfigVersionNotFound = newError(KindNotFound, "CONFIG_VERSION_NOT_FOUND", "config version not found")
+	// ErrInvalidRollback is returned when a version cannot be restored
+	ErrInvalidRollback = newError(KindConflict, "INVALID_ROLLBACK", "invalid config rollback")
+)
+
+// GetConfigHistory returns the recorded configuration changes of a prefix
+// of the caller's tenant, newest first
+func (s *SequentialIDService) GetConfigHistory(ctx context.Context, prefix string, limit int) ([]models.ConfigChange, error) {
+	if limit == 0 {
+		limit = DefaultConfigHistoryLimit
+	}
+	if limit < 0 || limit > MaxConfigHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidConfigHistory, MaxConfigHistoryLimit)
+	}
+
+	history, err := s.dbRepo.GetConfigHistory(ctx, tenantOf(ctx), prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get config history: %w", ErrConfigStoreUnavailable, err)
+	}
+	return history, nil
+}
+
+// RollbackConfig restores the settings a prefix had after a previous change.
+// The rollback is validated like any update and recorded as a new change, so
+// the history itself is never rewritten.
+func (s *SequentialIDService) RollbackConfig(ctx context.Context, prefix string, req *models.ConfigRollbackRequest) (*models.PrefixConfig, error) {
+	tenant := tenantOf(ctx)
+	change, err := s.dbRepo.GetConfigChange(ctx, req.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get config change: %w", ErrConfigStoreUnavailable, err)
+	}
+	if change == nil || change.Tenant != tenant || change.Prefix != prefix {
+		return nil, fmt.Errorf("%w: %s version %d", ErrConfigVersionNotFound, prefix, req.Version)
+	}
+	if change.NewConfig == nil {
+		return nil, fmt.Errorf("%w: version %d of prefix %s records a deletion", ErrInvalidRollback, req.Version, prefix)
+	}
+
+	target := change.NewConfig
+	update := &models.ConfigUpdateRequest{
+		PaddingLength:  &target.PaddingLength,
+		FormatTemplate: &target.FormatTemplate,
+		ResetRule:      &target.ResetRule,
+		Gapless:        &target.Gapless,
+		BlockSize:      &target.BlockSize,
+		AdminUser:      req.AdminUser,
+	}
+	if err := s.applyConfigUpdate(ctx, prefix, update, models.ConfigChangeRollback); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant":     tenant,
+		"prefix":     prefix,
+		"version":    req.Version,
+		"admin_user": update.AdminUser,
+	}).Info("Rolled back prefix config")
+
+	config, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newHistoryTestService creates a service whose prefix PO was created with
+// padding 4 (version 1) and widened to 8 (version 2)
+func newHistoryTestService(t *testing.T) (*SequentialIDService, *memoryDatabase) {
+	t.Helper()
+	db := newMemoryDatabase()
+	s := newTestService(newMemoryCounters(), db, nil)
+	ctx := context.Background()
+
+	narrow, wide := 4, 8
+	if err := s.UpdateConfig(ctx, "PO", &models.ConfigUpdateRequest{PaddingLength: &narrow, AdminUser: "alice", CreateIfNotExists: true}); err != nil {
+		t.Fatalf("create PO: %v", err)
+	}
+	if err := s.UpdateConfig(ctx, "PO", &models.ConfigUpdateRequest{PaddingLength: &wide, AdminUser: "bob"}); err != nil {
+		t.Fatalf("update PO: %v", err)
+	}
+	return s, db
+}
+
+func TestGetConfigHistory(t *testing.T) {
+	s, _ := newHistoryTestService(t)
+	ctx := context.Background()
+
+	history, err := s.GetConfigHistory(ctx, "PO", 0)
+	if err != nil {
+		t.Fatalf("GetConfigHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want 2 changes", history)
+	}
+	update, create := history[0], history[1]
+	if update.ChangeType != models.ConfigChangeUpdate || update.AdminUser != "bob" ||
+		update.OldConfig.PaddingLength != 4 || update.NewConfig.PaddingLength != 8 {
+		t.Errorf("newest change = %+v, want bob's update from padding 4 to 8", update)
+	}
+	if create.ChangeType != models.ConfigChangeCreate || create.AdminUser != "alice" || create.OldConfig != nil {
+		t.Errorf("oldest change = %+v, want alice's create", create)
+	}
+
+	if history, _ := s.GetConfigHistory(ctx, "PO", 1); len(history) != 1 || history[0].Version != update.Version {
+		t.Errorf("history limited to 1 = %+v, want the newest change", history)
+	}
+	for _, limit := range []int{-1, MaxConfigHistoryLimit + 1} {
+		if _, err := s.GetConfigHistory(ctx, "PO", limit); !errors.Is(err, ErrInvalidConfigHistory) {
+			t.Errorf("GetConfigHistory(limit %d) = %v, want ErrInvalidConfigHistory", limit, err)
+		}
+	}
+}
+
+func TestRollbackConfig(t *testing.T) {
+	s, _ := newHistoryTestService(t)
+	ctx := context.Background()
+
+	config, err := s.RollbackConfig(ctx, "PO", &models.ConfigRollbackRequest{Version: 1, AdminUser: "carol"})
+	if err != nil {
+		t.Fatalf("RollbackConfig: %v", err)
+	}
+	if config.PaddingLength != 4 {
+		t.Errorf("padding after the rollback = %d, want 4", config.PaddingLength)
+	}
+
+	// The rollback is a new change; earlier ones are kept
+	history, _ := s.GetConfigHistory(ctx, "PO", 0)
+	if len(history) != 3 {
+		t.Fatalf("history = %+v, want 3 changes", history)
+	}
+	if latest := history[0]; latest.ChangeType != models.ConfigChangeRollback || latest.AdminUser != "carol" || latest.NewConfig.PaddingLength != 4 {
+		t.Errorf("newest change = %+v, want carol's rollback to padding 4", latest)
+	}
+}
+
+func TestRollbackConfigErrors(t *testing.T) {
+	s, db := newHistoryTestService(t)
+	ctx := context.Background()
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	if err := s.DeletePrefix(ctx, "PO", &models.PrefixDeleteRequest{AdminUser: "alice"}); err != nil {
+		t.Fatalf("DeletePrefix: %v", err)
+	}
+	acme := auth.WithPrincipal(ctx, &auth.Principal{Subject: "mallory", Tenant: "acme"})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		prefix  string
+		version int64
+		admin   string
+		want    error
+	}{
+		{"unknown version", ctx, "PO", 42, "carol", ErrConfigVersionNotFound},
+		{"version of another prefix", ctx, "SG", 1, "carol", ErrConfigVersionNotFound},
+		{"version of another tenant", acme, "PO", 1, "carol", ErrConfigVersionNotFound},
+		{"deletion", ctx, "PO", 3, "carol", ErrInvalidRollback},
+		{"deleted prefix", ctx, "PO", 1, "carol", ErrPrefixNotFound},
+		{"missing admin user", ctx, "PO", 1, "", ErrAdminUserRequired},
+	}
+	for _, tt := range tests {
+		req := &models.ConfigRollbackRequest{Version: tt.version, AdminUser: tt.admin}
+		if _, err := s.RollbackConfig(tt.ctx, tt.prefix, req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: RollbackConfig = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
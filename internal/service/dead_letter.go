@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits on the number of dead letters inspected per request
+const (
+	DefaultDeadLetterLimit = 100
+	MaxDeadLetterLimit     = 10000
+)
+
+// Dead letter errors
+var (
+	// ErrInvalidDeadLetterRequest is returned for malformed dead letter requests
+	ErrInvalidDeadLetterRequest = newError(KindInvalidArgument, "INVALID_DEAD_LETTER_REQUEST", "invalid dead letter request")
+	// ErrQueueUnavailable is returned when the dead letter queue cannot be reached
+	ErrQueueUnavailable = newError(KindUnavailable, "QUEUE_UNAVAILABLE", "message queue unavailable")
+)
+
+// DeadLetterManager inspects the dead letter queue of audit events and
+// replays or purges its messages, recording every replay and purge in
+// seq_dlq_audit
+type DeadLetterManager struct {
+	rabbitRepo repository.EventBroker
+	dbRepo     repository.Database
+	logger     *logrus.Logger
+}
+
+// NewDeadLetterManager creates a new dead letter manager
+func NewDeadLetterManager(
+	rabbitRepo repository.EventBroker,
+	dbRepo repository.Database,
+	logger *logrus.Logger,
+) *DeadLetterManager {
+	return &DeadLetterManager{
+		rabbitRepo: rabbitRepo,
+		dbRepo:     dbRepo,
+		logger:     logger,
+	}
+}
+
+// ListDeadLetters lists dead-lettered audit events. Authenticated callers
+// only see events of their own tenant.
+func (s *SequentialIDService) ListDeadLetters(ctx context.Context, q *models.DeadLetterQuery) (*models.DeadLetterList, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); ok {
+		q.Tenant = tenantOf(ctx)
+	}
+	return NewDeadLetterManager(s.rabbitRepo, s.dbRepo, s.logger).List(ctx, q)
+}
+
+// ReplayDeadLetters publishes dead-lettered audit events back to the worker
+// (admin operation). Authenticated callers only replay events of their own
+// tenant.
+func (s *SequentialIDService) ReplayDeadLetters(ctx context.Context, req *models.DeadLetterRequest) (*models.DeadLetterResult, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); ok {
+		req.Tenant = tenantOf(ctx)
+	}
+	req.AdminUser = actor(ctx, req.AdminUser)
+	return NewDeadLetterManager(s.rabbitRepo, s.dbRepo, s.logger).Replay(ctx, req)
+}
+
+// PurgeDeadLetters discards dead-lettered audit events (admin operation).
+// Authenticated callers only purge events of their own tenant.
+func (s *SequentialIDService) PurgeDeadLetters(ctx context.Context, req *models.DeadLetterRequest) (*models.DeadLetterResult, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); ok {
+		req.Tenant = tenantOf(ctx)
+	}
+	req.AdminUser = actor(ctx, req.AdminUser)
+	return NewDeadLetterManager(s.rabbitRepo, s.dbRepo, s.logger).Purge(ctx, req)
+}
+
+// List returns the dead letters of q.Tenant, or of every tenant when it is
+// empty, among the first q.Limit messages of the queue. Messages whose
+// payload cannot be parsed belong to no tenant and are only listed for
+// every tenant.
+func (m *DeadLetterManager) List(ctx context.Context, q *models.DeadLetterQuery) (*models.DeadLetterList, error) {
+	limit, err := deadLetterLimit(q.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, scanned, err := m.rabbitRepo.PeekDeadLetters(ctx, limit, tenantMatcher(q.Tenant, nil))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to peek dead letters: %w", ErrQueueUnavailable, err)
+	}
+	if messages == nil {
+		messages = []models.DeadLetter{}
+	}
+
+	depth, err := m.rabbitRepo.DeadLetterDepth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get dead letter depth: %w", ErrQueueUnavailable, err)
+	}
+
+	return &models.DeadLetterList{
+		Messages: messages,
+		Count:    len(messages),
+		Scanned:  scanned,
+		Depth:    depth,
+	}, nil
+}
+
+// Replay publishes the selected dead letters back to the main exchange with
+// a fresh retry count
+func (m *DeadLetterManager) Replay(ctx context.Context, req *models.DeadLetterRequest) (*models.DeadLetterResult, error) {
+	return m.handle(ctx, req, models.DeadLetterActionReplay, m.rabbitRepo.ReplayDeadLetters)
+}
+
+// Purge discards the selected dead letters; a reason is required
+func (m *DeadLetterManager) Purge(ctx context.Context, req *models.DeadLetterRequest) (*models.DeadLetterResult, error) {
+	if req.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required to purge dead letters", ErrInvalidDeadLetterRequest)
+	}
+	return m.handle(ctx, req, models.DeadLetterActionPurge, m.rabbitRepo.DropDeadLetters)
+}
+
+// handle applies a replay or purge to the selected dead letters and records
+// it. Messages handled before a failure are recorded too.
+func (m *DeadLetterManager) handle(
+	ctx context.Context,
+	req *models.DeadLetterRequest,
+	action string,
+	apply func(context.Context, int, func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error),
+) (*models.DeadLetterResult, error) {
+	if req.All == (len(req.MessageIDs) > 0) {
+		return nil, fmt.Errorf("%w: exactly one of message_ids or all is required", ErrInvalidDeadLetterRequest)
+	}
+	if req.AdminUser == "" {
+		return nil, fmt.Errorf("%w: admin user is required", ErrInvalidDeadLetterRequest)
+	}
+	limit, err := deadLetterLimit(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids map[string]bool
+	if !req.All {
+		ids = make(map[string]bool, len(req.MessageIDs))
+		for _, id := range req.MessageIDs {
+			ids[id] = true
+		}
+	}
+
+	handled, scanned, applyErr := apply(ctx, limit, tenantMatcher(req.Tenant, ids))
+	if applyErr != nil && len(handled) == 0 {
+		return nil, fmt.Errorf("%w: failed to %s dead letters: %w", ErrQueueUnavailable, strings.ToLower(action), applyErr)
+	}
+
+	result := &models.DeadLetterResult{
+		Action:     action,
+		MessageIDs: make([]string, len(handled)),
+		Count:      len(handled),
+		Scanned:    scanned,
+		HandledAt:  time.Now(),
+	}
+	for i := range handled {
+		result.MessageIDs[i] = handled[i].MessageID
+	}
+	metrics.DeadLettersHandled.WithLabelValues(strings.ToLower(action)).Add(float64(len(handled)))
+
+	fields := logrus.Fields{
+		"tenant":     req.Tenant,
+		"action":     action,
+		"count":      result.Count,
+		"scanned":    scanned,
+		"admin_user": req.AdminUser,
+	}
+
+	audit := &models.DeadLetterAudit{
+		Tenant:     optionalString(req.Tenant),
+		Action:     action,
+		MessageIDs: result.MessageIDs,
+		Count:      result.Count,
+		Reason:     req.Reason,
+		AdminUser:  req.AdminUser,
+	}
+	if err := m.dbRepo.InsertDeadLetterAudit(ctx, audit); err != nil {
+		m.logger.WithError(err).WithFields(fields).WithField("message_ids", result.MessageIDs).
+			Error("Failed to record dead letter audit")
+		return nil, fmt.Errorf("%w: handled %d dead letters but failed to record them: %w", ErrAuditStoreUnavailable, result.Count, err)
+	}
+	result.AuditID = audit.ID
+	result.HandledAt = audit.HandledAt
+
+	if applyErr != nil {
+		m.logger.WithError(applyErr).WithFields(fields).Error("Dead letter request stopped early")
+		return nil, fmt.Errorf("%w: stopped after %d dead letters: %w", ErrQueueUnavailable, result.Count, applyErr)
+	}
+
+	depth, err := m.rabbitRepo.DeadLetterDepth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get dead letter depth: %w", ErrQueueUnavailable, err)
+	}
+	result.Remaining = depth
+
+	m.logger.WithFields(fields).Info("Handled dead letters")
+
+	return result, nil
+}
+
+// tenantMatcher selects dead letters of a tenant, or of every tenant when it
+// is empty, and with one of the given message IDs unless ids is nil
+func tenantMatcher(tenant string, ids map[string]bool) func(*models.DeadLetter) bool {
+	return func(dl *models.DeadLetter) bool {
+		if tenant != "" && dl.Tenant != tenant {
+			return false
+		}
+		return ids == nil || ids[dl.MessageID]
+	}
+}
+
+// deadLetterLimit resolves the requested number of messages to inspect
+func deadLetterLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultDeadLetterLimit, nil
+	}
+	if limit < 0 || limit > MaxDeadLetterLimit {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidDeadLetterRequest, MaxDeadLetterLimit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newDeadLetterTestService creates a service whose dead letter queue holds
+// events m1 and m3 of the default tenant, m2 of acme and the unparseable m4
+func newDeadLetterTestService() (*SequentialIDService, *memoryDatabase, *memoryBroker) {
+	db, broker := newMemoryDatabase(), newMemoryBroker()
+	for _, dl := range []struct{ id, tenant string }{{"m1", models.DefaultTenant}, {"m2", "acme"}, {"m3", models.DefaultTenant}} {
+		broker.deadLetters = append(broker.deadLetters, models.DeadLetter{
+			MessageID: dl.id,
+			Tenant:    dl.tenant,
+			Prefix:    "SG",
+			Reason:    "pq: connection refused",
+			Event:     &models.Event{MessageID: dl.id, Tenant: dl.tenant, Prefix: "SG"},
+		})
+	}
+	broker.deadLetters = append(broker.deadLetters, models.DeadLetter{MessageID: "m4", Payload: "{", Reason: "failed to parse event"})
+	return newTestService(nil, db, broker), db, broker
+}
+
+func messageIDs(dls []models.DeadLetter) []string {
+	ids := make([]string, len(dls))
+	for i := range dls {
+		ids[i] = dls[i].MessageID
+	}
+	return ids
+}
+
+func TestListDeadLetters(t *testing.T) {
+	s, _, _ := newDeadLetterTestService()
+	ctx := context.Background()
+	acme := auth.WithPrincipal(ctx, &auth.Principal{Subject: "alice", Tenant: "acme"})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		limit   int
+		want    []string
+		scanned int
+	}{
+		{"all tenants", ctx, 0, []string{"m1", "m2", "m3", "m4"}, 4},
+		{"limited", ctx, 2, []string{"m1", "m2"}, 2},
+		{"own tenant only", acme, 0, []string{"m2"}, 4},
+	}
+	for _, tt := range tests {
+		list, err := s.ListDeadLetters(tt.ctx, &models.DeadLetterQuery{Limit: tt.limit})
+		if err != nil {
+			t.Fatalf("%s: ListDeadLetters: %v", tt.name, err)
+		}
+		if got := messageIDs(list.Messages); !reflect.DeepEqual(got, tt.want) || list.Count != len(tt.want) {
+			t.Errorf("%s: listed %v (count %d), want %v", tt.name, got, list.Count, tt.want)
+		}
+		if list.Scanned != tt.scanned || list.Depth != 4 {
+			t.Errorf("%s: scanned %d of depth %d, want %d of 4", tt.name, list.Scanned, list.Depth, tt.scanned)
+		}
+	}
+
+	for _, limit := range []int{-1, MaxDeadLetterLimit + 1} {
+		if _, err := s.ListDeadLetters(ctx, &models.DeadLetterQuery{Limit: limit}); !errors.Is(err, ErrInvalidDeadLetterRequest) {
+			t.Errorf("ListDeadLetters(limit %d) = %v, want ErrInvalidDeadLetterRequest", limit, err)
+		}
+	}
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	s, db, broker := newDeadLetterTestService()
+
+	result, err := s.ReplayDeadLetters(context.Background(), &models.DeadLetterRequest{MessageIDs: []string{"m3", "m2"}, AdminUser: "admin"})
+	if err != nil {
+		t.Fatalf("ReplayDeadLetters: %v", err)
+	}
+	if !reflect.DeepEqual(result.MessageIDs, []string{"m2", "m3"}) || result.Remaining != 2 || result.Action != models.DeadLetterActionReplay {
+		t.Errorf("result = %+v, want m2 and m3 replayed with 2 remaining", result)
+	}
+	if published := broker.publishedEvents(); len(published) != 2 {
+		t.Errorf("published %d events, want the 2 replayed", len(published))
+	}
+	if got := messageIDs(broker.deadLetters); !reflect.DeepEqual(got, []string{"m1", "m4"}) {
+		t.Errorf("dead letters left = %v, want m1 and m4", got)
+	}
+
+	if len(db.dlqAudits) != 1 {
+		t.Fatalf("dead letter audits = %+v, want 1", db.dlqAudits)
+	}
+	audit := db.dlqAudits[0]
+	if audit.ID != result.AuditID || audit.Count != 2 || audit.AdminUser != "admin" || audit.Tenant != nil {
+		t.Errorf("audit = %+v, want admin's replay of 2 messages of all tenants", audit)
+	}
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	s, db, broker := newDeadLetterTestService()
+	acme := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice", Tenant: "acme"})
+
+	result, err := s.PurgeDeadLetters(acme, &models.DeadLetterRequest{All: true, Reason: "test data", AdminUser: "ignored"})
+	if err != nil {
+		t.Fatalf("PurgeDeadLetters: %v", err)
+	}
+	if !reflect.DeepEqual(result.MessageIDs, []string{"m2"}) {
+		t.Errorf("purged %v, want acme's m2 only", result.MessageIDs)
+	}
+	if published := broker.publishedEvents(); len(published) != 0 {
+		t.Errorf("purge published %d events", len(published))
+	}
+	audit := db.dlqAudits[0]
+	if audit.Action != models.DeadLetterActionPurge || audit.Reason != "test data" || audit.AdminUser != "alice" || stringValue(audit.Tenant) != "acme" {
+		t.Errorf("audit = %+v, want alice's purge of acme's dead letters with the reason", audit)
+	}
+}
+
+func TestDeadLetterRequestErrors(t *testing.T) {
+	queueDown := errors.New("amqp: channel closed")
+	tests := []struct {
+		name      string
+		req       models.DeadLetterRequest
+		purge     bool
+		broker    string // dead letter method failing with queueDown
+		partial   bool   // the scan stops after one message
+		database  string // fails with an error
+		want      error
+		audited   int
+		remaining int // dead letters left
+	}{
+		{name: "no selection", req: models.DeadLetterRequest{AdminUser: "admin"}, want: ErrInvalidDeadLetterRequest, remaining: 4},
+		{name: "ids and all", req: models.DeadLetterRequest{MessageIDs: []string{"m1"}, All: true, AdminUser: "admin"}, want: ErrInvalidDeadLetterRequest, remaining: 4},
+		{name: "no admin user", req: models.DeadLetterRequest{All: true}, want: ErrInvalidDeadLetterRequest, remaining: 4},
+		{name: "purge without reason", req: models.DeadLetterRequest{All: true, AdminUser: "admin"}, purge: true, want: ErrInvalidDeadLetterRequest, remaining: 4},
+		{name: "queue unavailable", req: models.DeadLetterRequest{All: true, AdminUser: "admin"}, broker: "ReplayDeadLetters", want: ErrQueueUnavailable, remaining: 4},
+		{name: "stopped early", req: models.DeadLetterRequest{All: true, AdminUser: "admin"}, partial: true, want: ErrQueueUnavailable, audited: 1, remaining: 3},
+		{name: "audit unavailable", req: models.DeadLetterRequest{All: true, AdminUser: "admin"}, database: "InsertDeadLetterAudit", want: ErrAuditStoreUnavailable},
+	}
+	for _, tt := range tests {
+		s, db, broker := newDeadLetterTestService()
+		if tt.broker != "" {
+			broker.failWith(tt.broker, queueDown)
+		}
+		if tt.partial {
+			broker.scanFailAfter, broker.scanErr = 1, queueDown
+		}
+		if tt.database != "" {
+			db.failWith(tt.database, errors.New("pq: connection refused"))
+		}
+
+		var err error
+		if tt.purge {
+			_, err = s.PurgeDeadLetters(context.Background(), &tt.req)
+		} else {
+			_, err = s.ReplayDeadLetters(context.Background(), &tt.req)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+		if len(db.dlqAudits) != tt.audited {
+			t.Errorf("%s: %d audits recorded, want %d", tt.name, len(db.dlqAudits), tt.audited)
+		}
+		if len(broker.deadLetters) != tt.remaining {
+			t.Errorf("%s: %d dead letters left, want %d", tt.name, len(broker.deadLetters), tt.remaining)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+)
+
+// Kind classifies service errors so each transport can map them to its own
+// status codes
+type Kind int
+
+// Error kinds
+const (
+	// KindInternal marks unexpected failures
+	KindInternal Kind = iota
+	// KindInvalidArgument marks malformed or out-of-range requests
+	KindInvalidArgument
+	// KindNotFound marks unknown prefixes, reservations and versions
+	KindNotFound
+	// KindConflict marks requests that conflict with the current state
+	KindConflict
+	// KindExpired marks resources that existed but can no longer be used
+	KindExpired
+	// KindUnprocessable marks well-formed requests that contradict an earlier one
+	KindUnprocessable
+	// KindAborted marks requests blocked by a concurrent one; retrying may succeed
+	KindAborted
+	// KindUnavailable marks failures of a backing store
+	KindUnavailable
+	// KindPermissionDenied marks callers lacking a role or prefix scope
+	KindPermissionDenied
+	// KindCanceled marks requests the caller gave up on
+	KindCanceled
+	// KindDeadlineExceeded marks requests that ran out of time
+	KindDeadlineExceeded
+)
+
+// Error is a service error with a kind and a stable, machine-readable code.
+// Errors are compared by identity, so errors.Is matches them through
+// fmt.Errorf wrapping.
+type Error struct {
+	Kind Kind
+	Code string
+	msg  string
+}
+
+func newError(kind Kind, code, msg string) *Error {
+	return &Error{Kind: kind, Code: code, msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// CodeInvalidArgument is the code of malformed request fields detected by
+// the transports before calling the service
+const CodeInvalidArgument = "INVALID_ARGUMENT"
+
+// Errors shared by several operations
+var (
+	// ErrCounterUnavailable is returned when the counter store cannot be reached
+	ErrCounterUnavailable = newError(KindUnavailable, "COUNTER_STORE_UNAVAILABLE", "counter store unavailable")
+	// ErrConfigStoreUnavailable is returned when prefix configurations cannot be read
+	ErrConfigStoreUnavailable = newError(KindUnavailable, "CONFIG_STORE_UNAVAILABLE", "config store unavailable")
+	// ErrAuditStoreUnavailable is returned when the audit log cannot be read or written
+	ErrAuditStoreUnavailable = newError(KindUnavailable, "AUDIT_STORE_UNAVAILABLE", "audit store unavailable")
+	// ErrInvalidCount is returned for batch and preview sizes outside the allowed range
+	ErrInvalidCount = newError(KindInvalidArgument, "INVALID_COUNT", "invalid count")
+	// ErrAdminUserRequired is returned for admin operations without an operator
+	ErrAdminUserRequired = newError(KindInvalidArgument, "ADMIN_USER_REQUIRED", "admin user is required")
+	// ErrInvalidConfig is returned for configuration updates that cannot be applied
+	ErrInvalidConfig = newError(KindInvalidArgument, "INVALID_CONFIG", "invalid prefix configuration")
+)
+
+// Errors that classify errors of other packages
+var (
+	errPermissionDenied      = newError(KindPermissionDenied, "PERMISSION_DENIED", "permission denied")
+	errInvalidFormatTemplate = newError(KindInvalidArgument, "INVALID_FORMAT_TEMPLATE", "invalid format template")
+	errCanceled              = newError(KindCanceled, "CANCELED", "request canceled")
+	errDeadlineExceeded      = newError(KindDeadlineExceeded, "DEADLINE_EXCEEDED", "deadline exceeded")
+	errInternal              = newError(KindInternal, "INTERNAL", "internal error")
+)
+
+// Classify returns the service error wrapped by err. Errors caused by the
+// request's context classify as CANCELED or DEADLINE_EXCEEDED, whatever
+// else they wrap. Authorization failures classify as PERMISSION_DENIED,
+// format template errors as INVALID_FORMAT_TEMPLATE and anything else as
+// INTERNAL.
+func Classify(err error) *Error {
+	var svcErr *Error
+	var parseErr *idformat.ParseError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return errDeadlineExceeded
+	case errors.As(err, &svcErr):
+		return svcErr
+	case errors.Is(err, auth.ErrForbidden):
+		return errPermissionDenied
+	case errors.As(err, &parseErr):
+		return errInvalidFormatTemplate
+	default:
+		return errInternal
+	}
+}
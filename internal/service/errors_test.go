@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+func TestClassify(t *testing.T) {
+	_, parseErr := idformat.Parse("{prefix}{bogus}")
+	if parseErr == nil {
+		t.Fatal("idformat.Parse accepted an unknown placeholder")
+	}
+	tests := []struct {
+		name string
+		err  error
+		want *Error
+	}{
+		{"service error", ErrPrefixNotFound, ErrPrefixNotFound},
+		{"wrapped service error", fmt.Errorf("%w: failed to get counter: %w", ErrCounterUnavailable, errors.New("dial tcp: refused")), ErrCounterUnavailable},
+		{"canceled", context.Canceled, errCanceled},
+		{"canceled wins over the wrapping error", fmt.Errorf("%w: %w", ErrConfigStoreUnavailable, context.Canceled), errCanceled},
+		{"deadline exceeded", fmt.Errorf("%w: %w", ErrAuditStoreUnavailable, context.DeadlineExceeded), errDeadlineExceeded},
+		{"forbidden", fmt.Errorf("prefix SG: %w", auth.ErrForbidden), errPermissionDenied},
+		{"format template", parseErr, errInvalidFormatTemplate},
+		{"anything else", errors.New("pq: syntax error"), errInternal},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("%s: Classify(%v) = %s, want %s", tt.name, tt.err, got.Code, tt.want.Code)
+		}
+	}
+}
+
+// TestStoreFailuresClassify checks that database failures are reported as
+// unavailable stores rather than as internal errors
+func TestStoreFailuresClassify(t *testing.T) {
+	dbDown := errors.New("pq: connection refused")
+	admin := "admin"
+	tests := []struct {
+		name   string
+		method string // fails with dbDown
+		call   func(s *SequentialIDService) error
+		want   *Error
+	}{
+		{"query audit logs", "QueryAuditLogs", func(s *SequentialIDService) error {
+			_, err := s.QueryAuditLogs(context.Background(), &models.AuditQuery{})
+			return err
+		}, ErrAuditStoreUnavailable},
+		{"look up an ID", "GetAuditLogsByFullNumber", func(s *SequentialIDService) error {
+			_, err := s.LookupID(context.Background(), "SG000001")
+			return err
+		}, ErrAuditStoreUnavailable},
+		{"reset history", "GetResetHistory", func(s *SequentialIDService) error {
+			_, err := s.GetResetHistory(context.Background(), "SG", nil, 0)
+			return err
+		}, ErrAuditStoreUnavailable},
+		{"list prefixes", "GetTenantPrefixConfigs", func(s *SequentialIDService) error {
+			_, err := s.ListPrefixes(context.Background(), false)
+			return err
+		}, ErrConfigStoreUnavailable},
+		{"last issued times", "GetLastIssuedTimes", func(s *SequentialIDService) error {
+			_, err := s.ListPrefixes(context.Background(), false)
+			return err
+		}, ErrAuditStoreUnavailable},
+		{"config history", "GetConfigHistory", func(s *SequentialIDService) error {
+			_, err := s.GetConfigHistory(context.Background(), "SG", 0)
+			return err
+		}, ErrConfigStoreUnavailable},
+		{"rollback", "GetConfigChange", func(s *SequentialIDService) error {
+			_, err := s.RollbackConfig(context.Background(), "SG", &models.ConfigRollbackRequest{Version: 1, AdminUser: admin})
+			return err
+		}, ErrConfigStoreUnavailable},
+		{"create", "CreatePrefixConfig", func(s *SequentialIDService) error {
+			return s.UpdateConfig(context.Background(), "PO", &models.ConfigUpdateRequest{AdminUser: admin, CreateIfNotExists: true})
+		}, ErrConfigStoreUnavailable},
+		{"update", "UpdatePrefixConfig", func(s *SequentialIDService) error {
+			padding := 8
+			return s.UpdateConfig(context.Background(), "SG", &models.ConfigUpdateRequest{PaddingLength: &padding, AdminUser: admin})
+		}, ErrConfigStoreUnavailable},
+		{"archive", "UpdatePrefixConfig", func(s *SequentialIDService) error {
+			_, err := s.ArchivePrefix(context.Background(), "SG", &models.PrefixArchiveRequest{AdminUser: admin})
+			return err
+		}, ErrConfigStoreUnavailable},
+		{"delete", "DeletePrefixConfig", func(s *SequentialIDService) error {
+			return s.DeletePrefix(context.Background(), "SG", &models.PrefixDeleteRequest{AdminUser: admin})
+		}, ErrConfigStoreUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMemoryDatabase()
+			db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+			db.failWith(tt.method, dbDown)
+			err := tt.call(newTestService(nil, db, nil))
+			if got := Classify(err); got != tt.want {
+				t.Errorf("Classify(%v) = %s, want %s", err, got.Code, tt.want.Code)
+			}
+		})
+	}
+}
+
+// staleDatabase answers GetPrefixConfig with the state before a concurrent
+// request created or deleted the prefix
+type staleDatabase struct {
+	*memoryDatabase
+	stale *models.PrefixConfig
+}
+
+func (d *staleDatabase) GetPrefixConfig(context.Context, string, string) (*models.PrefixConfig, error) {
+	return d.stale, nil
+}
+
+func TestConcurrentConfigWrites(t *testing.T) {
+	sg := models.PrefixConfig{Tenant: models.DefaultTenant, Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever}
+	padding := 8
+	ctx := context.Background()
+
+	// Created by another request after the existence check
+	created := newMemoryDatabase()
+	created.addConfig(sg)
+	s := newTestService(nil, &staleDatabase{memoryDatabase: created}, nil)
+	err := s.UpdateConfig(ctx, "SG", &models.ConfigUpdateRequest{PaddingLength: &padding, AdminUser: "admin", CreateIfNotExists: true})
+	if !errors.Is(err, ErrPrefixExists) || Classify(err).Kind != KindConflict {
+		t.Errorf("create of a concurrently created prefix = %v, want a PREFIX_EXISTS conflict", err)
+	}
+
+	// Deleted by another request after the existence check
+	s = newTestService(nil, &staleDatabase{memoryDatabase: newMemoryDatabase(), stale: &sg}, nil)
+	err = s.UpdateConfig(ctx, "SG", &models.ConfigUpdateRequest{PaddingLength: &padding, AdminUser: "admin"})
+	if !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("update of a concurrently deleted prefix = %v, want ErrPrefixNotFound", err)
+	}
+	err = s.DeletePrefix(ctx, "SG", &models.PrefixDeleteRequest{AdminUser: "admin"})
+	if !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("delete of a concurrently deleted prefix = %v, want ErrPrefixNotFound", err)
+	}
+	_, err = s.ArchivePrefix(ctx, "SG", &models.PrefixArchiveRequest{AdminUser: "admin"})
+	if !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("archive of a concurrently deleted prefix = %v, want ErrPrefixNotFound", err)
+	}
+}
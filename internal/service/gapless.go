@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Gapless mode errors
+var (
+	// ErrGaplessPrefix is returned by GetNext and GetNextBatch for gapless prefixes
+	ErrGaplessPrefix = newError(KindConflict, "PREFIX_GAPLESS", "prefix is gapless; use reserve and commit")
+	// ErrNotGapless is returned when reserving a number of a regular prefix
+	ErrNotGapless = newError(KindConflict, "PREFIX_NOT_GAPLESS", "prefix is not gapless")
+	// ErrInvalidLease is returned for lease durations outside the allowed range
+	ErrInvalidLease = newError(KindInvalidArgument, "INVALID_LEASE", "invalid lease")
+	// ErrReservationNotFound is returned for unknown reservation IDs
+	ErrReservationNotFound = newError(KindNotFound, "RESERVATION_NOT_FOUND", "reservation not found")
+	// ErrReservationExpired is returned when the lease was released or has expired
+	ErrReservationExpired = newError(KindExpired, "RESERVATION_EXPIRED", "reservation expired or released")
+	// ErrReservationStoreUnavailable is returned when reservations cannot be read or written
+	ErrReservationStoreUnavailable = newError(KindUnavailable, "RESERVATION_STORE_UNAVAILABLE", "reservation store unavailable")
+)
+
+// Reserve leases a provisional number of a gapless prefix. Released and
+// expired numbers are handed out again, lowest first, before the counter
+// advances, so committed numbers stay free of gaps.
+func (s *SequentialIDService) Reserve(ctx context.Context, req *models.ReserveRequest) (_ *models.Reservation, err error) {
+	defer func(start time.Time) { metrics.ObserveGenerate("reserve", start, err) }(time.Now())
+
+	if err := authorizePrefix(ctx, req.Prefix); err != nil {
+		return nil, err
+	}
+
+	lease, err := s.leaseDuration(req.LeaseSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get prefix configuration
+	tenant := tenantOf(ctx)
+	config, err := s.prefixConfig(ctx, tenant, req.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixNotFound, req.Prefix)
+	}
+	if config.ArchivedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixArchived, req.Prefix)
+	}
+	if !config.Gapless {
+		return nil, fmt.Errorf("%w: %s", ErrNotGapless, req.Prefix)
+	}
+
+	// Resolve the counter period from the reset rule
+	now := time.Now()
+	period, err := currentPeriod(config.ResetRule, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", req.Prefix, err)
+	}
+
+	// Parse the format template before a counter value is consumed
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", req.Prefix, err)
+	}
+
+	res := &models.Reservation{
+		ReservationID: uuid.New().String(),
+		Tenant:        tenant,
+		Prefix:        req.Prefix,
+		PeriodKey:     period.Key,
+		Status:        models.ReservationStatusReserved,
+		ClientID:      optionalString(req.ClientID),
+		GeneratedBy:   optionalString(req.GeneratedBy),
+		ExpiresAt:     now.Add(lease),
+	}
+
+	// Reuse a released or expired number first
+	reused, err := s.dbRepo.ReuseReservation(ctx, res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to reuse reservation: %w", ErrReservationStoreUnavailable, err)
+	}
+
+	if !reused {
+		counter, err := s.counters.IncrementCounter(ctx, counterName(tenant, req.Prefix, period))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to increment counter: %w", ErrCounterUnavailable, err)
+		}
+
+		s.markPeriodReset(ctx, config, period)
+
+		res.CounterValue = counter
+		res.FullNumber = formatID(tmpl, config, counter, now)
+		if err := s.dbRepo.InsertReservation(ctx, res); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"tenant":  tenant,
+				"prefix":  req.Prefix,
+				"period":  period.Key,
+				"counter": counter,
+			}).Error("Failed to record reservation of a gapless number")
+			s.recordReleased(ctx, res)
+			return nil, fmt.Errorf("%w: failed to record reservation: %w", ErrReservationStoreUnavailable, err)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant":         tenant,
+		"prefix":         req.Prefix,
+		"period":         period.Key,
+		"counter":        res.CounterValue,
+		"full_number":    res.FullNumber,
+		"reservation_id": res.ReservationID,
+		"reused":         reused,
+		"expires_at":     res.ExpiresAt,
+	}).Info("Reserved gapless number")
+
+	return res, nil
+}
+
+// CommitReservation finalizes a reserved number into seq_log
+func (s *SequentialIDService) CommitReservation(ctx context.Context, reservationID string) (_ *models.SequentialID, err error) {
+	defer func(start time.Time) { metrics.ObserveGenerate("commit", start, err) }(time.Now())
+
+	res, err := s.heldReservation(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	seqID := &models.SequentialID{
+		Tenant:      res.Tenant,
+		Prefix:      res.Prefix,
+		Counter:     res.CounterValue,
+		PeriodKey:   res.PeriodKey,
+		FullNumber:  res.FullNumber,
+		GeneratedBy: stringValue(res.GeneratedBy),
+		ClientID:    stringValue(res.ClientID),
+		MessageID:   uuid.New().String(),
+		GeneratedAt: time.Now(),
+	}
+
+	auditLog := &models.AuditLog{
+		Tenant:       seqID.Tenant,
+		Prefix:       seqID.Prefix,
+		CounterValue: seqID.Counter,
+		PeriodKey:    seqID.PeriodKey,
+		FullNumber:   seqID.FullNumber,
+		GeneratedBy:  res.GeneratedBy,
+		ClientID:     res.ClientID,
+		MessageID:    seqID.MessageID,
+		GeneratedAt:  seqID.GeneratedAt,
+	}
+
+	committed, err := s.dbRepo.CommitReservation(ctx, reservationID, auditLog)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to commit reservation: %w", ErrReservationStoreUnavailable, err)
+	}
+	if !committed {
+		return nil, ErrReservationExpired
+	}
+	metrics.IDsIssued.WithLabelValues(res.Prefix).Inc()
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant":         res.Tenant,
+		"prefix":         res.Prefix,
+		"period":         res.PeriodKey,
+		"counter":        res.CounterValue,
+		"full_number":    res.FullNumber,
+		"reservation_id": reservationID,
+	}).Info("Committed gapless number")
+
+	return seqID, nil
+}
+
+// ReleaseReservation returns a reserved number to the pool so the next
+// reservation of the prefix reuses it
+func (s *SequentialIDService) ReleaseReservation(ctx context.Context, reservationID string) error {
+	res, err := s.heldReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	released, err := s.dbRepo.ReleaseReservation(ctx, reservationID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to release reservation: %w", ErrReservationStoreUnavailable, err)
+	}
+	if !released {
+		return ErrReservationExpired
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant":         res.Tenant,
+		"prefix":         res.Prefix,
+		"counter":        res.CounterValue,
+		"reservation_id": reservationID,
+	}).Info("Released gapless number")
+
+	return nil
+}
+
+// recordReleased records the number of a reservation that could not be
+// stored as released, so the next reservation of the prefix reuses it
+// instead of leaving a gap. It must not be cut short by the failing
+// request's context. When it fails too, reconciliation reports the number
+// as missing.
+func (s *SequentialIDService) recordReleased(ctx context.Context, res *models.Reservation) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lostEventsTimeout)
+	defer cancel()
+
+	released := *res
+	released.Status = models.ReservationStatusReleased
+
+	fields := logrus.Fields{
+		"tenant":  res.Tenant,
+		"prefix":  res.Prefix,
+		"period":  res.PeriodKey,
+		"counter": res.CounterValue,
+	}
+	if err := s.dbRepo.InsertReservation(ctx, &released); err != nil {
+		s.logger.WithError(err).WithFields(fields).Error("Failed to return unrecorded gapless number to the pool; left to reconciliation")
+		return
+	}
+	s.logger.WithFields(fields).Warn("Returned unrecorded gapless number to the pool")
+}
+
+// heldReservation loads a reservation whose lease is still held by the caller.
+// Reservations of other tenants are reported as not found.
+func (s *SequentialIDService) heldReservation(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	res, err := s.dbRepo.GetReservation(ctx, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get reservation: %w", ErrReservationStoreUnavailable, err)
+	}
+	if res == nil || res.Tenant != tenantOf(ctx) {
+		return nil, ErrReservationNotFound
+	}
+
+	if err := authorizePrefix(ctx, res.Prefix); err != nil {
+		return nil, err
+	}
+
+	if res.Status != models.ReservationStatusReserved || !time.Now().Before(res.ExpiresAt) {
+		return nil, ErrReservationExpired
+	}
+
+	return res, nil
+}
+
+// leaseDuration resolves the requested lease against the configured limits
+func (s *SequentialIDService) leaseDuration(seconds int) (time.Duration, error) {
+	if seconds == 0 {
+		return s.cfg.Gapless.DefaultLease, nil
+	}
+
+	lease := time.Duration(seconds) * time.Second
+	if seconds < 0 || lease > s.cfg.Gapless.MaxLease {
+		return 0, fmt.Errorf("%w: lease_seconds must be between 1 and %d", ErrInvalidLease, int(s.cfg.Gapless.MaxLease.Seconds()))
+	}
+	return lease, nil
+}
+
+// optionalString returns nil for empty strings
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
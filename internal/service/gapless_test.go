@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newGaplessTestService creates a service with a gapless prefix INV and a
+// regular prefix SG
+func newGaplessTestService() (*SequentialIDService, *memoryCounters, *memoryDatabase) {
+	counters, db := newMemoryCounters(), newMemoryDatabase()
+	db.addConfig(models.PrefixConfig{Prefix: "INV", PaddingLength: 4, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever, Gapless: true})
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	return newTestService(counters, db, nil), counters, db
+}
+
+func reserve(t *testing.T, s *SequentialIDService) *models.Reservation {
+	t.Helper()
+	res, err := s.Reserve(context.Background(), &models.ReserveRequest{Prefix: "INV", ClientID: "billing"})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	return res
+}
+
+func TestReserveCommitRelease(t *testing.T) {
+	s, counters, db := newGaplessTestService()
+	ctx := context.Background()
+
+	first := reserve(t, s)
+	if first.CounterValue != 1 || first.FullNumber != "INV0001" || first.Status != models.ReservationStatusReserved {
+		t.Fatalf("Reserve = %+v, want INV0001 reserved", first)
+	}
+
+	id, err := s.CommitReservation(ctx, first.ReservationID)
+	if err != nil {
+		t.Fatalf("CommitReservation: %v", err)
+	}
+	if id.FullNumber != "INV0001" || id.ClientID != "billing" {
+		t.Errorf("CommitReservation = %+v, want INV0001 of billing", id)
+	}
+	if logs := db.auditLogs(); len(logs) != 1 || logs[0].CounterValue != 1 {
+		t.Errorf("audit logs = %+v, want counter 1", logs)
+	}
+	if _, err := s.CommitReservation(ctx, first.ReservationID); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("second commit = %v, want ErrReservationNotFound", err)
+	}
+
+	second := reserve(t, s)
+	if err := s.ReleaseReservation(ctx, second.ReservationID); err != nil {
+		t.Fatalf("ReleaseReservation: %v", err)
+	}
+	if _, err := s.CommitReservation(ctx, second.ReservationID); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("commit after release = %v, want ErrReservationExpired", err)
+	}
+	if err := s.ReleaseReservation(ctx, second.ReservationID); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("second release = %v, want ErrReservationExpired", err)
+	}
+
+	// The released number is handed out again before the counter advances
+	third := reserve(t, s)
+	if third.CounterValue != 2 || third.ReservationID == second.ReservationID {
+		t.Errorf("Reserve after release = %d (%s), want 2 under a new reservation ID", third.CounterValue, third.ReservationID)
+	}
+	if got := counters.value("INV"); got != 2 {
+		t.Errorf("counter = %d, want 2", got)
+	}
+}
+
+func TestReserveReusesExpiredNumbersLowestFirst(t *testing.T) {
+	s, counters, db := newGaplessTestService()
+
+	held := make([]*models.Reservation, 4)
+	for i := range held {
+		held[i] = reserve(t, s)
+	}
+	db.expireReservation(held[2].ReservationID)
+	db.expireReservation(held[0].ReservationID)
+
+	if _, err := s.CommitReservation(context.Background(), held[0].ReservationID); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("commit of an expired reservation = %v, want ErrReservationExpired", err)
+	}
+
+	for _, want := range []int64{1, 3, 5} {
+		if res := reserve(t, s); res.CounterValue != want {
+			t.Errorf("Reserve = %d, want %d", res.CounterValue, want)
+		}
+	}
+	if got := counters.value("INV"); got != 5 {
+		t.Errorf("counter = %d, want 5", got)
+	}
+}
+
+func TestReserveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.ReserveRequest
+		want error
+	}{
+		{"unknown prefix", models.ReserveRequest{Prefix: "PO"}, ErrPrefixNotFound},
+		{"regular prefix", models.ReserveRequest{Prefix: "SG"}, ErrNotGapless},
+		{"negative lease", models.ReserveRequest{Prefix: "INV", LeaseSeconds: -1}, ErrInvalidLease},
+		{"lease above the maximum", models.ReserveRequest{Prefix: "INV", LeaseSeconds: 2 * 3600}, ErrInvalidLease},
+	}
+	for _, tt := range tests {
+		s, counters, _ := newGaplessTestService()
+		if _, err := s.Reserve(context.Background(), &tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Reserve = %v, want %v", tt.name, err, tt.want)
+		}
+		if got := counters.value(tt.req.Prefix); got != 0 {
+			t.Errorf("%s: counter advanced to %d", tt.name, got)
+		}
+	}
+}
+
+func TestReservationsAreScopedToTheTenant(t *testing.T) {
+	s, _, _ := newGaplessTestService()
+	res := reserve(t, s)
+
+	other := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "mallory", Tenant: "acme"})
+	if _, err := s.CommitReservation(other, res.ReservationID); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("commit by another tenant = %v, want ErrReservationNotFound", err)
+	}
+	if err := s.ReleaseReservation(other, res.ReservationID); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("release by another tenant = %v, want ErrReservationNotFound", err)
+	}
+	if _, err := s.CommitReservation(context.Background(), res.ReservationID); err != nil {
+		t.Errorf("commit by the owning tenant: %v", err)
+	}
+}
+
+// failingInsert fails the first reservation insert, optionally canceling
+// the request's context first as a client disconnect would
+type failingInsert struct {
+	*memoryDatabase
+	cancel func()
+	failed bool
+}
+
+func (d *failingInsert) InsertReservation(ctx context.Context, res *models.Reservation) error {
+	if d.failed {
+		return d.memoryDatabase.InsertReservation(ctx, res)
+	}
+	d.failed = true
+	if d.cancel != nil {
+		d.cancel()
+		return ctx.Err()
+	}
+	return errors.New("pq: connection reset by peer")
+}
+
+func TestReserveReturnsUnrecordedNumbersToThePool(t *testing.T) {
+	tests := []struct {
+		name     string
+		canceled bool
+		kind     Kind
+	}{
+		{"database failure", false, KindUnavailable},
+		{"client disconnect", true, KindCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, counters, db := newGaplessTestService()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			failing := &failingInsert{memoryDatabase: db}
+			if tt.canceled {
+				failing.cancel = cancel
+			}
+			s.dbRepo = failing
+
+			_, err := s.Reserve(ctx, &models.ReserveRequest{Prefix: "INV"})
+			if kind := Classify(err).Kind; kind != tt.kind {
+				t.Fatalf("Reserve = %v (kind %v), want kind %v", err, kind, tt.kind)
+			}
+			if pool := db.pool(); pool[1] != models.ReservationStatusReleased {
+				t.Fatalf("reservations = %v, want counter 1 released", pool)
+			}
+
+			if res := reserve(t, s); res.CounterValue != 1 {
+				t.Errorf("Reserve after the failure = %d, want the unrecorded 1", res.CounterValue)
+			}
+			if got := counters.value("INV"); got != 1 {
+				t.Errorf("counter = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestReservationStoreFailuresClassify(t *testing.T) {
+	tests := []struct {
+		method string
+		call   func(s *SequentialIDService, reservationID string) error
+	}{
+		{"ReuseReservation", func(s *SequentialIDService, _ string) error {
+			_, err := s.Reserve(context.Background(), &models.ReserveRequest{Prefix: "INV"})
+			return err
+		}},
+		{"GetReservation", func(s *SequentialIDService, id string) error {
+			_, err := s.CommitReservation(context.Background(), id)
+			return err
+		}},
+		{"CommitReservation", func(s *SequentialIDService, id string) error {
+			_, err := s.CommitReservation(context.Background(), id)
+			return err
+		}},
+		{"ReleaseReservation", func(s *SequentialIDService, id string) error {
+			return s.ReleaseReservation(context.Background(), id)
+		}},
+	}
+	for _, tt := range tests {
+		s, _, db := newGaplessTestService()
+		res := reserve(t, s)
+		db.failWith(tt.method, errors.New("pq: connection refused"))
+		if err := tt.call(s, res.ReservationID); !errors.Is(err, ErrReservationStoreUnavailable) {
+			t.Errorf("%s failing: error = %v, want ErrReservationStoreUnavailable", tt.method, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxIdempotencyKeyLength bounds client-supplied idempotency keys
+const MaxIdempotencyKeyLength = 255
+
+// idempotencyPendingTTL bounds how long a key stays reserved by a request
+// that never completes, e.g. because the instance crashed
+const idempotencyPendingTTL = time.Minute
+
+// Idempotency errors
+var (
+	// ErrInvalidIdempotencyKey is returned for oversized keys
+	ErrInvalidIdempotencyKey = newError(KindInvalidArgument, "INVALID_IDEMPOTENCY_KEY", "invalid idempotency key")
+	// ErrIdempotencyConflict is returned when a key is reused with different parameters
+	ErrIdempotencyConflict = newError(KindUnprocessable, "IDEMPOTENCY_KEY_REUSED", "idempotency key reused with different parameters")
+	// ErrIdempotencyInProgress is returned while the first request with a key is still running
+	ErrIdempotencyInProgress = newError(KindAborted, "IDEMPOTENCY_KEY_IN_PROGRESS", "request with this idempotency key is still in progress")
+)
+
+// idempotencyParams identifies a request; replays must match exactly
+type idempotencyParams struct {
+	Operation     string `json:"operation"`
+	Prefix        string `json:"prefix"`
+	Count         int    `json:"count,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	GeneratedBy   string `json:"generated_by,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// idempotencyRecord is stored in the counter backend per idempotency key
+type idempotencyRecord struct {
+	Fingerprint string          `json:"fingerprint"`
+	Done        bool            `json:"done"`
+	Response    json.RawMessage `json:"response,omitempty"`
+}
+
+// idempotent runs generate once per idempotency key and replays its result
+// for later requests with the same key and parameters. An empty key runs
+// generate unconditionally. Failed requests release the key so they can be
+// retried.
+func idempotent[T any](s *SequentialIDService, ctx context.Context, key string, params idempotencyParams, generate func() (*T, error)) (*T, error) {
+	if key == "" {
+		return generate()
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: must be at most %d characters", ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+
+	fingerprint, err := idempotencyFingerprint(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are scoped to the caller so clients cannot replay each other's IDs
+	storeKey := scopedIdempotencyKey(ctx, key)
+	pending, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	reserved, existing, err := s.counters.ReserveIdempotencyKey(ctx, storeKey, pending, idempotencyPendingTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to reserve idempotency key: %w", ErrCounterUnavailable, err)
+	}
+	if !reserved {
+		return replay[T](existing, fingerprint)
+	}
+
+	result, err := generate()
+	if err != nil {
+		if releaseErr := s.counters.ReleaseIdempotencyKey(ctx, storeKey); releaseErr != nil {
+			s.logger.WithError(releaseErr).WithField("operation", params.Operation).Warn("Failed to release idempotency key")
+		}
+		return nil, err
+	}
+
+	response, err := json.Marshal(result)
+	if err == nil {
+		var done []byte
+		done, err = json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Done: true, Response: response})
+		if err == nil {
+			err = s.counters.StoreIdempotencyKey(ctx, storeKey, done, s.cfg.Idempotency.TTL)
+		}
+	}
+	if err != nil {
+		// The IDs were issued; return them even though replays will be refused
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"operation": params.Operation,
+			"prefix":    params.Prefix,
+		}).Error("Failed to store idempotent response")
+	}
+
+	return result, nil
+}
+
+// replay returns the stored response for a completed request
+func replay[T any](existing []byte, fingerprint string) (*T, error) {
+	var record idempotencyRecord
+	if err := json.Unmarshal(existing, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyConflict
+	}
+	if !record.Done {
+		return nil, ErrIdempotencyInProgress
+	}
+
+	var result T
+	if err := json.Unmarshal(record.Response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+	}
+	return &result, nil
+}
+
+// idempotencyFingerprint hashes the request parameters
+func idempotencyFingerprint(params idempotencyParams) (string, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal idempotency parameters: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// scopedIdempotencyKey derives the storage key from the caller, its tenant
+// and the client-supplied key. Keys of the default tenant are derived as
+// before tenants existed so stored responses stay valid.
+func scopedIdempotencyKey(ctx context.Context, key string) string {
+	subject := ""
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		subject = principal.Subject
+	}
+	if tenant := tenantOf(ctx); tenant != models.DefaultTenant {
+		subject = tenant + "\x00" + subject
+	}
+	sum := sha256.Sum256([]byte(subject + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryIdempotencyStore keeps idempotency records in memory. Counter
+// methods are not implemented; the tests in this file only use the
+// idempotency methods.
+type memoryIdempotencyStore struct {
+	repository.CounterBackend
+
+	mu         sync.Mutex
+	records    map[string][]byte
+	ttls       map[string]time.Duration
+	reserveErr error
+	storeErr   error
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (m *memoryIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key string, record []byte, ttl time.Duration) (bool, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reserveErr != nil {
+		return false, nil, m.reserveErr
+	}
+	if existing, ok := m.records[key]; ok {
+		return false, existing, nil
+	}
+	m.records[key], m.ttls[key] = record, ttl
+	return true, nil, nil
+}
+
+func (m *memoryIdempotencyStore) StoreIdempotencyKey(_ context.Context, key string, record []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+	m.records[key], m.ttls[key] = record, ttl
+	return nil
+}
+
+func (m *memoryIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	delete(m.ttls, key)
+	return nil
+}
+
+func newIdempotencyTestService(store *memoryIdempotencyStore) *SequentialIDService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &SequentialIDService{
+		counters: store,
+		cfg:      &config.Config{Idempotency: config.IdempotencyConfig{TTL: 24 * time.Hour}},
+		logger:   logger,
+	}
+}
+
+// issuer counts how often generate runs and issues increasing counters
+type issuer struct{ calls int64 }
+
+func (i *issuer) generate() (*models.SequentialID, error) {
+	i.calls++
+	return &models.SequentialID{FullNumber: fmt.Sprintf("SG%06d", i.calls), Prefix: "SG", Counter: i.calls}, nil
+}
+
+func TestIdempotentReplaysCompletedRequests(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	s := newIdempotencyTestService(store)
+	ctx := context.Background()
+	params := idempotencyParams{Operation: "get_next", Prefix: "SG", ClientID: "pos-1"}
+	var gen issuer
+
+	first, err := idempotent(s, ctx, "order-1", params, gen.generate)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		got, err := idempotent(s, ctx, "order-1", params, gen.generate)
+		if err != nil {
+			t.Fatalf("replay %d: %v", i, err)
+		}
+		if got.FullNumber != first.FullNumber || got.Counter != first.Counter {
+			t.Errorf("replay %d = %+v, want %+v", i, got, first)
+		}
+	}
+	if gen.calls != 1 {
+		t.Errorf("generate ran %d times, want 1", gen.calls)
+	}
+
+	key := scopedIdempotencyKey(ctx, "order-1")
+	if ttl := store.ttls[key]; ttl != s.cfg.Idempotency.TTL {
+		t.Errorf("completed record stored for %v, want %v", ttl, s.cfg.Idempotency.TTL)
+	}
+}
+
+func TestIdempotentWithoutKeyAlwaysGenerates(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	s := newIdempotencyTestService(store)
+	var gen issuer
+
+	for i := 0; i < 3; i++ {
+		if _, err := idempotent(s, context.Background(), "", idempotencyParams{Operation: "get_next", Prefix: "SG"}, gen.generate); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if gen.calls != 3 || len(store.records) != 0 {
+		t.Errorf("generate ran %d times with %d records, want 3 and none", gen.calls, len(store.records))
+	}
+}
+
+func TestIdempotentErrors(t *testing.T) {
+	params := idempotencyParams{Operation: "get_batch", Prefix: "SG", Count: 10}
+	tests := []struct {
+		name  string
+		setup func(s *SequentialIDService, store *memoryIdempotencyStore)
+		key   string
+		want  error
+		kind  Kind
+	}{
+		{
+			name: "oversized key",
+			key:  strings.Repeat("k", MaxIdempotencyKeyLength+1),
+			want: ErrInvalidIdempotencyKey,
+			kind: KindInvalidArgument,
+		},
+		{
+			name: "key reused with other parameters",
+			setup: func(s *SequentialIDService, _ *memoryIdempotencyStore) {
+				other := params
+				other.Count = 5
+				var gen issuer
+				if _, err := idempotent(s, context.Background(), "batch-1", other, gen.generate); err != nil {
+					t.Fatalf("setup: %v", err)
+				}
+			},
+			key:  "batch-1",
+			want: ErrIdempotencyConflict,
+			kind: KindUnprocessable,
+		},
+		{
+			name: "reserve fails",
+			setup: func(_ *SequentialIDService, store *memoryIdempotencyStore) {
+				store.reserveErr = errors.New("connection refused")
+			},
+			key:  "batch-1",
+			want: ErrCounterUnavailable,
+			kind: KindUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotencyStore()
+			s := newIdempotencyTestService(store)
+			if tt.setup != nil {
+				tt.setup(s, store)
+			}
+			var gen issuer
+			_, err := idempotent(s, context.Background(), tt.key, params, gen.generate)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if kind := Classify(err).Kind; kind != tt.kind {
+				t.Errorf("Classify(%v).Kind = %v, want %v", err, kind, tt.kind)
+			}
+			if gen.calls != 0 {
+				t.Errorf("generate ran %d times, want 0", gen.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotentRefusesWhileInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	s := newIdempotencyTestService(store)
+	ctx := context.Background()
+	params := idempotencyParams{Operation: "get_next", Prefix: "SG"}
+
+	var concurrent error
+	_, err := idempotent(s, ctx, "order-1", params, func() (*models.SequentialID, error) {
+		key := scopedIdempotencyKey(ctx, "order-1")
+		if ttl := store.ttls[key]; ttl != idempotencyPendingTTL {
+			t.Errorf("pending record stored for %v, want %v", ttl, idempotencyPendingTTL)
+		}
+		var gen issuer
+		_, concurrent = idempotent(s, ctx, "order-1", params, gen.generate)
+		return &models.SequentialID{Prefix: "SG", Counter: 1}, nil
+	})
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if !errors.Is(concurrent, ErrIdempotencyInProgress) {
+		t.Errorf("concurrent call error = %v, want ErrIdempotencyInProgress", concurrent)
+	}
+}
+
+func TestIdempotentReleasesKeyOnFailure(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	s := newIdempotencyTestService(store)
+	ctx := context.Background()
+	params := idempotencyParams{Operation: "get_next", Prefix: "SG"}
+
+	_, err := idempotent(s, ctx, "order-1", params, func() (*models.SequentialID, error) {
+		return nil, ErrCounterUnavailable
+	})
+	if !errors.Is(err, ErrCounterUnavailable) {
+		t.Fatalf("error = %v, want ErrCounterUnavailable", err)
+	}
+
+	var gen issuer
+	got, err := idempotent(s, ctx, "order-1", params, gen.generate)
+	if err != nil || got.Counter != 1 || gen.calls != 1 {
+		t.Fatalf("retry = %+v, %v after %d generations; want a fresh ID", got, err, gen.calls)
+	}
+}
+
+func TestIdempotentReturnsResultWhenStoreFails(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	store.storeErr = errors.New("connection reset")
+	s := newIdempotencyTestService(store)
+	var gen issuer
+
+	got, err := idempotent(s, context.Background(), "order-1", idempotencyParams{Operation: "get_next", Prefix: "SG"}, gen.generate)
+	if err != nil || got == nil || got.Counter != 1 {
+		t.Fatalf("idempotent = %+v, %v; want the issued ID", got, err)
+	}
+}
+
+func TestScopedIdempotencyKey(t *testing.T) {
+	withPrincipal := func(subject, tenant string) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Tenant: tenant})
+	}
+	anonymous := scopedIdempotencyKey(context.Background(), "k")
+	alice := scopedIdempotencyKey(withPrincipal("alice", ""), "k")
+	aliceDefault := scopedIdempotencyKey(withPrincipal("alice", models.DefaultTenant), "k")
+	aliceBilling := scopedIdempotencyKey(withPrincipal("alice", "billing"), "k")
+	bob := scopedIdempotencyKey(withPrincipal("bob", ""), "k")
+
+	if alice != aliceDefault {
+		t.Errorf("default tenant key %q differs from the untenanted key %q", aliceDefault, alice)
+	}
+	keys := map[string]string{"anonymous": anonymous, "alice": alice, "alice@billing": aliceBilling, "bob": bob}
+	seen := make(map[string]string)
+	for who, key := range keys {
+		if other, ok := seen[key]; ok {
+			t.Errorf("%s and %s share the idempotency key %q", who, other, key)
+		}
+		seen[key] = who
+	}
+	if scopedIdempotencyKey(withPrincipal("alice", ""), "k2") == alice {
+		t.Error("different client keys map to the same idempotency key")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	fingerprint, err := idempotencyFingerprint(idempotencyParams{Operation: "get_next", Prefix: "SG"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		record string
+		want   error
+	}{
+		{"completed", `{"fingerprint":"` + fingerprint + `","done":true,"response":{"prefix":"SG","counter":7}}`, nil},
+		{"pending", `{"fingerprint":"` + fingerprint + `"}`, ErrIdempotencyInProgress},
+		{"other parameters", `{"fingerprint":"0000","done":true,"response":{}}`, ErrIdempotencyConflict},
+	}
+	for _, tt := range tests {
+		got, err := replay[models.SequentialID]([]byte(tt.record), fingerprint)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: replay error = %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if tt.want == nil && (got.Prefix != "SG" || got.Counter != 7) {
+			t.Errorf("%s: replay = %+v, want SG 7", tt.name, got)
+		}
+	}
+	if _, err := replay[models.SequentialID]([]byte("not json"), fingerprint); err == nil {
+		t.Error("replay of a corrupt record succeeded")
+	}
+}
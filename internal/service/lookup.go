@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LookupID resolves a full number of the caller's tenant back to the audit
+// entry, batch and reset history it belongs to. Numbers handed out by Redis
+// whose audit entry has not been written yet are reported as issued but not
+// audited. It returns nil when the number was never issued.
+func (s *SequentialIDService) LookupID(ctx context.Context, fullNumber string) (*models.IDLookup, error) {
+	tenant := tenantOf(ctx)
+	logs, err := s.dbRepo.GetAuditLogsByFullNumber(ctx, tenant, fullNumber)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to look up %s: %w", ErrAuditStoreUnavailable, fullNumber, err)
+	}
+
+	var lookup *models.IDLookup
+	if len(logs) > 0 {
+		lookup, err = s.auditedLookup(ctx, &logs[0])
+	} else {
+		lookup, err = s.pendingLookup(ctx, tenant, fullNumber)
+	}
+	if err != nil || lookup == nil {
+		return nil, err
+	}
+
+	resets, err := s.dbRepo.GetResetLogs(ctx, lookup.Tenant, lookup.Prefix, lookup.PeriodKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get reset history: %w", ErrAuditStoreUnavailable, err)
+	}
+	if resets == nil {
+		resets = []models.ResetLog{}
+	}
+	lookup.Resets = resets
+
+	return lookup, nil
+}
+
+// auditedLookup builds the lookup result for an ID recorded in seq_log
+func (s *SequentialIDService) auditedLookup(ctx context.Context, log *models.AuditLog) (*models.IDLookup, error) {
+	status := models.IDStatusAudited
+	if log.Status == models.AuditStatusLost {
+		status = models.IDStatusLost
+	}
+
+	lookup := &models.IDLookup{
+		FullNumber:   log.FullNumber,
+		Status:       status,
+		Tenant:       log.Tenant,
+		Prefix:       log.Prefix,
+		PeriodKey:    log.PeriodKey,
+		CounterValue: log.CounterValue,
+		Audit:        log,
+	}
+
+	if log.BatchID != nil && *log.BatchID != "" {
+		batch, err := s.dbRepo.GetBatchSummary(ctx, *log.BatchID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get batch: %w", ErrAuditStoreUnavailable, err)
+		}
+		lookup.Batch = batch
+	}
+
+	return lookup, nil
+}
+
+// pendingLookup matches a number without an audit entry against the
+// configured templates and reports it as issued when the corresponding
+// Redis counter has already passed its value
+func (s *SequentialIDService) pendingLookup(ctx context.Context, tenant, fullNumber string) (*models.IDLookup, error) {
+	configs, err := s.dbRepo.GetTenantPrefixConfigs(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix configs: %w", ErrConfigStoreUnavailable, err)
+	}
+
+	now := time.Now()
+	for i := range configs {
+		config := &configs[i]
+
+		tmpl, err := idformat.Parse(config.FormatTemplate)
+		if err != nil {
+			s.logger.WithError(err).WithField("prefix", config.Prefix).Warn("Skipping prefix with invalid format template")
+			continue
+		}
+
+		match, ok := tmpl.Match(fullNumber, config.Prefix, config.PaddingLength)
+		if !ok || match.Counter < 1 {
+			continue
+		}
+
+		period, err := matchedPeriod(config.ResetRule, match, now)
+		if err != nil {
+			s.logger.WithError(err).WithField("prefix", config.Prefix).Warn("Skipping prefix with invalid reset rule")
+			continue
+		}
+
+		current, err := s.counters.GetCounter(ctx, counterName(config.Tenant, config.Prefix, period))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get counter: %w", ErrCounterUnavailable, err)
+		}
+		if match.Counter > current {
+			continue
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"full_number": fullNumber,
+			"tenant":      config.Tenant,
+			"prefix":      config.Prefix,
+			"period":      period.Key,
+			"counter":     match.Counter,
+		}).Info("ID issued but not yet audited")
+
+		return &models.IDLookup{
+			FullNumber:   fullNumber,
+			Status:       models.IDStatusIssuedNotAudited,
+			Tenant:       config.Tenant,
+			Prefix:       config.Prefix,
+			PeriodKey:    period.Key,
+			CounterValue: match.Counter,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// matchedPeriod derives the counter period of a matched ID from the date
+// parts it encodes. IDs that do not encode enough of the date for the reset
+// rule are attributed to the current period.
+func matchedPeriod(resetRule string, match idformat.Match, now time.Time) (counterPeriod, error) {
+	now = now.UTC()
+	at := now
+
+	switch resetRule {
+	case models.ResetRuleYearly:
+		if match.Year != 0 {
+			at = time.Date(match.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		}
+	case models.ResetRuleMonthly:
+		if match.Year != 0 && match.Month != 0 {
+			at = time.Date(match.Year, time.Month(match.Month), 1, 0, 0, 0, 0, time.UTC)
+		}
+	case models.ResetRuleDaily:
+		if match.Year != 0 && match.Month != 0 && match.Day != 0 {
+			at = time.Date(match.Year, time.Month(match.Month), match.Day, 0, 0, 0, 0, time.UTC)
+		}
+	}
+
+	return currentPeriod(resetRule, at)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrAuditUnavailable is returned when the audit events of issued IDs could
+// be neither stored in the outbox nor published
+var ErrAuditUnavailable = newError(KindUnavailable, "AUDIT_UNAVAILABLE", "audit trail unavailable")
+
+// lostEventsTimeout bounds recording the placeholders of unrecorded events,
+// which must not be cut short by the failing request's context
+const lostEventsTimeout = 5 * time.Second
+
+// recordEvents durably stores audit events in the outbox before the IDs are
+// returned. When the outbox cannot be written the events are published
+// directly; an error is returned only if an event could be neither stored
+// nor published, so no ID is handed out without an audit record on the way.
+// The counter values of the failed call are already consumed, so all of its
+// events are recorded as "lost" placeholders before the error is returned.
+// That includes the events published before the failure: their placeholders
+// carry the events' message IDs, which keeps the worker from recording IDs
+// the caller never received as issued.
+func (s *SequentialIDService) recordEvents(ctx context.Context, prefix string, events []*models.Event) error {
+	outboxErr := s.dbRepo.InsertOutboxEvents(ctx, events)
+	if outboxErr == nil {
+		return nil
+	}
+
+	s.logger.WithError(outboxErr).WithFields(logrus.Fields{
+		"prefix": prefix,
+		"events": len(events),
+	}).Warn("Failed to write audit outbox, publishing directly")
+
+	for i, event := range events {
+		if err := s.rabbitRepo.PublishEvent(ctx, event); err != nil {
+			metrics.PublishFailures.WithLabelValues(prefix).Inc()
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"prefix":      prefix,
+				"counter":     event.Counter,
+				"full_number": event.FullNumber,
+				"message_id":  event.MessageID,
+			}).Error("Failed to publish audit event")
+			s.recordLostEvents(ctx, prefix, events)
+			return fmt.Errorf("%w: failed to record audit event: %v; %w (%d of %d events published)",
+				ErrAuditUnavailable, outboxErr, err, i, len(events))
+		}
+	}
+
+	return nil
+}
+
+// recordLostEvents writes "lost" placeholders for the counter values of
+// events whose IDs were not returned. When that fails too the values are
+// left to reconciliation, which backfills missing values; events already
+// published are then recorded as issued.
+func (s *SequentialIDService) recordLostEvents(ctx context.Context, prefix string, events []*models.Event) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lostEventsTimeout)
+	defer cancel()
+
+	logs := make([]models.AuditLog, len(events))
+	for i, event := range events {
+		logs[i] = models.AuditLog{
+			Tenant:        event.Tenant,
+			Prefix:        event.Prefix,
+			CounterValue:  event.Counter,
+			PeriodKey:     event.PeriodKey,
+			FullNumber:    event.FullNumber,
+			GeneratedBy:   optionalString(event.GeneratedBy),
+			ClientID:      optionalString(event.ClientID),
+			CorrelationID: optionalString(event.CorrelationID),
+			MessageID:     event.MessageID,
+			GeneratedAt:   event.GeneratedAt,
+			BatchID:       optionalString(event.BatchID),
+			Status:        models.AuditStatusLost,
+		}
+	}
+
+	fields := logrus.Fields{
+		"prefix": prefix,
+		"from":   events[0].Counter,
+		"to":     events[len(events)-1].Counter,
+	}
+	if _, err := s.dbRepo.InsertLostAuditLogs(ctx, logs); err != nil {
+		s.logger.WithError(err).WithFields(fields).Error("Failed to record unaudited counter values as lost; left to reconciliation")
+		return
+	}
+	s.logger.WithFields(fields).Warn("Recorded unaudited counter values as lost")
+}
+
+// OutboxRelay drains the audit outbox into RabbitMQ. Several relays may run
+// against the same database; claimed events are leased so each is published
+// by one relay at a time. Publishing is at-least-once; the worker ignores
+// events already recorded in seq_log.
+type OutboxRelay struct {
+	dbRepo     repository.Database
+	rabbitRepo repository.EventBroker
+	cfg        config.OutboxConfig
+	logger     *logrus.Logger
+}
+
+// NewOutboxRelay creates a new outbox relay
+func NewOutboxRelay(
+	dbRepo repository.Database,
+	rabbitRepo repository.EventBroker,
+	cfg config.OutboxConfig,
+	logger *logrus.Logger,
+) *OutboxRelay {
+	return &OutboxRelay{
+		dbRepo:     dbRepo,
+		rabbitRepo: rabbitRepo,
+		cfg:        cfg,
+		logger:     logger,
+	}
+}
+
+// Run relays outbox events until the context is cancelled. Full batches are
+// followed immediately by the next one; otherwise the relay waits for the
+// poll interval.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	r.logger.Info("Outbox relay started")
+
+	for {
+		n, err := r.relayBatch(ctx)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to relay outbox events")
+		}
+
+		if err != nil || n < r.cfg.BatchSize {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.cfg.PollInterval):
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// relayBatch publishes one batch of due events and returns its size
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	entries, err := r.dbRepo.ClaimOutboxEvents(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	published := make([]int64, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		if err := r.publish(ctx, entry); err != nil {
+			metrics.OutboxRelayFailures.WithLabelValues(entry.Prefix).Inc()
+			r.reschedule(ctx, entry, err)
+			continue
+		}
+		metrics.OutboxPublished.WithLabelValues(entry.Prefix).Inc()
+		published = append(published, entry.ID)
+	}
+
+	// Rows left behind on failure are published again once the lease expires
+	if len(published) > 0 {
+		if err := r.dbRepo.DeleteOutboxEvents(ctx, published); err != nil {
+			return len(entries), err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// publish sends a single outbox entry to RabbitMQ
+func (r *OutboxRelay) publish(ctx context.Context, entry *models.OutboxEntry) error {
+	var event models.Event
+	if err := json.Unmarshal(entry.Payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal outbox event: %w", err)
+	}
+	return r.rabbitRepo.PublishEvent(ctx, &event)
+}
+
+// reschedule postpones a failed entry with exponential backoff
+func (r *OutboxRelay) reschedule(ctx context.Context, entry *models.OutboxEntry, cause error) {
+	delay := outboxBackoff(entry.Attempts, r.cfg.PollInterval, r.cfg.MaxBackoff)
+
+	logger := r.logger.WithError(cause).WithFields(logrus.Fields{
+		"prefix":     entry.Prefix,
+		"message_id": entry.MessageID,
+		"attempts":   entry.Attempts + 1,
+		"retry_in":   delay.String(),
+	})
+	logger.Warn("Failed to relay outbox event")
+
+	if err := r.dbRepo.RescheduleOutboxEvent(ctx, entry.ID, time.Now().Add(delay), cause.Error()); err != nil {
+		logger.WithError(err).Error("Failed to reschedule outbox event")
+	}
+}
+
+// outboxBackoff doubles the delay with every failed attempt up to limit
+func outboxBackoff(attempts int, base, limit time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
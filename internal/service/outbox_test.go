@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newOutboxTestService creates a service with prefix SG whose audit outbox
+// cannot be written
+func newOutboxTestService() (*SequentialIDService, *memoryDatabase, *memoryBroker) {
+	db, broker := newMemoryDatabase(), newMemoryBroker()
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	db.failWith("InsertOutboxEvents", errors.New("pq: connection refused"))
+	return newTestService(newMemoryCounters(), db, broker), db, broker
+}
+
+func TestRecordEventsPublishesWhenTheOutboxIsDown(t *testing.T) {
+	s, db, broker := newOutboxTestService()
+
+	batch, err := s.GetNextBatch(context.Background(), &models.BatchRequest{Prefix: "SG", Count: 3})
+	if err != nil {
+		t.Fatalf("GetNextBatch: %v", err)
+	}
+	published := broker.publishedEvents()
+	if len(published) != 3 {
+		t.Fatalf("published %d events, want 3", len(published))
+	}
+	for i, event := range published {
+		if event.MessageID != batch.IDs[i].MessageID {
+			t.Errorf("event %d has message ID %s, want %s", i, event.MessageID, batch.IDs[i].MessageID)
+		}
+	}
+	if logs := db.auditLogs(); len(logs) != 0 {
+		t.Errorf("audit logs = %+v, want no placeholders", logs)
+	}
+}
+
+func TestRecordEventsVoidsPartiallyPublishedCalls(t *testing.T) {
+	s, db, broker := newOutboxTestService()
+	broker.failAfter, broker.publishErr = 1, errors.New("amqp: channel closed")
+
+	_, err := s.GetNextBatch(context.Background(), &models.BatchRequest{Prefix: "SG", Count: 3})
+	if !errors.Is(err, ErrAuditUnavailable) {
+		t.Fatalf("GetNextBatch = %v, want ErrAuditUnavailable", err)
+	}
+	published := broker.publishedEvents()
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1 before the failure", len(published))
+	}
+
+	// Every value of the failed batch is a placeholder, the published one
+	// under the message ID of its event
+	logs := db.auditLogs()
+	if len(logs) != 3 {
+		t.Fatalf("audit logs = %+v, want placeholders for 1..3", logs)
+	}
+	for i, log := range logs {
+		if log.CounterValue != int64(i+1) || log.Status != models.AuditStatusLost {
+			t.Errorf("placeholder %d = counter %d %s, want counter %d lost", i, log.CounterValue, log.Status, i+1)
+		}
+	}
+	if logs[0].MessageID != published[0].MessageID {
+		t.Errorf("placeholder of counter 1 has message ID %s, want the published %s", logs[0].MessageID, published[0].MessageID)
+	}
+
+	// The worker receiving the published event leaves the value lost
+	db.issue(published[0])
+	if logs := db.auditLogs(); logs[0].Status != models.AuditStatusLost {
+		t.Errorf("counter 1 is %s after its event arrived, want lost", logs[0].Status)
+	}
+}
+
+func TestLostPlaceholdersVoidEventsAuditedFirst(t *testing.T) {
+	s, db, broker := newOutboxTestService()
+	broker.failAfter, broker.publishErr = 1, errors.New("amqp: channel closed")
+
+	// The worker records the published event before the placeholders are
+	// written
+	s.rabbitRepo = &issuingBroker{memoryBroker: broker, db: db}
+
+	_, err := s.GetNextBatch(context.Background(), &models.BatchRequest{Prefix: "SG", Count: 2})
+	if !errors.Is(err, ErrAuditUnavailable) {
+		t.Fatalf("GetNextBatch = %v, want ErrAuditUnavailable", err)
+	}
+	logs := db.auditLogs()
+	if len(logs) != 2 {
+		t.Fatalf("audit logs = %+v, want entries for 1..2", logs)
+	}
+	for _, log := range logs {
+		if log.Status != models.AuditStatusLost {
+			t.Errorf("counter %d is %s, want lost", log.CounterValue, log.Status)
+		}
+	}
+}
+
+// issuingBroker records published events in seq_log right away, as a fast
+// worker would
+type issuingBroker struct {
+	*memoryBroker
+	db *memoryDatabase
+}
+
+func (b *issuingBroker) PublishEvent(ctx context.Context, event *models.Event) error {
+	if err := b.memoryBroker.PublishEvent(ctx, event); err != nil {
+		return err
+	}
+	b.db.issue(event)
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// counterPeriod identifies the reset window a counter value belongs to
+type counterPeriod struct {
+	Key   string    // Period key, e.g. "2026", "202610" or "20261016" ("" for never)
+	Start time.Time // Start of the period (zero for never)
+}
+
+// currentPeriod derives the counter period for a reset rule at the given time.
+// Period boundaries are evaluated in UTC so every instance agrees on them.
+func currentPeriod(resetRule string, now time.Time) (counterPeriod, error) {
+	now = now.UTC()
+
+	switch resetRule {
+	case "", models.ResetRuleNever:
+		return counterPeriod{}, nil
+	case models.ResetRuleDaily:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return counterPeriod{Key: start.Format("20060102"), Start: start}, nil
+	case models.ResetRuleMonthly:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return counterPeriod{Key: start.Format("200601"), Start: start}, nil
+	case models.ResetRuleYearly:
+		start := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return counterPeriod{Key: start.Format("2006"), Start: start}, nil
+	default:
+		return counterPeriod{}, fmt.Errorf("unsupported reset rule %q", resetRule)
+	}
+}
+
+// validateResetRule checks that a reset rule is one of the supported values
+func validateResetRule(resetRule string) error {
+	if _, err := currentPeriod(resetRule, time.Now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// counterName returns the name of the counter holding values for a prefix
+// of a tenant within a period. The default tenant and prefixes that never
+// reset keep the plain name so existing Redis keys (seq:<prefix>) remain
+// valid; other tenants are namespaced as seq:<tenant>:<prefix>.
+func counterName(tenant, prefix string, period counterPeriod) string {
+	name := prefix
+	if tenant != models.DefaultTenant {
+		name = tenant + ":" + prefix
+	}
+	if period.Key == "" {
+		return name
+	}
+	return name + ":" + period.Key
+}
+
+// needsResetMark reports whether last_reset_at has to be advanced to the
+// start of the current period
+func needsResetMark(config *models.PrefixConfig, period counterPeriod) bool {
+	if period.Key == "" {
+		return false
+	}
+	return config.LastResetAt == nil || config.LastResetAt.Before(period.Start)
+}
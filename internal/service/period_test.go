@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+func TestCurrentPeriod(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		rule    string
+		now     time.Time
+		wantKey string
+		start   time.Time
+	}{
+		{models.ResetRuleNever, time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), "", time.Time{}},
+		{"", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), "", time.Time{}},
+		{models.ResetRuleDaily, time.Date(2026, 10, 16, 23, 59, 59, 999999999, time.UTC), "20261016", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleDaily, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), "20261017", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleMonthly, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), "202402", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleMonthly, time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), "202612", time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleYearly, time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), "2026", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleYearly, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "2027", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Boundaries are evaluated in UTC: New Year's morning in Jakarta is
+		// still the previous day, month and year
+		{models.ResetRuleDaily, time.Date(2027, 1, 1, 6, 0, 0, 0, jakarta), "20261231", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleMonthly, time.Date(2027, 1, 1, 6, 0, 0, 0, jakarta), "202612", time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleYearly, time.Date(2027, 1, 1, 6, 0, 0, 0, jakarta), "2026", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{models.ResetRuleYearly, time.Date(2027, 1, 1, 7, 0, 0, 0, jakarta), "2027", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := currentPeriod(tt.rule, tt.now)
+		if err != nil {
+			t.Fatalf("currentPeriod(%q, %v): %v", tt.rule, tt.now, err)
+		}
+		if got.Key != tt.wantKey || !got.Start.Equal(tt.start) {
+			t.Errorf("currentPeriod(%q, %v) = %q from %v, want %q from %v", tt.rule, tt.now, got.Key, got.Start, tt.wantKey, tt.start)
+		}
+	}
+}
+
+func TestValidateResetRule(t *testing.T) {
+	for _, rule := range []string{"", models.ResetRuleNever, models.ResetRuleDaily, models.ResetRuleMonthly, models.ResetRuleYearly} {
+		if err := validateResetRule(rule); err != nil {
+			t.Errorf("validateResetRule(%q) = %v, want nil", rule, err)
+		}
+	}
+	for _, rule := range []string{"weekly", "YEARLY", " daily"} {
+		if err := validateResetRule(rule); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("validateResetRule(%q) = %v, want ErrInvalidConfig", rule, err)
+		}
+	}
+}
+
+func TestCounterName(t *testing.T) {
+	yearly := counterPeriod{Key: "2026"}
+	tests := []struct {
+		tenant string
+		prefix string
+		period counterPeriod
+		want   string
+	}{
+		{models.DefaultTenant, "SG", counterPeriod{}, "SG"},
+		{models.DefaultTenant, "INV", yearly, "INV:2026"},
+		{"billing", "SG", counterPeriod{}, "billing:SG"},
+		{"billing", "INV", yearly, "billing:INV:2026"},
+	}
+	for _, tt := range tests {
+		if got := counterName(tt.tenant, tt.prefix, tt.period); got != tt.want {
+			t.Errorf("counterName(%q, %q, %q) = %q, want %q", tt.tenant, tt.prefix, tt.period.Key, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsResetMark(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, at := start.Add(-time.Second), start
+	tests := []struct {
+		lastReset *time.Time
+		period    counterPeriod
+		want      bool
+	}{
+		{nil, counterPeriod{}, false},
+		{nil, counterPeriod{Key: "2026", Start: start}, true},
+		{&before, counterPeriod{Key: "2026", Start: start}, true},
+		{&at, counterPeriod{Key: "2026", Start: start}, false},
+	}
+	for _, tt := range tests {
+		config := &models.PrefixConfig{LastResetAt: tt.lastReset}
+		if got := needsResetMark(config, tt.period); got != tt.want {
+			t.Errorf("needsResetMark(%v, %q) = %v, want %v", tt.lastReset, tt.period.Key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Prefix lifecycle errors
+var (
+	// ErrPrefixNotFound is returned for prefixes without a configuration
+	ErrPrefixNotFound = newError(KindNotFound, "PREFIX_NOT_CONFIGURED", "prefix not found")
+	// ErrPrefixExists is returned when creating a prefix another request just created
+	ErrPrefixExists = newError(KindConflict, "PREFIX_EXISTS", "prefix already exists")
+	// ErrPrefixArchived is returned when issuing IDs of an archived prefix
+	ErrPrefixArchived = newError(KindConflict, "PREFIX_ARCHIVED", "prefix is archived")
+	// ErrPrefixInUse is returned when deleting a prefix with issued IDs without force
+	ErrPrefixInUse = newError(KindConflict, "PREFIX_IN_USE", "prefix has issued IDs; delete requires force and a reason")
+	// ErrInvalidPrefixDelete is returned for incomplete delete requests
+	ErrInvalidPrefixDelete = newError(KindInvalidArgument, "INVALID_PREFIX_DELETE", "invalid prefix delete")
+)
+
+// ListPrefixes returns the prefixes configured for the caller's tenant with
+// their current counter and the time of their last issued ID
+func (s *SequentialIDService) ListPrefixes(ctx context.Context, includeArchived bool) ([]models.PrefixSummary, error) {
+	tenant := tenantOf(ctx)
+	configs, err := s.dbRepo.GetTenantPrefixConfigs(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix configs: %w", ErrConfigStoreUnavailable, err)
+	}
+
+	lastIssued, err := s.dbRepo.GetLastIssuedTimes(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get last issued times: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	now := time.Now()
+	summaries := make([]models.PrefixSummary, 0, len(configs))
+	for _, config := range configs {
+		if config.ArchivedAt != nil && !includeArchived {
+			continue
+		}
+
+		summary := models.PrefixSummary{
+			PrefixConfig: config,
+			Archived:     config.ArchivedAt != nil,
+		}
+		if t, ok := lastIssued[config.Prefix]; ok {
+			summary.LastIssuedAt = &t
+		}
+
+		period, err := currentPeriod(config.ResetRule, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for prefix %s: %w", config.Prefix, err)
+		}
+		summary.PeriodKey = period.Key
+
+		summary.CurrentCounter, err = s.counters.GetCounter(ctx, counterName(config.Tenant, config.Prefix, period))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get current counter of prefix %s: %w", ErrCounterUnavailable, config.Prefix, err)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// ArchivePrefix stops a prefix from issuing new IDs. Its configuration,
+// counters and audit history are kept.
+func (s *SequentialIDService) ArchivePrefix(ctx context.Context, prefix string, req *models.PrefixArchiveRequest) (*models.PrefixConfig, error) {
+	return s.setArchived(ctx, prefix, req, true)
+}
+
+// UnarchivePrefix lets an archived prefix issue IDs again
+func (s *SequentialIDService) UnarchivePrefix(ctx context.Context, prefix string, req *models.PrefixArchiveRequest) (*models.PrefixConfig, error) {
+	return s.setArchived(ctx, prefix, req, false)
+}
+
+// setArchived archives or unarchives a prefix, recording the change in the
+// config history
+func (s *SequentialIDService) setArchived(ctx context.Context, prefix string, req *models.PrefixArchiveRequest, archived bool) (*models.PrefixConfig, error) {
+	req.AdminUser = actor(ctx, req.AdminUser)
+	if req.AdminUser == "" {
+		return nil, fmt.Errorf("%w to archive a prefix", ErrAdminUserRequired)
+	}
+
+	tenant := tenantOf(ctx)
+	existing, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get existing config: %w", ErrConfigStoreUnavailable, err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	}
+
+	if (existing.ArchivedAt != nil) != archived {
+		var archivedAt *time.Time
+		if archived {
+			now := time.Now()
+			archivedAt = &now
+		}
+		updates := map[string]interface{}{
+			"archived_at": archivedAt,
+			"updated_by":  req.AdminUser,
+		}
+		if err := s.dbRepo.UpdatePrefixConfig(ctx, tenant, prefix, updates, models.ConfigChangeUpdate, req.AdminUser); err != nil {
+			return nil, configWriteError("update", prefix, err)
+		}
+		s.configs.invalidate(configKey(tenant, prefix))
+
+		s.logger.WithFields(logrus.Fields{
+			"tenant":     tenant,
+			"prefix":     prefix,
+			"archived":   archived,
+			"admin_user": req.AdminUser,
+		}).Info("Changed prefix archive state")
+	}
+
+	config, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+	return config, nil
+}
+
+// DeletePrefix deletes the configuration of a prefix. A prefix that has
+// issued IDs is only deleted with force and a reason; its counters and audit
+// history are kept either way.
+func (s *SequentialIDService) DeletePrefix(ctx context.Context, prefix string, req *models.PrefixDeleteRequest) error {
+	req.AdminUser = actor(ctx, req.AdminUser)
+	if req.AdminUser == "" {
+		return fmt.Errorf("%w to delete a prefix", ErrAdminUserRequired)
+	}
+	if req.Force && req.Reason == "" {
+		return fmt.Errorf("%w: force requires a reason", ErrInvalidPrefixDelete)
+	}
+
+	tenant := tenantOf(ctx)
+	existing, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get existing config: %w", ErrConfigStoreUnavailable, err)
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	}
+
+	deleted, err := s.dbRepo.DeletePrefixConfig(ctx, tenant, prefix, req.Force, req.AdminUser, req.Reason)
+	if err != nil {
+		return configWriteError("delete", prefix, err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrPrefixInUse, prefix)
+	}
+	s.configs.invalidate(configKey(tenant, prefix))
+
+	entry := s.logger.WithFields(logrus.Fields{
+		"tenant":     tenant,
+		"prefix":     prefix,
+		"admin_user": req.AdminUser,
+		"force":      req.Force,
+		"reason":     req.Reason,
+	})
+	if req.Force {
+		entry.Warn("Force deleted prefix with issued IDs")
+	} else {
+		entry.Info("Deleted prefix")
+	}
+
+	return nil
+}
+
+// configWriteError classifies a failed write of a prefix configuration.
+// Prefixes created or deleted by a concurrent request are reported as such;
+// anything else is a failure of the config store.
+func configWriteError(op, prefix string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrPrefixExists):
+		return fmt.Errorf("%w: %s", ErrPrefixExists, prefix)
+	case errors.Is(err, repository.ErrPrefixNotFound):
+		return fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	default:
+		return fmt.Errorf("%w: failed to %s prefix config: %w", ErrConfigStoreUnavailable, op, err)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newPrefixTestService creates a service with prefixes PO and SG, where SG
+// has issued two IDs that the worker has audited
+func newPrefixTestService(t *testing.T) (*SequentialIDService, *memoryDatabase) {
+	t.Helper()
+	db := newMemoryDatabase()
+	db.addConfig(models.PrefixConfig{Prefix: "PO", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	s := newTestService(newMemoryCounters(), db, nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetNext(context.Background(), &models.NextRequest{Prefix: "SG"}); err != nil {
+			t.Fatalf("GetNext: %v", err)
+		}
+	}
+	for _, event := range db.outboxEvents() {
+		db.issue(event)
+	}
+	return s, db
+}
+
+func TestListPrefixes(t *testing.T) {
+	s, db := newPrefixTestService(t)
+	ctx := context.Background()
+	if _, err := s.ArchivePrefix(ctx, "PO", &models.PrefixArchiveRequest{AdminUser: "admin"}); err != nil {
+		t.Fatalf("ArchivePrefix: %v", err)
+	}
+
+	active, err := s.ListPrefixes(ctx, false)
+	if err != nil {
+		t.Fatalf("ListPrefixes: %v", err)
+	}
+	if len(active) != 1 || active[0].Prefix != "SG" {
+		t.Fatalf("active prefixes = %+v, want SG only", active)
+	}
+	sg := active[0]
+	if sg.CurrentCounter != 2 || sg.Archived {
+		t.Errorf("SG = counter %d, archived %t; want counter 2, active", sg.CurrentCounter, sg.Archived)
+	}
+	if last := db.outboxEvents()[1].GeneratedAt; sg.LastIssuedAt == nil || !sg.LastIssuedAt.Equal(last) {
+		t.Errorf("SG last issued at %v, want %v", sg.LastIssuedAt, last)
+	}
+
+	all, err := s.ListPrefixes(ctx, true)
+	if err != nil {
+		t.Fatalf("ListPrefixes: %v", err)
+	}
+	if len(all) != 2 || all[0].Prefix != "PO" || !all[0].Archived || all[0].LastIssuedAt != nil {
+		t.Errorf("all prefixes = %+v, want the archived, never issued PO and SG", all)
+	}
+
+	acme := auth.WithPrincipal(ctx, &auth.Principal{Subject: "mallory", Tenant: "acme"})
+	if prefixes, err := s.ListPrefixes(acme, true); err != nil || len(prefixes) != 0 {
+		t.Errorf("prefixes of another tenant = %+v, %v; want none", prefixes, err)
+	}
+}
+
+func TestArchivePrefix(t *testing.T) {
+	s, db := newPrefixTestService(t)
+	ctx := context.Background()
+	req := &models.PrefixArchiveRequest{AdminUser: "admin"}
+
+	config, err := s.ArchivePrefix(ctx, "SG", req)
+	if err != nil {
+		t.Fatalf("ArchivePrefix: %v", err)
+	}
+	if config.ArchivedAt == nil {
+		t.Error("ArchivePrefix returned a config without archived_at")
+	}
+	if _, err := s.GetNext(ctx, &models.NextRequest{Prefix: "SG"}); !errors.Is(err, ErrPrefixArchived) {
+		t.Errorf("GetNext of an archived prefix = %v, want ErrPrefixArchived", err)
+	}
+	if _, err := s.GetNextBatch(ctx, &models.BatchRequest{Prefix: "SG", Count: 2}); !errors.Is(err, ErrPrefixArchived) {
+		t.Errorf("GetNextBatch of an archived prefix = %v, want ErrPrefixArchived", err)
+	}
+
+	// Archiving again changes nothing
+	if _, err := s.ArchivePrefix(ctx, "SG", req); err != nil {
+		t.Fatalf("second ArchivePrefix: %v", err)
+	}
+	if history, _ := s.GetConfigHistory(ctx, "SG", 0); len(history) != 1 || history[0].NewConfig.ArchivedAt == nil {
+		t.Errorf("history = %+v, want a single archiving change", history)
+	}
+
+	if _, err := s.UnarchivePrefix(ctx, "SG", req); err != nil {
+		t.Fatalf("UnarchivePrefix: %v", err)
+	}
+	id, err := s.GetNext(ctx, &models.NextRequest{Prefix: "SG"})
+	if err != nil || id.Counter != 3 {
+		t.Errorf("GetNext after unarchiving = %+v, %v; want counter 3", id, err)
+	}
+	if logs := db.auditLogs(); len(logs) != 2 {
+		t.Errorf("audit logs = %+v, want the 2 issued before archiving kept", logs)
+	}
+
+	if _, err := s.ArchivePrefix(ctx, "INV", req); !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("ArchivePrefix of an unknown prefix = %v, want ErrPrefixNotFound", err)
+	}
+	if _, err := s.ArchivePrefix(ctx, "SG", &models.PrefixArchiveRequest{}); !errors.Is(err, ErrAdminUserRequired) {
+		t.Errorf("ArchivePrefix without an admin user = %v, want ErrAdminUserRequired", err)
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	s, _ := newPrefixTestService(t)
+	ctx := context.Background()
+
+	// PO never issued an ID
+	if err := s.DeletePrefix(ctx, "PO", &models.PrefixDeleteRequest{AdminUser: "admin"}); err != nil {
+		t.Fatalf("DeletePrefix(PO): %v", err)
+	}
+	if _, err := s.GetNext(ctx, &models.NextRequest{Prefix: "PO"}); !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("GetNext of a deleted prefix = %v, want ErrPrefixNotFound", err)
+	}
+
+	tests := []struct {
+		name string
+		req  models.PrefixDeleteRequest
+		want error
+	}{
+		{"without an admin user", models.PrefixDeleteRequest{}, ErrAdminUserRequired},
+		{"with issued IDs", models.PrefixDeleteRequest{AdminUser: "admin"}, ErrPrefixInUse},
+		{"forced without a reason", models.PrefixDeleteRequest{AdminUser: "admin", Force: true}, ErrInvalidPrefixDelete},
+	}
+	for _, tt := range tests {
+		if err := s.DeletePrefix(ctx, "SG", &tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: DeletePrefix = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	forced := &models.PrefixDeleteRequest{AdminUser: "admin", Force: true, Reason: "migrated to SO"}
+	if err := s.DeletePrefix(ctx, "SG", forced); err != nil {
+		t.Fatalf("forced DeletePrefix(SG): %v", err)
+	}
+	history, _ := s.GetConfigHistory(ctx, "SG", 0)
+	if len(history) != 1 || history[0].ChangeType != models.ConfigChangeDelete || history[0].Reason != forced.Reason {
+		t.Errorf("history = %+v, want a DELETE with the reason", history)
+	}
+	if err := s.DeletePrefix(ctx, "SG", forced); !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("second DeletePrefix = %v, want ErrPrefixNotFound", err)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// Limits on streamed allocations
+const (
+	// MaxRangeChunk caps the IDs per range; it matches the GetNextBatch limit
+	MaxRangeChunk = 1000
+	// MaxRangeDemand caps the IDs of a single demand
+	MaxRangeDemand = 1000000
+)
+
+// ErrInvalidRangeRequest is returned for malformed range demands
+var ErrInvalidRangeRequest = newError(KindInvalidArgument, "INVALID_RANGE_REQUEST", "invalid range request")
+
+// AllocateRanges issues req.Count IDs of a prefix as ranges of at most
+// req.ChunkSize consecutive IDs and passes each range to emit before the
+// next one is allocated, so a consumer that stops reading holds back
+// allocation once emit blocks. Every range is issued and audited like a
+// GetNextBatch call; ranges allocated before an error stay issued.
+func (s *SequentialIDService) AllocateRanges(ctx context.Context, req *models.RangeRequest, emit func(*models.IDRange) error) (err error) {
+	defer func(start time.Time) { metrics.ObserveGenerate("allocate_ranges", start, err) }(time.Now())
+
+	if req.Count <= 0 || req.Count > MaxRangeDemand {
+		return fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidRangeRequest, MaxRangeDemand)
+	}
+	chunkSize := req.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = MaxRangeChunk
+	}
+	if chunkSize < 0 || chunkSize > MaxRangeChunk {
+		return fmt.Errorf("%w: chunk_size must be between 1 and %d", ErrInvalidRangeRequest, MaxRangeChunk)
+	}
+
+	if err := authorizePrefix(ctx, req.Prefix); err != nil {
+		return err
+	}
+
+	for remaining := req.Count; remaining > 0; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		count := chunkSize
+		if remaining < count {
+			count = remaining
+		}
+
+		batch, err := s.getNextBatch(ctx, &models.BatchRequest{
+			Prefix:        req.Prefix,
+			Count:         count,
+			ClientID:      req.ClientID,
+			GeneratedBy:   req.GeneratedBy,
+			CorrelationID: req.CorrelationID,
+		})
+		if err != nil {
+			return err
+		}
+		remaining -= count
+
+		if err := emit(toIDRange(req.DemandID, batch, remaining)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toIDRange describes a batch as a range of a demand
+func toIDRange(demandID string, batch *models.BatchResponse, remaining int) *models.IDRange {
+	first, last := batch.IDs[0], batch.IDs[len(batch.IDs)-1]
+	r := &models.IDRange{
+		DemandID:     demandID,
+		Tenant:       first.Tenant,
+		Prefix:       first.Prefix,
+		PeriodKey:    first.PeriodKey,
+		StartCounter: first.Counter,
+		EndCounter:   last.Counter,
+		Count:        batch.Count,
+		FullNumbers:  make([]string, len(batch.IDs)),
+		BatchID:      batch.BatchID,
+		GeneratedAt:  batch.GeneratedAt,
+		Remaining:    remaining,
+	}
+	for i := range batch.IDs {
+		r.FullNumbers[i] = batch.IDs[i].FullNumber
+	}
+	return r
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// newRangeTestService creates a service with prefix SG padded to 6 digits
+func newRangeTestService() (*SequentialIDService, *memoryCounters, *memoryDatabase) {
+	counters, db := newMemoryCounters(), newMemoryDatabase()
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	return newTestService(counters, db, nil), counters, db
+}
+
+// collect allocates a demand and returns the emitted ranges
+func collect(ctx context.Context, s *SequentialIDService, req *models.RangeRequest) ([]*models.IDRange, error) {
+	var ranges []*models.IDRange
+	err := s.AllocateRanges(ctx, req, func(r *models.IDRange) error {
+		ranges = append(ranges, r)
+		return nil
+	})
+	return ranges, err
+}
+
+func TestAllocateRanges(t *testing.T) {
+	s, counters, db := newRangeTestService()
+
+	ranges, err := collect(context.Background(), s, &models.RangeRequest{DemandID: "d1", Prefix: "SG", Count: 2500, ChunkSize: 1000})
+	if err != nil {
+		t.Fatalf("AllocateRanges: %v", err)
+	}
+	want := []struct {
+		start, end int64
+		remaining  int
+	}{{1, 1000, 1500}, {1001, 2000, 500}, {2001, 2500, 0}}
+	if len(ranges) != len(want) {
+		t.Fatalf("emitted %d ranges, want %d", len(ranges), len(want))
+	}
+	batches := make(map[string]int)
+	for i, r := range ranges {
+		w := want[i]
+		if r.StartCounter != w.start || r.EndCounter != w.end || r.Remaining != w.remaining || r.DemandID != "d1" {
+			t.Errorf("range %d = %d..%d, %d remaining of %s; want %d..%d, %d remaining of d1",
+				i, r.StartCounter, r.EndCounter, r.Remaining, r.DemandID, w.start, w.end, w.remaining)
+		}
+		if r.Count != int(w.end-w.start+1) || len(r.FullNumbers) != r.Count {
+			t.Errorf("range %d has count %d and %d full numbers, want %d", i, r.Count, len(r.FullNumbers), w.end-w.start+1)
+		}
+		batches[r.BatchID] = 0
+	}
+	if ranges[0].FullNumbers[0] != "SG000001" || ranges[2].FullNumbers[499] != "SG002500" {
+		t.Errorf("ranges span %s..%s, want SG000001..SG002500", ranges[0].FullNumbers[0], ranges[2].FullNumbers[499])
+	}
+	if got := counters.value("SG"); got != 2500 {
+		t.Errorf("counter = %d, want 2500", got)
+	}
+
+	// Every ID is audited under the batch of its range
+	events := db.outboxEvents()
+	if len(events) != 2500 {
+		t.Fatalf("outbox holds %d events, want 2500", len(events))
+	}
+	for _, event := range events {
+		if _, ok := batches[event.BatchID]; !ok {
+			t.Fatalf("event %s has batch %q of no range", event.FullNumber, event.BatchID)
+		}
+		batches[event.BatchID]++
+	}
+	for i, r := range ranges {
+		if batches[r.BatchID] != r.Count {
+			t.Errorf("range %d has %d audit events, want %d", i, batches[r.BatchID], r.Count)
+		}
+	}
+}
+
+func TestAllocateRangesDefaultChunk(t *testing.T) {
+	s, _, _ := newRangeTestService()
+
+	ranges, err := collect(context.Background(), s, &models.RangeRequest{Prefix: "SG", Count: MaxRangeChunk + 1})
+	if err != nil {
+		t.Fatalf("AllocateRanges: %v", err)
+	}
+	if len(ranges) != 2 || ranges[0].Count != MaxRangeChunk || ranges[1].Count != 1 {
+		t.Errorf("emitted %d ranges, want %d IDs and 1", len(ranges), MaxRangeChunk)
+	}
+}
+
+func TestAllocateRangesErrors(t *testing.T) {
+	forbidden := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice", Prefixes: []string{"PO*"}})
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  models.RangeRequest
+		want error
+	}{
+		{"no count", context.Background(), models.RangeRequest{Prefix: "SG"}, ErrInvalidRangeRequest},
+		{"count above the maximum", context.Background(), models.RangeRequest{Prefix: "SG", Count: MaxRangeDemand + 1}, ErrInvalidRangeRequest},
+		{"negative chunk", context.Background(), models.RangeRequest{Prefix: "SG", Count: 10, ChunkSize: -1}, ErrInvalidRangeRequest},
+		{"chunk above the maximum", context.Background(), models.RangeRequest{Prefix: "SG", Count: 10, ChunkSize: MaxRangeChunk + 1}, ErrInvalidRangeRequest},
+		{"unknown prefix", context.Background(), models.RangeRequest{Prefix: "PO", Count: 10}, ErrPrefixNotFound},
+		{"prefix not allowed", forbidden, models.RangeRequest{Prefix: "SG", Count: 10}, auth.ErrForbidden},
+	}
+	for _, tt := range tests {
+		s, counters, _ := newRangeTestService()
+		ranges, err := collect(tt.ctx, s, &tt.req)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: AllocateRanges = %v, want %v", tt.name, err, tt.want)
+		}
+		if len(ranges) != 0 || counters.value(tt.req.Prefix) != 0 {
+			t.Errorf("%s: emitted %d ranges and advanced the counter to %d", tt.name, len(ranges), counters.value(tt.req.Prefix))
+		}
+	}
+}
+
+func TestAllocateRangesStopsWithTheConsumer(t *testing.T) {
+	gone := errors.New("stream closed")
+	tests := []struct {
+		name string
+		stop func(cancel context.CancelFunc) error // called when the second range is emitted
+		want error
+	}{
+		{"emit fails", func(context.CancelFunc) error { return gone }, gone},
+		{"context canceled", func(cancel context.CancelFunc) error { cancel(); return nil }, context.Canceled},
+	}
+	for _, tt := range tests {
+		s, counters, _ := newRangeTestService()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		emitted := 0
+		err := s.AllocateRanges(ctx, &models.RangeRequest{Prefix: "SG", Count: 50, ChunkSize: 10}, func(*models.IDRange) error {
+			emitted++
+			if emitted == 2 {
+				return tt.stop(cancel)
+			}
+			return nil
+		})
+		cancel()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: AllocateRanges = %v, want %v", tt.name, err, tt.want)
+		}
+		// Ranges already allocated stay issued; no further range is allocated
+		if emitted != 2 || counters.value("SG") != 20 {
+			t.Errorf("%s: emitted %d ranges with the counter at %d, want 2 ranges and 20", tt.name, emitted, counters.value("SG"))
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMaxBackfill bounds the number of placeholders written per run
+const DefaultMaxBackfill = 10000
+
+// ErrInvalidReconcile is returned for malformed reconciliation requests
+var ErrInvalidReconcile = newError(KindInvalidArgument, "INVALID_RECONCILE_REQUEST", "invalid reconcile request")
+
+// reconcileActor is recorded as generated_by on placeholder entries
+const reconcileActor = "reconcile"
+
+// Reconciler compares stored counters and checkpoints against seq_log,
+// reports counter values that were issued but never audited and optionally
+// backfills placeholder entries for them
+type Reconciler struct {
+	counters repository.CounterStore
+	dbRepo   repository.Database
+	logger   *logrus.Logger
+}
+
+// NewReconciler creates a new reconciler
+func NewReconciler(
+	counters repository.CounterStore,
+	dbRepo repository.Database,
+	logger *logrus.Logger,
+) *Reconciler {
+	return &Reconciler{
+		counters: counters,
+		dbRepo:   dbRepo,
+		logger:   logger,
+	}
+}
+
+// Reconcile checks the requested prefixes (all configured prefixes when none
+// are given) and backfills missing values when req.FixGaps is set.
+// Authenticated callers only reconcile prefixes of their own tenant.
+func (s *SequentialIDService) Reconcile(ctx context.Context, req *models.ReconcileRequest) (*models.ReconcileReport, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); ok {
+		req.Tenant = tenantOf(ctx)
+	}
+	return NewReconciler(s.counters, s.dbRepo, s.logger).Reconcile(ctx, req)
+}
+
+// Reconcile checks the requested prefixes (all configured prefixes when none
+// are given) of req.Tenant, or of every tenant when it is empty, and
+// backfills missing values when req.FixGaps is set
+func (r *Reconciler) Reconcile(ctx context.Context, req *models.ReconcileRequest) (*models.ReconcileReport, error) {
+	budget := req.MaxBackfill
+	if budget < 0 {
+		return nil, fmt.Errorf("%w: max_backfill must not be negative", ErrInvalidReconcile)
+	}
+	if budget == 0 {
+		budget = DefaultMaxBackfill
+	}
+
+	configs, err := r.prefixConfigs(ctx, req.Tenant, req.Prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconcileReport{
+		Periods:   []models.PeriodReconciliation{},
+		CheckedAt: time.Now(),
+	}
+
+	for i := range configs {
+		periods, err := r.reconcilePrefix(ctx, &configs[i], req.FixGaps, &budget, report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile prefix %s of tenant %s: %w", configs[i].Prefix, configs[i].Tenant, err)
+		}
+		report.Periods = append(report.Periods, periods...)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant":     req.Tenant,
+		"prefixes":   len(configs),
+		"missing":    report.Missing,
+		"backfilled": report.Backfilled,
+		"truncated":  report.Truncated,
+	}).Info("Reconciliation completed")
+
+	return report, nil
+}
+
+// prefixConfigs loads the configurations of the requested prefixes of a
+// tenant. Without prefixes, all prefixes of the tenant are loaded, or those
+// of every tenant when tenant is empty; named prefixes without a tenant
+// belong to the default tenant.
+func (r *Reconciler) prefixConfigs(ctx context.Context, tenant string, prefixes []string) ([]models.PrefixConfig, error) {
+	if len(prefixes) == 0 {
+		var configs []models.PrefixConfig
+		var err error
+		if tenant == "" {
+			configs, err = r.dbRepo.GetAllPrefixConfigs(ctx)
+		} else {
+			configs, err = r.dbRepo.GetTenantPrefixConfigs(ctx, tenant)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get prefix configs: %w", ErrConfigStoreUnavailable, err)
+		}
+		return configs, nil
+	}
+
+	if tenant == "" {
+		tenant = models.DefaultTenant
+	}
+
+	configs := make([]models.PrefixConfig, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		config, err := r.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+		}
+		if config == nil {
+			return nil, fmt.Errorf("%w: prefix %s of tenant %s not configured", ErrInvalidReconcile, prefix, tenant)
+		}
+		configs = append(configs, *config)
+	}
+	return configs, nil
+}
+
+// reconcilePrefix reconciles every counter period of a prefix
+func (r *Reconciler) reconcilePrefix(ctx context.Context, config *models.PrefixConfig, fix bool, budget *int64, report *models.ReconcileReport) ([]models.PeriodReconciliation, error) {
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %w", err)
+	}
+
+	active, err := currentPeriod(config.ResetRule, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid reset rule: %w", err)
+	}
+
+	keys, err := r.dbRepo.GetAuditPeriods(ctx, config.Tenant, config.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get audit periods: %w", ErrAuditStoreUnavailable, err)
+	}
+	if !containsString(keys, active.Key) {
+		keys = append(keys, active.Key)
+	}
+
+	checkpoint, err := r.dbRepo.GetCheckpoint(ctx, config.Tenant, config.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get checkpoint: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	results := make([]models.PeriodReconciliation, 0, len(keys))
+	for _, key := range keys {
+		rec, err := r.reconcilePeriod(ctx, config, tmpl, key, key == active.Key, checkpoint, fix, budget, report)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *rec)
+	}
+
+	return results, nil
+}
+
+// reconcilePeriod compares Redis, the checkpoint and seq_log for one period
+func (r *Reconciler) reconcilePeriod(
+	ctx context.Context,
+	config *models.PrefixConfig,
+	tmpl *idformat.Template,
+	key string,
+	active bool,
+	checkpoint *models.Checkpoint,
+	fix bool,
+	budget *int64,
+	report *models.ReconcileReport,
+) (*models.PeriodReconciliation, error) {
+	redisCounter, err := r.counters.GetCounter(ctx, counterName(config.Tenant, config.Prefix, counterPeriod{Key: key}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get counter: %w", ErrCounterUnavailable, err)
+	}
+
+	stats, err := r.dbRepo.GetAuditStats(ctx, config.Tenant, config.Prefix, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get audit stats: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	rec := &models.PeriodReconciliation{
+		Tenant:       config.Tenant,
+		Prefix:       config.Prefix,
+		PeriodKey:    key,
+		Active:       active,
+		RedisCounter: redisCounter,
+		AuditedCount: stats.Count,
+		AuditedMax:   stats.MaxCounter,
+		LostCount:    stats.LostCount,
+		RedisBehind:  redisCounter < stats.MaxCounter,
+		Gaps:         []models.CounterGap{},
+	}
+
+	// The highest value known to be issued in this period
+	issued := redisCounter
+	if checkpoint != nil && checkpoint.PeriodKey == key {
+		synced := checkpoint.LastCounterSynced
+		rec.CheckpointCounter = &synced
+		if synced > issued {
+			issued = synced
+		}
+	}
+
+	bounds, err := r.gapBounds(ctx, config, key, stats, issued)
+	if err != nil {
+		return nil, err
+	}
+
+	resets, err := r.dbRepo.GetResetLogs(ctx, config.Tenant, config.Prefix, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get reset logs: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	// Numbers of gapless prefixes stay unaudited until they are committed
+	var reserved []int64
+	if config.Gapless {
+		reserved, err = r.dbRepo.GetReservedCounters(ctx, config.Tenant, config.Prefix, key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get reserved counters: %w", ErrAuditStoreUnavailable, err)
+		}
+	}
+
+	for _, b := range bounds {
+		// Values past the last audited entry of the active period may
+		// still be queued for the worker
+		reason := models.GapReasonMissing
+		if active && b.From > stats.MaxCounter {
+			reason = models.GapReasonPending
+		}
+
+		for _, gap := range splitByReserved(splitByResets(b.From, b.To, reason, resets), reserved) {
+			if gap.Reason == models.GapReasonMissing {
+				rec.Missing += gap.Count
+				if fix {
+					filled, err := r.backfill(ctx, config, tmpl, key, gap, placeholderTime(b, stats, key), budget, report)
+					if err != nil {
+						return nil, err
+					}
+					gap.Backfilled = filled
+				}
+			}
+			rec.Gaps = append(rec.Gaps, gap)
+		}
+	}
+
+	report.Missing += rec.Missing
+
+	return rec, nil
+}
+
+// gapBounds lists the leading, inner and trailing ranges of counter values
+// up to issued that have no audit entry
+func (r *Reconciler) gapBounds(ctx context.Context, config *models.PrefixConfig, key string, stats *models.AuditStats, issued int64) ([]models.GapBounds, error) {
+	if stats.Count == 0 {
+		if issued < 1 {
+			return nil, nil
+		}
+		return []models.GapBounds{{From: 1, To: issued}}, nil
+	}
+
+	var bounds []models.GapBounds
+	if stats.MinCounter > 1 {
+		bounds = append(bounds, models.GapBounds{From: 1, To: stats.MinCounter - 1})
+	}
+
+	inner, err := r.dbRepo.FindCounterGaps(ctx, config.Tenant, config.Prefix, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to find counter gaps: %w", ErrAuditStoreUnavailable, err)
+	}
+	bounds = append(bounds, inner...)
+
+	if issued > stats.MaxCounter {
+		bounds = append(bounds, models.GapBounds{From: stats.MaxCounter + 1, To: issued, BeforeAt: stats.LastGenerated})
+	}
+
+	return bounds, nil
+}
+
+// backfill writes placeholder entries for a missing range within the
+// remaining budget and returns the number written
+func (r *Reconciler) backfill(
+	ctx context.Context,
+	config *models.PrefixConfig,
+	tmpl *idformat.Template,
+	key string,
+	gap models.CounterGap,
+	at time.Time,
+	budget *int64,
+	report *models.ReconcileReport,
+) (int64, error) {
+	count := gap.Count
+	if count > *budget {
+		count = *budget
+		report.Truncated = true
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	logs := make([]models.AuditLog, 0, count)
+	generatedBy := reconcileActor
+	for counter := gap.From; counter < gap.From+count; counter++ {
+		logs = append(logs, models.AuditLog{
+			Tenant:       config.Tenant,
+			Prefix:       config.Prefix,
+			CounterValue: counter,
+			PeriodKey:    key,
+			FullNumber:   formatID(tmpl, config, counter, at),
+			GeneratedBy:  &generatedBy,
+			MessageID:    uuid.New().String(),
+			GeneratedAt:  at,
+			Status:       models.AuditStatusLost,
+		})
+	}
+
+	inserted, err := r.dbRepo.InsertLostAuditLogs(ctx, logs)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to backfill placeholders: %w", ErrAuditStoreUnavailable, err)
+	}
+
+	*budget -= count
+	report.Backfilled += inserted
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant":   config.Tenant,
+		"prefix":   config.Prefix,
+		"period":   key,
+		"from":     gap.From,
+		"to":       gap.From + count - 1,
+		"inserted": inserted,
+	}).Warn("Backfilled placeholders for unaudited IDs")
+
+	return inserted, nil
+}
+
+// splitByResets divides a gap into the parts skipped by counter resets and
+// the rest, which keeps the given reason
+func splitByResets(from, to int64, reason string, resets []models.ResetLog) []models.CounterGap {
+	type span struct{ from, to int64 }
+	var skipped []span
+	for _, reset := range resets {
+		if reset.NewValue > reset.OldValue {
+			skipped = append(skipped, span{reset.OldValue + 1, reset.NewValue})
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].from < skipped[j].from })
+
+	var gaps []models.CounterGap
+	add := func(from, to int64, reason string) {
+		if from <= to {
+			gaps = append(gaps, models.CounterGap{From: from, To: to, Count: to - from + 1, Reason: reason})
+		}
+	}
+
+	cur := from
+	for _, s := range skipped {
+		if s.to < cur || s.from > to {
+			continue
+		}
+		add(cur, s.from-1, reason)
+		end := s.to
+		if end > to {
+			end = to
+		}
+		if start := max(cur, s.from); start <= end {
+			add(start, end, models.GapReasonReset)
+		}
+		cur = end + 1
+	}
+	add(cur, to, reason)
+
+	return gaps
+}
+
+// splitByReserved moves counter values held by gapless reservations out of
+// the missing and pending gaps into gaps of their own. reserved must be
+// sorted.
+func splitByReserved(gaps []models.CounterGap, reserved []int64) []models.CounterGap {
+	if len(reserved) == 0 {
+		return gaps
+	}
+
+	var out []models.CounterGap
+	add := func(from, to int64, reason string) {
+		if from <= to {
+			out = append(out, models.CounterGap{From: from, To: to, Count: to - from + 1, Reason: reason})
+		}
+	}
+
+	for _, gap := range gaps {
+		if gap.Reason == models.GapReasonReset {
+			out = append(out, gap)
+			continue
+		}
+
+		cur := gap.From
+		i := sort.Search(len(reserved), func(i int) bool { return reserved[i] >= gap.From })
+		for i < len(reserved) && reserved[i] <= gap.To {
+			// Collect the run of consecutive reserved values
+			start := reserved[i]
+			end := start
+			for i+1 < len(reserved) && reserved[i+1] == end+1 && reserved[i+1] <= gap.To {
+				i++
+				end = reserved[i]
+			}
+			i++
+
+			add(cur, start-1, gap.Reason)
+			add(start, end, models.GapReasonReserved)
+			cur = end + 1
+		}
+		add(cur, gap.To, gap.Reason)
+	}
+
+	return out
+}
+
+// placeholderTime picks the generation time recorded on placeholders: the
+// time of the entry before the gap, else the first entry of the period,
+// else the start of the period
+func placeholderTime(b models.GapBounds, stats *models.AuditStats, key string) time.Time {
+	switch {
+	case b.BeforeAt != nil:
+		return *b.BeforeAt
+	case stats.FirstGenerated != nil:
+		return *stats.FirstGenerated
+	}
+
+	for _, layout := range []string{"20060102", "200601", "2006"} {
+		if len(layout) == len(key) {
+			if start, err := time.Parse(layout, key); err == nil {
+				return start
+			}
+		}
+	}
+	return time.Now()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -3,166 +3,281 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/idformat"
+	"github.com/putram11/sequential-id-counter-service/internal/metrics"
 	"github.com/putram11/sequential-id-counter-service/internal/models"
 	"github.com/putram11/sequential-id-counter-service/internal/repository"
 	"github.com/sirupsen/logrus"
 )
 
+// Defaults applied to prefixes created without explicit settings
+const (
+	DefaultPaddingLength  = 6
+	DefaultFormatTemplate = "{prefix}{seq}"
+)
+
 // SequentialIDService provides sequential ID generation functionality
 type SequentialIDService struct {
-	redisRepo  *repository.RedisRepository
-	dbRepo     *repository.PostgresRepository
-	rabbitRepo *repository.RabbitMQRepository
+	counters   repository.CounterBackend
+	dbRepo     repository.Database
+	rabbitRepo repository.EventBroker
+	cfg        *config.Config
+	blocks     *blockAllocator
+	configs    *configCache
 	logger     *logrus.Logger
 }
 
 // NewSequentialIDService creates a new sequential ID service
 func NewSequentialIDService(
-	redisRepo *repository.RedisRepository,
-	dbRepo *repository.PostgresRepository,
-	rabbitRepo *repository.RabbitMQRepository,
+	counters repository.CounterBackend,
+	dbRepo repository.Database,
+	rabbitRepo repository.EventBroker,
+	cfg *config.Config,
 	logger *logrus.Logger,
 ) *SequentialIDService {
 	return &SequentialIDService{
-		redisRepo:  redisRepo,
+		counters:   counters,
 		dbRepo:     dbRepo,
 		rabbitRepo: rabbitRepo,
+		cfg:        cfg,
+		blocks:     newBlockAllocator(),
+		configs:    newConfigCache(cfg.ConfigCache),
 		logger:     logger,
 	}
 }
 
-// GetNext generates the next sequential ID for a given prefix
-func (s *SequentialIDService) GetNext(ctx context.Context, prefix, clientID, generatedBy string) (*models.SequentialID, error) {
+// GetNext generates the next sequential ID for a given prefix. A repeated
+// call with the same non-empty idempotency key returns the original ID.
+func (s *SequentialIDService) GetNext(ctx context.Context, req *models.NextRequest) (_ *models.SequentialID, err error) {
+	defer func(start time.Time) { metrics.ObserveGenerate("get_next", start, err) }(time.Now())
+
+	if err := authorizePrefix(ctx, req.Prefix); err != nil {
+		return nil, err
+	}
+
+	params := idempotencyParams{
+		Operation:     "get_next",
+		Prefix:        req.Prefix,
+		ClientID:      req.ClientID,
+		GeneratedBy:   req.GeneratedBy,
+		CorrelationID: req.CorrelationID,
+	}
+	return idempotent(s, ctx, req.IdempotencyKey, params, func() (*models.SequentialID, error) {
+		return s.getNext(ctx, req)
+	})
+}
+
+// getNext issues the next sequential ID for a prefix of the caller's tenant
+func (s *SequentialIDService) getNext(ctx context.Context, req *models.NextRequest) (*models.SequentialID, error) {
+	prefix := req.Prefix
+	tenant := tenantOf(ctx)
+
 	// Get prefix configuration
-	config, err := s.dbRepo.GetPrefixConfig(ctx, prefix)
+	config, err := s.prefixConfig(ctx, tenant, prefix)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get prefix config: %w", err)
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
 	}
 	if config == nil {
-		return nil, fmt.Errorf("prefix %s not configured", prefix)
+		return nil, fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
+	}
+	if config.ArchivedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixArchived, prefix)
+	}
+	if config.Gapless {
+		return nil, fmt.Errorf("%w: %s", ErrGaplessPrefix, prefix)
+	}
+
+	// Resolve the counter period from the reset rule
+	now := time.Now()
+	period, err := currentPeriod(config.ResetRule, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", prefix, err)
+	}
+
+	// Parse the format template before a counter value is consumed
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", prefix, err)
 	}
 
-	// Increment counter in Redis (atomic operation)
-	counter, err := s.redisRepo.IncrementCounter(ctx, prefix)
+	// Increment counter in the counter store (atomic operation), or take
+	// the next value of this instance's block
+	counter, err := s.nextCounter(ctx, config, period)
 	if err != nil {
-		return nil, fmt.Errorf("failed to increment counter: %w", err)
+		return nil, fmt.Errorf("%w: failed to increment counter: %w", ErrCounterUnavailable, err)
 	}
+	metrics.IDsIssued.WithLabelValues(prefix).Inc()
+
+	s.markPeriodReset(ctx, config, period)
 
 	// Format the ID
-	fullNumber := s.formatID(config, counter)
+	fullNumber := formatID(tmpl, config, counter, now)
 
 	// Create sequential ID
 	seqID := &models.SequentialID{
-		Prefix:      prefix,
-		Counter:     counter,
-		FullNumber:  fullNumber,
-		GeneratedBy: generatedBy,
-		ClientID:    clientID,
-		MessageID:   uuid.New().String(),
-		GeneratedAt: time.Now(),
+		Tenant:        tenant,
+		Prefix:        prefix,
+		Counter:       counter,
+		PeriodKey:     period.Key,
+		FullNumber:    fullNumber,
+		GeneratedBy:   req.GeneratedBy,
+		ClientID:      req.ClientID,
+		CorrelationID: req.CorrelationID,
+		MessageID:     uuid.New().String(),
+		GeneratedAt:   now,
+	}
+
+	// Record the audit event before the ID is returned
+	event := &models.Event{
+		MessageID:     seqID.MessageID,
+		Tenant:        seqID.Tenant,
+		Prefix:        seqID.Prefix,
+		Counter:       seqID.Counter,
+		PeriodKey:     seqID.PeriodKey,
+		FullNumber:    seqID.FullNumber,
+		GeneratedBy:   seqID.GeneratedBy,
+		ClientID:      seqID.ClientID,
+		CorrelationID: seqID.CorrelationID,
+		GeneratedAt:   seqID.GeneratedAt,
+		RetryCount:    0,
 	}
 
-	// Publish event for audit logging (async)
-	event := &models.Event{
-		MessageID:   seqID.MessageID,
-		Prefix:      seqID.Prefix,
-		Counter:     seqID.Counter,
-		FullNumber:  seqID.FullNumber,
-		GeneratedBy: seqID.GeneratedBy,
-		ClientID:    seqID.ClientID,
-		GeneratedAt: seqID.GeneratedAt,
-		RetryCount:  0,
-	}
-
-	if err := s.rabbitRepo.PublishEvent(ctx, event); err != nil {
-		// Log error but don't fail the request - the ID was already generated
-		s.logger.WithError(err).WithFields(logrus.Fields{
-			"prefix":      prefix,
-			"counter":     counter,
-			"full_number": fullNumber,
-			"message_id":  seqID.MessageID,
-		}).Error("Failed to publish audit event")
+	if err := s.recordEvents(ctx, prefix, []*models.Event{event}); err != nil {
+		return nil, err
 	}
 
 	s.logger.WithFields(logrus.Fields{
+		"tenant":       tenant,
 		"prefix":       prefix,
+		"period":       period.Key,
 		"counter":      counter,
 		"full_number":  fullNumber,
-		"client_id":    clientID,
-		"generated_by": generatedBy,
+		"client_id":    req.ClientID,
+		"generated_by": req.GeneratedBy,
 	}).Info("Generated sequential ID")
 
 	return seqID, nil
 }
 
-// GetNextBatch generates multiple sequential IDs in a single operation
-func (s *SequentialIDService) GetNextBatch(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error) {
+// GetNextBatch generates multiple sequential IDs in a single operation. A
+// repeated call with the same non-empty idempotency key returns the
+// original batch.
+func (s *SequentialIDService) GetNextBatch(ctx context.Context, req *models.BatchRequest) (_ *models.BatchResponse, err error) {
+	defer func(start time.Time) { metrics.ObserveGenerate("get_next_batch", start, err) }(time.Now())
+
 	if req.Count <= 0 || req.Count > 1000 {
-		return nil, fmt.Errorf("invalid count: must be between 1 and 1000")
+		return nil, fmt.Errorf("%w: must be between 1 and 1000", ErrInvalidCount)
+	}
+
+	if err := authorizePrefix(ctx, req.Prefix); err != nil {
+		return nil, err
+	}
+
+	params := idempotencyParams{
+		Operation:     "get_next_batch",
+		Prefix:        req.Prefix,
+		Count:         req.Count,
+		ClientID:      req.ClientID,
+		GeneratedBy:   req.GeneratedBy,
+		CorrelationID: req.CorrelationID,
 	}
+	return idempotent(s, ctx, req.IdempotencyKey, params, func() (*models.BatchResponse, error) {
+		return s.getNextBatch(ctx, req)
+	})
+}
+
+// getNextBatch issues a batch of sequential IDs for a prefix of the
+// caller's tenant
+func (s *SequentialIDService) getNextBatch(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error) {
+	tenant := tenantOf(ctx)
 
 	// Get prefix configuration
-	config, err := s.dbRepo.GetPrefixConfig(ctx, req.Prefix)
+	config, err := s.prefixConfig(ctx, tenant, req.Prefix)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get prefix config: %w", err)
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
 	}
 	if config == nil {
-		return nil, fmt.Errorf("prefix %s not configured", req.Prefix)
+		return nil, fmt.Errorf("%w: %s", ErrPrefixNotFound, req.Prefix)
+	}
+	if config.ArchivedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixArchived, req.Prefix)
+	}
+	if config.Gapless {
+		return nil, fmt.Errorf("%w: %s", ErrGaplessPrefix, req.Prefix)
 	}
 
-	// Increment counter by batch size (atomic operation)
-	endCounter, err := s.redisRepo.IncrementCounterBy(ctx, req.Prefix, int64(req.Count))
+	// Resolve the counter period from the reset rule
+	generatedAt := time.Now()
+	period, err := currentPeriod(config.ResetRule, generatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", req.Prefix, err)
+	}
+
+	// Parse the format template before counter values are consumed
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for prefix %s: %w", req.Prefix, err)
+	}
+
+	// Increment counter by batch size (atomic operation). Batches take a
+	// consecutive range of their own, also for prefixes allocating in blocks.
+	endCounter, err := s.counters.IncrementCounterBy(ctx, counterName(tenant, req.Prefix, period), int64(req.Count))
 	if err != nil {
-		return nil, fmt.Errorf("failed to increment counter: %w", err)
+		return nil, fmt.Errorf("%w: failed to increment counter: %w", ErrCounterUnavailable, err)
 	}
+	metrics.IDsIssued.WithLabelValues(req.Prefix).Add(float64(req.Count))
+	metrics.BatchSize.WithLabelValues(req.Prefix).Observe(float64(req.Count))
+
+	s.markPeriodReset(ctx, config, period)
 
 	startCounter := endCounter - int64(req.Count) + 1
 	batchID := uuid.New().String()
-	generatedAt := time.Now()
 
 	// Generate all IDs in the batch
 	ids := make([]models.SequentialID, req.Count)
+	events := make([]*models.Event, req.Count)
 	for i := 0; i < req.Count; i++ {
 		counter := startCounter + int64(i)
-		fullNumber := s.formatID(config, counter)
+		fullNumber := formatID(tmpl, config, counter, generatedAt)
 
 		ids[i] = models.SequentialID{
-			Prefix:      req.Prefix,
-			Counter:     counter,
-			FullNumber:  fullNumber,
-			GeneratedBy: req.GeneratedBy,
-			ClientID:    req.ClientID,
-			MessageID:   uuid.New().String(),
-			GeneratedAt: generatedAt,
+			Tenant:        tenant,
+			Prefix:        req.Prefix,
+			Counter:       counter,
+			PeriodKey:     period.Key,
+			FullNumber:    fullNumber,
+			GeneratedBy:   req.GeneratedBy,
+			ClientID:      req.ClientID,
+			CorrelationID: req.CorrelationID,
+			MessageID:     uuid.New().String(),
+			GeneratedAt:   generatedAt,
 		}
 
-		// Publish individual events for audit
-		event := &models.Event{
+		// Individual events for audit
+		events[i] = &models.Event{
 			MessageID:     ids[i].MessageID,
+			Tenant:        ids[i].Tenant,
 			Prefix:        ids[i].Prefix,
 			Counter:       ids[i].Counter,
+			PeriodKey:     ids[i].PeriodKey,
 			FullNumber:    ids[i].FullNumber,
 			GeneratedBy:   ids[i].GeneratedBy,
 			ClientID:      ids[i].ClientID,
-			CorrelationID: req.CorrelationID,
+			CorrelationID: ids[i].CorrelationID,
 			GeneratedAt:   ids[i].GeneratedAt,
 			BatchID:       batchID,
 			RetryCount:    0,
 		}
+	}
 
-		if err := s.rabbitRepo.PublishEvent(ctx, event); err != nil {
-			s.logger.WithError(err).WithFields(logrus.Fields{
-				"prefix":     req.Prefix,
-				"counter":    counter,
-				"batch_id":   batchID,
-				"message_id": ids[i].MessageID,
-			}).Error("Failed to publish batch audit event")
-		}
+	// Record the audit events before the IDs are returned
+	if err := s.recordEvents(ctx, req.Prefix, events); err != nil {
+		return nil, err
 	}
 
 	response := &models.BatchResponse{
@@ -173,7 +288,9 @@ func (s *SequentialIDService) GetNextBatch(ctx context.Context, req *models.Batc
 	}
 
 	s.logger.WithFields(logrus.Fields{
+		"tenant":   tenant,
 		"prefix":   req.Prefix,
+		"period":   period.Key,
 		"count":    req.Count,
 		"batch_id": batchID,
 		"start":    startCounter,
@@ -183,16 +300,24 @@ func (s *SequentialIDService) GetNextBatch(ctx context.Context, req *models.Batc
 	return response, nil
 }
 
-// GetStatus returns the current status of a counter
+// GetStatus returns the current status of a counter of the caller's tenant
 func (s *SequentialIDService) GetStatus(ctx context.Context, prefix string) (*models.CounterStatus, error) {
-	// Get current counter from Redis
-	currentCounter, err := s.redisRepo.GetCounter(ctx, prefix)
+	tenant := tenantOf(ctx)
+
+	// Resolve the active period for the prefix
+	period, err := s.activePeriod(ctx, tenant, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get current counter from the counter store
+	currentCounter, err := s.counters.GetCounter(ctx, counterName(tenant, prefix, period))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current counter: %w", err)
+		return nil, fmt.Errorf("%w: failed to get current counter: %w", ErrCounterUnavailable, err)
 	}
 
 	// Get last audit counter from database
-	lastAuditCounter, err := s.dbRepo.GetMaxCounter(ctx, prefix)
+	lastAuditCounter, err := s.dbRepo.GetMaxCounter(ctx, tenant, prefix, period.Key)
 	if err != nil {
 		// Don't fail if we can't get audit counter
 		s.logger.WithError(err).Warn("Failed to get last audit counter")
@@ -200,9 +325,9 @@ func (s *SequentialIDService) GetStatus(ctx context.Context, prefix string) (*mo
 	}
 
 	// Check component health
-	redisHealthy := true
-	if err := s.redisRepo.Ping(ctx); err != nil {
-		redisHealthy = false
+	counterHealthy := true
+	if err := s.counters.Ping(ctx); err != nil {
+		counterHealthy = false
 	}
 
 	queueHealthy := true
@@ -216,10 +341,12 @@ func (s *SequentialIDService) GetStatus(ctx context.Context, prefix string) (*mo
 	}
 
 	status := &models.CounterStatus{
+		Tenant:           tenant,
 		Prefix:           prefix,
+		PeriodKey:        period.Key,
 		CurrentCounter:   currentCounter,
 		NextCounter:      currentCounter + 1,
-		RedisHealthy:     redisHealthy,
+		RedisHealthy:     counterHealthy,
 		QueueHealthy:     queueHealthy,
 		DatabaseHealthy:  dbHealthy,
 		LastAuditCounter: lastAuditCounter,
@@ -228,42 +355,68 @@ func (s *SequentialIDService) GetStatus(ctx context.Context, prefix string) (*mo
 	return status, nil
 }
 
+// Counter reset errors
+var (
+	// ErrInvalidReset is returned for malformed counter resets
+	ErrInvalidReset = newError(KindInvalidArgument, "INVALID_RESET", "invalid counter reset")
+	// ErrResetNotForced is returned for resets that do not advance the counter without force
+	ErrResetNotForced = newError(KindConflict, "RESET_REQUIRES_FORCE", "reset does not advance the counter")
+)
+
 // ResetCounter resets a counter to a specific value (admin operation)
 func (s *SequentialIDService) ResetCounter(ctx context.Context, prefix string, req *models.ResetRequest) (*models.ResetResponse, error) {
+	req.AdminUser = actor(ctx, req.AdminUser)
+
 	// Validate request
 	if req.SetTo < 0 {
-		return nil, fmt.Errorf("counter value cannot be negative")
+		return nil, fmt.Errorf("%w: counter value cannot be negative", ErrInvalidReset)
 	}
 
 	if req.Reason == "" {
-		return nil, fmt.Errorf("reason is required for counter reset")
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidReset)
 	}
 
 	if req.AdminUser == "" {
-		return nil, fmt.Errorf("admin user is required for counter reset")
+		return nil, fmt.Errorf("%w for counter reset", ErrAdminUserRequired)
 	}
 
+	// Resets apply to the counter of the active period
+	tenant := tenantOf(ctx)
+	period, err := s.activePeriod(ctx, tenant, prefix)
+	if err != nil {
+		return nil, err
+	}
+	counterKey := counterName(tenant, prefix, period)
+
 	// Get current value
-	currentValue, err := s.redisRepo.GetCounter(ctx, prefix)
+	currentValue, err := s.counters.GetCounter(ctx, counterKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current counter: %w", err)
+		return nil, fmt.Errorf("%w: failed to get current counter: %w", ErrCounterUnavailable, err)
 	}
 
 	// Check if reset is safe (unless forced)
 	if !req.Force && req.SetTo <= currentValue {
-		return nil, fmt.Errorf("new value %d is not greater than current value %d (use force=true to override)", req.SetTo, currentValue)
+		return nil, fmt.Errorf("%w: new value %d is not greater than current value %d (use force=true to override)", ErrResetNotForced, req.SetTo, currentValue)
 	}
 
-	// Reset counter in Redis
-	oldValue, err := s.redisRepo.ResetCounter(ctx, prefix, req.SetTo)
+	// Reset counter in the counter store
+	oldValue, err := s.counters.ResetCounter(ctx, counterKey, req.SetTo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset counter: %w", err)
+		return nil, fmt.Errorf("%w: failed to reset counter: %w", ErrCounterUnavailable, err)
+	}
+
+	// Values leased before the reset must not be handed out after it
+	s.DiscardBlocks(tenant, prefix)
+	if err := s.dbRepo.NotifyCounterReset(ctx, tenant, prefix); err != nil {
+		s.logger.WithError(err).Error("Failed to notify other instances of counter reset")
 	}
 
 	// Log the reset operation
 	resetID := uuid.New().String()
 	resetLog := &models.ResetLog{
+		Tenant:    tenant,
 		Prefix:    prefix,
+		PeriodKey: period.Key,
 		OldValue:  oldValue,
 		NewValue:  req.SetTo,
 		Reason:    req.Reason,
@@ -277,7 +430,9 @@ func (s *SequentialIDService) ResetCounter(ctx context.Context, prefix string, r
 
 	// Update checkpoint
 	checkpoint := &models.Checkpoint{
+		Tenant:            tenant,
 		Prefix:            prefix,
+		PeriodKey:         period.Key,
 		LastCounterSynced: req.SetTo,
 		SyncedBy:          &req.AdminUser,
 	}
@@ -287,7 +442,9 @@ func (s *SequentialIDService) ResetCounter(ctx context.Context, prefix string, r
 	}
 
 	s.logger.WithFields(logrus.Fields{
+		"tenant":     tenant,
 		"prefix":     prefix,
+		"period":     period.Key,
 		"old_value":  oldValue,
 		"new_value":  req.SetTo,
 		"admin_user": req.AdminUser,
@@ -304,11 +461,38 @@ func (s *SequentialIDService) ResetCounter(ctx context.Context, prefix string, r
 	}, nil
 }
 
-// GetConfig retrieves configuration for a prefix
+// Limits on the number of resets returned by GetResetHistory
+const (
+	DefaultResetHistoryLimit = 50
+	MaxResetHistoryLimit     = 500
+)
+
+// ErrInvalidResetHistory is returned for reset history limits outside the allowed range
+var ErrInvalidResetHistory = newError(KindInvalidArgument, "INVALID_RESET_HISTORY_QUERY", "invalid reset history query")
+
+// GetResetHistory returns the most recent counter resets of a prefix of the
+// caller's tenant, newest first, limited to one counter period when
+// periodKey is set
+func (s *SequentialIDService) GetResetHistory(ctx context.Context, prefix string, periodKey *string, limit int) ([]models.ResetLog, error) {
+	if limit == 0 {
+		limit = DefaultResetHistoryLimit
+	}
+	if limit < 0 || limit > MaxResetHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidResetHistory, MaxResetHistoryLimit)
+	}
+
+	resets, err := s.dbRepo.GetResetHistory(ctx, tenantOf(ctx), prefix, periodKey, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get reset history: %w", ErrAuditStoreUnavailable, err)
+	}
+	return resets, nil
+}
+
+// GetConfig retrieves configuration for a prefix of the caller's tenant
 func (s *SequentialIDService) GetConfig(ctx context.Context, prefix string) (*models.PrefixConfig, error) {
-	config, err := s.dbRepo.GetPrefixConfig(ctx, prefix)
+	config, err := s.prefixConfig(ctx, tenantOf(ctx), prefix)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get prefix config: %w", err)
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
 	}
 
 	return config, nil
@@ -316,27 +500,61 @@ func (s *SequentialIDService) GetConfig(ctx context.Context, prefix string) (*mo
 
 // UpdateConfig updates configuration for a prefix
 func (s *SequentialIDService) UpdateConfig(ctx context.Context, prefix string, req *models.ConfigUpdateRequest) error {
+	return s.applyConfigUpdate(ctx, prefix, req, models.ConfigChangeUpdate)
+}
+
+// applyConfigUpdate validates and stores a configuration update, recording
+// it in the config history with the given change type
+func (s *SequentialIDService) applyConfigUpdate(ctx context.Context, prefix string, req *models.ConfigUpdateRequest, changeType string) error {
+	req.AdminUser = actor(ctx, req.AdminUser)
+
 	// Validate request
 	if req.AdminUser == "" {
-		return fmt.Errorf("admin user is required for config update")
+		return fmt.Errorf("%w for config update", ErrAdminUserRequired)
+	}
+
+	if req.ResetRule != nil {
+		if err := validateResetRule(*req.ResetRule); err != nil {
+			return err
+		}
+	}
+
+	if req.PaddingLength != nil {
+		if err := validatePadding(*req.PaddingLength); err != nil {
+			return err
+		}
+	}
+
+	if req.FormatTemplate != nil {
+		if _, err := idformat.Parse(*req.FormatTemplate); err != nil {
+			return err
+		}
+	}
+
+	if req.BlockSize != nil {
+		if err := validateBlockSize(*req.BlockSize); err != nil {
+			return err
+		}
 	}
 
 	// Check if prefix exists
-	existing, err := s.dbRepo.GetPrefixConfig(ctx, prefix)
+	tenant := tenantOf(ctx)
+	existing, err := s.dbRepo.GetPrefixConfig(ctx, tenant, prefix)
 	if err != nil {
-		return fmt.Errorf("failed to get existing config: %w", err)
+		return fmt.Errorf("%w: failed to get existing config: %w", ErrConfigStoreUnavailable, err)
 	}
 
 	if existing == nil && !req.CreateIfNotExists {
-		return fmt.Errorf("prefix %s does not exist", prefix)
+		return fmt.Errorf("%w: %s", ErrPrefixNotFound, prefix)
 	}
 
 	// Create new prefix if it doesn't exist
 	if existing == nil {
 		newConfig := &models.PrefixConfig{
+			Tenant:         tenant,
 			Prefix:         prefix,
-			PaddingLength:  6,
-			FormatTemplate: "%s%06d",
+			PaddingLength:  DefaultPaddingLength,
+			FormatTemplate: DefaultFormatTemplate,
 			ResetRule:      "never",
 			CreatedBy:      &req.AdminUser,
 		}
@@ -351,8 +569,21 @@ func (s *SequentialIDService) UpdateConfig(ctx context.Context, prefix string, r
 		if req.ResetRule != nil {
 			newConfig.ResetRule = *req.ResetRule
 		}
+		if req.Gapless != nil {
+			newConfig.Gapless = *req.Gapless
+		}
+		if req.BlockSize != nil {
+			newConfig.BlockSize = *req.BlockSize
+		}
+		if newConfig.Gapless && newConfig.BlockSize > 1 {
+			return errGaplessBlocks
+		}
 
-		return s.dbRepo.CreatePrefixConfig(ctx, newConfig)
+		if err := s.dbRepo.CreatePrefixConfig(ctx, newConfig); err != nil {
+			return configWriteError("create", prefix, err)
+		}
+		s.configs.invalidate(configKey(tenant, prefix))
+		return nil
 	}
 
 	// Update existing config
@@ -366,67 +597,114 @@ func (s *SequentialIDService) UpdateConfig(ctx context.Context, prefix string, r
 	if req.ResetRule != nil {
 		updates["reset_rule"] = *req.ResetRule
 	}
+	if req.Gapless != nil {
+		updates["gapless"] = *req.Gapless
+	}
+	if req.BlockSize != nil {
+		updates["block_size"] = *req.BlockSize
+	}
+	gapless, blockSize := existing.Gapless, existing.BlockSize
+	if req.Gapless != nil {
+		gapless = *req.Gapless
+	}
+	if req.BlockSize != nil {
+		blockSize = *req.BlockSize
+	}
+	if gapless && blockSize > 1 {
+		return errGaplessBlocks
+	}
 	if req.AdminUser != "" {
 		updates["updated_by"] = req.AdminUser
 	}
 
 	if len(updates) == 0 {
-		return fmt.Errorf("no updates provided")
+		return fmt.Errorf("%w: no updates provided", ErrInvalidConfig)
 	}
 
-	return s.dbRepo.UpdatePrefixConfig(ctx, prefix, updates)
+	if err := s.dbRepo.UpdatePrefixConfig(ctx, tenant, prefix, updates, changeType, req.AdminUser); err != nil {
+		return configWriteError("update", prefix, err)
+	}
+
+	// Other instances drop their copy when the database announces the change
+	// and release blocks of the previous size on their next allocation
+	s.configs.invalidate(configKey(tenant, prefix))
+	if blockSize != existing.BlockSize {
+		s.releasePrefixBlocks(ctx, tenant, prefix)
+	}
+	return nil
 }
 
-// SyncCountersOnStartup syncs Redis counters with database values on service startup
+// SyncCountersOnStartup raises the stored counters of all tenants to the
+// highest audited values on service startup
 func (s *SequentialIDService) SyncCountersOnStartup(ctx context.Context) error {
 	s.logger.Info("Starting counter synchronization on startup")
 
 	// Get all prefix configurations
 	configs, err := s.dbRepo.GetAllPrefixConfigs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get prefix configs: %w", err)
+		return fmt.Errorf("%w: failed to get prefix configs: %w", ErrConfigStoreUnavailable, err)
 	}
 
-	for _, config := range configs {
-		// Get max counter from database
-		maxCounter, err := s.dbRepo.GetMaxCounter(ctx, config.Prefix)
+	now := time.Now()
+	for i := range configs {
+		config := &configs[i]
+
+		// Only the active period is resumed; earlier periods are closed
+		fields := logrus.Fields{"tenant": config.Tenant, "prefix": config.Prefix}
+		period, err := currentPeriod(config.ResetRule, now)
+		if err != nil {
+			s.logger.WithError(err).WithFields(fields).Error("Invalid reset rule for prefix")
+			continue
+		}
+		counterKey := counterName(config.Tenant, config.Prefix, period)
+
+		// Get max counter of the active period from database
+		maxCounter, err := s.dbRepo.GetMaxCounter(ctx, config.Tenant, config.Prefix, period.Key)
 		if err != nil {
-			s.logger.WithError(err).WithField("prefix", config.Prefix).Error("Failed to get max counter for prefix")
+			s.logger.WithError(err).WithFields(fields).Error("Failed to get max counter for prefix")
 			continue
 		}
 
-		// Set Redis counter (only if greater than current value)
-		currentRedisCounter, err := s.redisRepo.GetCounter(ctx, config.Prefix)
+		// Raise the stored counter (only if greater than current value)
+		currentCounter, err := s.counters.GetCounter(ctx, counterKey)
 		if err != nil {
-			s.logger.WithError(err).WithField("prefix", config.Prefix).Error("Failed to get Redis counter for prefix")
+			s.logger.WithError(err).WithFields(fields).Error("Failed to get counter for prefix")
 			continue
 		}
 
-		if maxCounter > currentRedisCounter {
-			if err := s.redisRepo.SetCounter(ctx, config.Prefix, maxCounter); err != nil {
+		if maxCounter > currentCounter {
+			if err := s.counters.SetCounter(ctx, counterKey, maxCounter); err != nil {
 				s.logger.WithError(err).WithFields(logrus.Fields{
+					"tenant":      config.Tenant,
 					"prefix":      config.Prefix,
+					"period":      period.Key,
 					"max_counter": maxCounter,
-				}).Error("Failed to sync Redis counter")
+				}).Error("Failed to sync counter")
 				continue
 			}
 
 			s.logger.WithFields(logrus.Fields{
+				"tenant":         config.Tenant,
 				"prefix":         config.Prefix,
+				"period":         period.Key,
 				"synced_counter": maxCounter,
-				"redis_counter":  currentRedisCounter,
-			}).Info("Synced Redis counter with database")
+				"stored_counter": currentCounter,
+			}).Info("Synced counter with database")
 		}
 
+		s.markPeriodReset(ctx, config, period)
+
 		// Update checkpoint
 		checkpoint := &models.Checkpoint{
+			Tenant:            config.Tenant,
 			Prefix:            config.Prefix,
+			PeriodKey:         period.Key,
 			LastCounterSynced: maxCounter,
 			SyncedBy:          stringPtr("system"),
 		}
 
 		if err := s.dbRepo.UpdateCheckpoint(ctx, checkpoint); err != nil {
-			s.logger.WithError(err).WithField("prefix", config.Prefix).Error("Failed to update checkpoint")
+			s.logger.WithError(err).WithFields(fields).Error("Failed to update checkpoint")
 		}
 	}
 
@@ -439,12 +717,12 @@ func (s *SequentialIDService) HealthCheck(ctx context.Context) *models.HealthSta
 	components := make(map[string]string)
 	healthy := true
 
-	// Check Redis
-	if err := s.redisRepo.Ping(ctx); err != nil {
-		components["redis"] = fmt.Sprintf("unhealthy: %v", err)
+	// Check the counter store
+	if err := s.counters.Ping(ctx); err != nil {
+		components[s.counters.Backend()] = fmt.Sprintf("unhealthy: %v", err)
 		healthy = false
 	} else {
-		components["redis"] = "healthy"
+		components[s.counters.Backend()] = "healthy"
 	}
 
 	// Check Database
@@ -470,29 +748,161 @@ func (s *SequentialIDService) HealthCheck(ctx context.Context) *models.HealthSta
 	}
 }
 
-// formatID formats a counter value according to the prefix configuration
-func (s *SequentialIDService) formatID(config *models.PrefixConfig, counter int64) string {
-	template := config.FormatTemplate
-
-	// Handle different template formats
-	if strings.Contains(template, "%s") && strings.Contains(template, "%d") {
-		// Template like "%s%06d" or "INV%d-%04d"
-		if strings.Contains(template, "%06d") {
-			return fmt.Sprintf(template, config.Prefix, counter)
-		} else if strings.Contains(template, "%04d") {
-			return fmt.Sprintf(template, time.Now().Year(), counter)
-		} else {
-			// Generic case
-			return fmt.Sprintf(template, config.Prefix, counter)
+// activePeriod resolves the current counter period for a prefix of a tenant.
+// Prefixes without configuration are treated as never resetting.
+func (s *SequentialIDService) activePeriod(ctx context.Context, tenant, prefix string) (counterPeriod, error) {
+	config, err := s.prefixConfig(ctx, tenant, prefix)
+	if err != nil {
+		return counterPeriod{}, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+	if config == nil {
+		return counterPeriod{}, nil
+	}
+
+	period, err := currentPeriod(config.ResetRule, time.Now())
+	if err != nil {
+		return counterPeriod{}, fmt.Errorf("invalid configuration for prefix %s: %w", prefix, err)
+	}
+	return period, nil
+}
+
+// markPeriodReset records the start of a new period in last_reset_at the
+// first time a counter of that period is used
+func (s *SequentialIDService) markPeriodReset(ctx context.Context, config *models.PrefixConfig, period counterPeriod) {
+	if !needsResetMark(config, period) {
+		return
+	}
+
+	if err := s.dbRepo.MarkPeriodReset(ctx, config.Tenant, config.Prefix, period.Start); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"tenant": config.Tenant,
+			"prefix": config.Prefix,
+			"period": period.Key,
+		}).Warn("Failed to update last reset timestamp")
+		return
+	}
+
+	resetAt := period.Start
+	config.LastResetAt = &resetAt
+	s.configs.invalidate(configKey(config.Tenant, config.Prefix))
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant": config.Tenant,
+		"prefix": config.Prefix,
+		"period": period.Key,
+	}).Info("Counter rolled over to new period")
+}
+
+// PreviewFormat renders sample IDs for a proposed format template without
+// consuming any counter values
+func (s *SequentialIDService) PreviewFormat(ctx context.Context, prefix string, req *models.FormatPreviewRequest) (*models.FormatPreviewResponse, error) {
+	if req.Count == 0 {
+		req.Count = 5
+	}
+	if req.Count < 0 || req.Count > 100 {
+		return nil, fmt.Errorf("%w: must be between 1 and 100", ErrInvalidCount)
+	}
+	if req.StartCounter < 0 {
+		return nil, fmt.Errorf("%w: start counter cannot be negative", ErrInvalidConfig)
+	}
+
+	existing, err := s.dbRepo.GetPrefixConfig(ctx, tenantOf(ctx), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get prefix config: %w", ErrConfigStoreUnavailable, err)
+	}
+
+	// Start from the existing configuration and overlay the proposal
+	config := &models.PrefixConfig{
+		Prefix:         prefix,
+		PaddingLength:  DefaultPaddingLength,
+		FormatTemplate: DefaultFormatTemplate,
+	}
+	if existing != nil {
+		config = existing
+	}
+	if req.FormatTemplate != "" {
+		config.FormatTemplate = req.FormatTemplate
+	}
+	if req.PaddingLength != nil {
+		if err := validatePadding(*req.PaddingLength); err != nil {
+			return nil, err
 		}
-	} else if strings.Contains(template, "%d") {
-		// Template like "INV%06d"
-		return fmt.Sprintf(template, counter)
-	} else {
-		// Fallback to default format
-		format := "%s%0" + strconv.Itoa(config.PaddingLength) + "d"
-		return fmt.Sprintf(format, config.Prefix, counter)
+		config.PaddingLength = *req.PaddingLength
+	}
+
+	tmpl, err := idformat.Parse(config.FormatTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	start := req.StartCounter
+	if start == 0 {
+		start = 1
+	}
+
+	samples := make([]string, req.Count)
+	for i := range samples {
+		samples[i] = formatID(tmpl, config, start+int64(i), at)
+	}
+
+	return &models.FormatPreviewResponse{
+		Prefix:         prefix,
+		FormatTemplate: tmpl.String(),
+		PaddingLength:  config.PaddingLength,
+		Samples:        samples,
+	}, nil
+}
+
+// formatID formats a counter value according to the prefix configuration
+func formatID(tmpl *idformat.Template, config *models.PrefixConfig, counter int64, generatedAt time.Time) string {
+	return tmpl.Render(idformat.Values{
+		Prefix:  config.Prefix,
+		Counter: counter,
+		Padding: config.PaddingLength,
+		Time:    generatedAt,
+	})
+}
+
+// validatePadding checks that a padding length can be rendered
+func validatePadding(padding int) error {
+	if padding < 1 || padding > idformat.MaxSeqWidth {
+		return fmt.Errorf("%w: padding length must be between 1 and %d", ErrInvalidConfig, idformat.MaxSeqWidth)
+	}
+	return nil
+}
+
+// actor returns the authenticated principal's subject, falling back to the
+// client-supplied user only when authentication is disabled
+func actor(ctx context.Context, supplied string) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.Subject
+	}
+	return supplied
+}
+
+// tenantOf returns the tenant of the authenticated principal. Calls without
+// a principal (authentication disabled) use the default tenant.
+func tenantOf(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.Tenant != "" {
+		return principal.Tenant
+	}
+	return models.DefaultTenant
+}
+
+// authorizePrefix checks the caller's prefix allow-list before any counter
+// value is consumed. Calls without a principal (authentication disabled)
+// are not restricted.
+func authorizePrefix(ctx context.Context, prefix string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
 	}
+	return principal.AuthorizePrefix(prefix)
 }
 
 // Helper function to create string pointer
@@ -0,0 +1,697 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/internal/config"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+	"github.com/putram11/sequential-id-counter-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryCounters is an in-memory counter store. Idempotency methods are not
+// implemented. Failures are injected per method with failWith.
+type memoryCounters struct {
+	repository.CounterBackend
+
+	mu       sync.Mutex
+	errs     map[string]error
+	counters map[string]int64
+}
+
+func newMemoryCounters() *memoryCounters {
+	return &memoryCounters{errs: make(map[string]error), counters: make(map[string]int64)}
+}
+
+// failWith makes every later call of method return err
+func (m *memoryCounters) failWith(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs[method] = err
+}
+
+// value returns a counter without going through the fault injection
+func (m *memoryCounters) value(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+func (m *memoryCounters) IncrementCounter(_ context.Context, name string) (int64, error) {
+	return m.increment("IncrementCounter", name, 1)
+}
+
+func (m *memoryCounters) IncrementCounterBy(_ context.Context, name string, increment int64) (int64, error) {
+	return m.increment("IncrementCounterBy", name, increment)
+}
+
+func (m *memoryCounters) increment(method, name string, increment int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs[method]; err != nil {
+		return 0, err
+	}
+	m.counters[name] += increment
+	return m.counters[name], nil
+}
+
+func (m *memoryCounters) GetCounter(_ context.Context, name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetCounter"]; err != nil {
+		return 0, err
+	}
+	return m.counters[name], nil
+}
+
+func (m *memoryCounters) SetCounter(_ context.Context, name string, value int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["SetCounter"]; err != nil {
+		return err
+	}
+	m.counters[name] = value
+	return nil
+}
+
+func (m *memoryCounters) ResetCounter(_ context.Context, name string, newValue int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["ResetCounter"]; err != nil {
+		return 0, err
+	}
+	old := m.counters[name]
+	m.counters[name] = newValue
+	return old, nil
+}
+
+func (m *memoryCounters) CompareAndSetCounter(_ context.Context, name string, oldValue, newValue int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["CompareAndSetCounter"]; err != nil {
+		return false, err
+	}
+	if m.counters[name] != oldValue {
+		return false, nil
+	}
+	m.counters[name] = newValue
+	return true, nil
+}
+
+func (m *memoryCounters) Backend() string { return "memory" }
+
+func (m *memoryCounters) Ping(context.Context) error { return nil }
+
+// memoryDatabase keeps prefix configurations, their history, reservations
+// and audit logs in memory. Methods the tests do not use are not
+// implemented. Failures are injected per method with failWith.
+type memoryDatabase struct {
+	repository.Database
+
+	mu           sync.Mutex
+	errs         map[string]error
+	configs      map[string]models.PrefixConfig
+	history      []models.ConfigChange
+	reservations []*models.Reservation
+	logs         []models.AuditLog
+	outbox       []*models.Event
+	dlqAudits    []models.DeadLetterAudit
+	nextID       int64
+}
+
+func newMemoryDatabase() *memoryDatabase {
+	return &memoryDatabase{errs: make(map[string]error), configs: make(map[string]models.PrefixConfig)}
+}
+
+// failWith makes every later call of method return err
+func (m *memoryDatabase) failWith(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs[method] = err
+}
+
+// addConfig stores a configuration as if it had been created earlier
+func (m *memoryDatabase) addConfig(config models.PrefixConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if config.Tenant == "" {
+		config.Tenant = models.DefaultTenant
+	}
+	m.nextID++
+	config.ID = m.nextID
+	m.configs[configKey(config.Tenant, config.Prefix)] = config
+}
+
+func (m *memoryDatabase) GetPrefixConfig(_ context.Context, tenant, prefix string) (*models.PrefixConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetPrefixConfig"]; err != nil {
+		return nil, err
+	}
+	config, ok := m.configs[configKey(tenant, prefix)]
+	if !ok {
+		return nil, nil
+	}
+	return &config, nil
+}
+
+func (m *memoryDatabase) GetAllPrefixConfigs(ctx context.Context) ([]models.PrefixConfig, error) {
+	return m.GetTenantPrefixConfigs(ctx, "")
+}
+
+// GetTenantPrefixConfigs returns the configurations of a tenant, or of every
+// tenant when it is empty, ordered by prefix
+func (m *memoryDatabase) GetTenantPrefixConfigs(_ context.Context, tenant string) ([]models.PrefixConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetTenantPrefixConfigs"]; err != nil {
+		return nil, err
+	}
+	var configs []models.PrefixConfig
+	for _, config := range m.configs {
+		if tenant == "" || config.Tenant == tenant {
+			configs = append(configs, config)
+		}
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configKey(configs[i].Tenant, configs[i].Prefix) < configKey(configs[j].Tenant, configs[j].Prefix)
+	})
+	return configs, nil
+}
+
+func (m *memoryDatabase) CreatePrefixConfig(_ context.Context, config *models.PrefixConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["CreatePrefixConfig"]; err != nil {
+		return err
+	}
+	key := configKey(config.Tenant, config.Prefix)
+	if _, ok := m.configs[key]; ok {
+		return fmt.Errorf("%w: %s", repository.ErrPrefixExists, config.Prefix)
+	}
+	m.nextID++
+	config.ID = m.nextID
+	m.configs[key] = *config
+	m.record(config.Tenant, config.Prefix, models.ConfigChangeCreate, stringValue(config.CreatedBy), nil, config)
+	return nil
+}
+
+func (m *memoryDatabase) UpdatePrefixConfig(_ context.Context, tenant, prefix string, updates map[string]interface{}, changeType, adminUser string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["UpdatePrefixConfig"]; err != nil {
+		return err
+	}
+	key := configKey(tenant, prefix)
+	old, ok := m.configs[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", repository.ErrPrefixNotFound, prefix)
+	}
+	updated := old
+	for field, value := range updates {
+		switch field {
+		case "padding_length":
+			updated.PaddingLength = value.(int)
+		case "format_template":
+			updated.FormatTemplate = value.(string)
+		case "reset_rule":
+			updated.ResetRule = value.(string)
+		case "gapless":
+			updated.Gapless = value.(bool)
+		case "block_size":
+			updated.BlockSize = value.(int)
+		case "archived_at":
+			updated.ArchivedAt = value.(*time.Time)
+		case "updated_by":
+			user := value.(string)
+			updated.UpdatedBy = &user
+		default:
+			return fmt.Errorf("unknown field %s", field)
+		}
+	}
+	m.configs[key] = updated
+	m.record(tenant, prefix, changeType, adminUser, &old, &updated)
+	return nil
+}
+
+func (m *memoryDatabase) DeletePrefixConfig(_ context.Context, tenant, prefix string, force bool, adminUser, reason string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["DeletePrefixConfig"]; err != nil {
+		return false, err
+	}
+	key := configKey(tenant, prefix)
+	old, ok := m.configs[key]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", repository.ErrPrefixNotFound, prefix)
+	}
+	if !force {
+		for _, log := range m.logs {
+			if log.Tenant == tenant && log.Prefix == prefix {
+				return false, nil
+			}
+		}
+	}
+	delete(m.configs, key)
+	m.record(tenant, prefix, models.ConfigChangeDelete, adminUser, &old, nil)
+	m.history[len(m.history)-1].Reason = reason
+	return true, nil
+}
+
+// record appends a change to the config history; m.mu is held
+func (m *memoryDatabase) record(tenant, prefix, changeType, adminUser string, old, new *models.PrefixConfig) {
+	m.history = append(m.history, models.ConfigChange{
+		Version:    int64(len(m.history) + 1),
+		Tenant:     tenant,
+		Prefix:     prefix,
+		ChangeType: changeType,
+		OldConfig:  old,
+		NewConfig:  new,
+		AdminUser:  adminUser,
+		ChangedAt:  time.Now(),
+	})
+}
+
+func (m *memoryDatabase) GetConfigHistory(_ context.Context, tenant, prefix string, limit int) ([]models.ConfigChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetConfigHistory"]; err != nil {
+		return nil, err
+	}
+	var changes []models.ConfigChange
+	for i := len(m.history) - 1; i >= 0 && len(changes) < limit; i-- {
+		if change := m.history[i]; change.Tenant == tenant && change.Prefix == prefix {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *memoryDatabase) GetConfigChange(_ context.Context, version int64) (*models.ConfigChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetConfigChange"]; err != nil {
+		return nil, err
+	}
+	if version < 1 || version > int64(len(m.history)) {
+		return nil, nil
+	}
+	change := m.history[version-1]
+	return &change, nil
+}
+
+func (m *memoryDatabase) QueryAuditLogs(context.Context, *models.AuditQuery, *models.AuditCursor, int) ([]models.AuditLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return nil, m.errs["QueryAuditLogs"]
+}
+
+func (m *memoryDatabase) GetAuditLogsByFullNumber(_ context.Context, tenant, fullNumber string) ([]models.AuditLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetAuditLogsByFullNumber"]; err != nil {
+		return nil, err
+	}
+	var logs []models.AuditLog
+	for _, log := range m.logs {
+		if log.Tenant == tenant && log.FullNumber == fullNumber {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (m *memoryDatabase) GetResetLogs(context.Context, string, string, string) ([]models.ResetLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return nil, m.errs["GetResetLogs"]
+}
+
+func (m *memoryDatabase) GetLastIssuedTimes(_ context.Context, tenant string) (map[string]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetLastIssuedTimes"]; err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time)
+	for _, log := range m.logs {
+		if log.Tenant == tenant && log.Status == models.AuditStatusIssued && log.GeneratedAt.After(times[log.Prefix]) {
+			times[log.Prefix] = log.GeneratedAt
+		}
+	}
+	return times, nil
+}
+
+func (m *memoryDatabase) GetResetHistory(context.Context, string, string, *string, int) ([]models.ResetLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return nil, m.errs["GetResetHistory"]
+}
+
+// ReuseReservation leases the lowest released or expired number of the
+// reservation's prefix period
+func (m *memoryDatabase) ReuseReservation(_ context.Context, res *models.Reservation) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["ReuseReservation"]; err != nil {
+		return false, err
+	}
+	var reuse *models.Reservation
+	now := time.Now()
+	for _, r := range m.reservations {
+		if r.Tenant != res.Tenant || r.Prefix != res.Prefix || r.PeriodKey != res.PeriodKey {
+			continue
+		}
+		if r.Status != models.ReservationStatusReleased && now.Before(r.ExpiresAt) {
+			continue
+		}
+		if reuse == nil || r.CounterValue < reuse.CounterValue {
+			reuse = r
+		}
+	}
+	if reuse == nil {
+		return false, nil
+	}
+	reuse.ReservationID, reuse.Status = res.ReservationID, models.ReservationStatusReserved
+	reuse.ClientID, reuse.GeneratedBy = res.ClientID, res.GeneratedBy
+	reuse.ReservedAt, reuse.ExpiresAt = now, res.ExpiresAt
+	res.ID, res.CounterValue, res.FullNumber, res.ReservedAt = reuse.ID, reuse.CounterValue, reuse.FullNumber, now
+	return true, nil
+}
+
+// InsertReservation enforces the unique reservation IDs and counter values
+// of seq_reservation
+func (m *memoryDatabase) InsertReservation(ctx context.Context, res *models.Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["InsertReservation"]; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, r := range m.reservations {
+		if r.ReservationID == res.ReservationID ||
+			r.Tenant == res.Tenant && r.Prefix == res.Prefix && r.PeriodKey == res.PeriodKey && r.CounterValue == res.CounterValue {
+			return fmt.Errorf("duplicate reservation of counter %d", res.CounterValue)
+		}
+	}
+	m.nextID++
+	res.ID, res.ReservedAt = m.nextID, time.Now()
+	stored := *res
+	m.reservations = append(m.reservations, &stored)
+	return nil
+}
+
+func (m *memoryDatabase) GetReservation(_ context.Context, reservationID string) (*models.Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["GetReservation"]; err != nil {
+		return nil, err
+	}
+	for _, r := range m.reservations {
+		if r.ReservationID == reservationID {
+			res := *r
+			return &res, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryDatabase) CommitReservation(_ context.Context, reservationID string, log *models.AuditLog) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["CommitReservation"]; err != nil {
+		return false, err
+	}
+	for i, r := range m.reservations {
+		if r.ReservationID == reservationID && r.Status == models.ReservationStatusReserved && time.Now().Before(r.ExpiresAt) {
+			m.reservations = append(m.reservations[:i], m.reservations[i+1:]...)
+			m.nextID++
+			log.ID, log.InsertedAt = m.nextID, time.Now()
+			m.logs = append(m.logs, *log)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryDatabase) ReleaseReservation(_ context.Context, reservationID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["ReleaseReservation"]; err != nil {
+		return false, err
+	}
+	for _, r := range m.reservations {
+		if r.ReservationID == reservationID && r.Status == models.ReservationStatusReserved {
+			r.Status = models.ReservationStatusReleased
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryDatabase) MarkPeriodReset(_ context.Context, tenant, prefix string, periodStart time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["MarkPeriodReset"]; err != nil {
+		return err
+	}
+	key := configKey(tenant, prefix)
+	if config, ok := m.configs[key]; ok {
+		config.LastResetAt = &periodStart
+		m.configs[key] = config
+	}
+	return nil
+}
+
+func (m *memoryDatabase) InsertOutboxEvents(_ context.Context, events []*models.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["InsertOutboxEvents"]; err != nil {
+		return err
+	}
+	m.outbox = append(m.outbox, events...)
+	return nil
+}
+
+func (m *memoryDatabase) InsertLostAuditLogs(ctx context.Context, logs []models.AuditLog) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["InsertLostAuditLogs"]; err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var inserted int64
+	for _, log := range logs {
+		if existing := m.auditLog(log.Tenant, log.Prefix, log.PeriodKey, log.CounterValue); existing != nil {
+			if existing.MessageID == log.MessageID && existing.Status == models.AuditStatusIssued {
+				existing.Status = models.AuditStatusLost
+				inserted++
+			}
+			continue
+		}
+		m.nextID++
+		log.ID, log.InsertedAt = m.nextID, time.Now()
+		m.logs = append(m.logs, log)
+		inserted++
+	}
+	return inserted, nil
+}
+
+// auditLog finds the entry of a counter value; callers hold m.mu
+func (m *memoryDatabase) auditLog(tenant, prefix, periodKey string, counter int64) *models.AuditLog {
+	for i := range m.logs {
+		log := &m.logs[i]
+		if log.Tenant == tenant && log.Prefix == prefix && log.PeriodKey == periodKey && log.CounterValue == counter {
+			return log
+		}
+	}
+	return nil
+}
+
+// issue records an ID as the worker would on receiving its audit event
+func (m *memoryDatabase) issue(event *models.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing := m.auditLog(event.Tenant, event.Prefix, event.PeriodKey, event.Counter); existing != nil {
+		if existing.Status == models.AuditStatusLost && existing.MessageID != event.MessageID {
+			existing.MessageID, existing.Status = event.MessageID, models.AuditStatusIssued
+		}
+		return
+	}
+	m.nextID++
+	m.logs = append(m.logs, models.AuditLog{
+		ID:           m.nextID,
+		Tenant:       event.Tenant,
+		Prefix:       event.Prefix,
+		CounterValue: event.Counter,
+		PeriodKey:    event.PeriodKey,
+		FullNumber:   event.FullNumber,
+		MessageID:    event.MessageID,
+		GeneratedAt:  event.GeneratedAt,
+		Status:       models.AuditStatusIssued,
+	})
+}
+
+func (m *memoryDatabase) InsertDeadLetterAudit(_ context.Context, audit *models.DeadLetterAudit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["InsertDeadLetterAudit"]; err != nil {
+		return err
+	}
+	m.nextID++
+	audit.ID, audit.HandledAt = m.nextID, time.Now()
+	m.dlqAudits = append(m.dlqAudits, *audit)
+	return nil
+}
+
+// outboxEvents returns a copy of the events written to the outbox
+func (m *memoryDatabase) outboxEvents() []*models.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Event(nil), m.outbox...)
+}
+
+// expireReservation lets the lease of a reservation run out
+func (m *memoryDatabase) expireReservation(reservationID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.reservations {
+		if r.ReservationID == reservationID {
+			r.ExpiresAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+// pool returns the counter values and statuses of the stored reservations
+func (m *memoryDatabase) pool() map[int64]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool := make(map[int64]string, len(m.reservations))
+	for _, r := range m.reservations {
+		pool[r.CounterValue] = r.Status
+	}
+	return pool
+}
+
+// auditLogs returns a copy of the stored audit logs
+func (m *memoryDatabase) auditLogs() []models.AuditLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.AuditLog(nil), m.logs...)
+}
+
+// newTestService creates a service on the given stores with the default
+// configuration and a silent logger
+// memoryBroker is an in-memory event broker. PublishEvent fails with
+// publishErr once failAfter events have been published; a negative
+// failAfter never fails. Dead letter methods fail per method with failWith;
+// replays and drops stop with scanErr after scanFailAfter handled messages.
+type memoryBroker struct {
+	repository.EventBroker
+	mu            sync.Mutex
+	published     []*models.Event
+	failAfter     int
+	publishErr    error
+	deadLetters   []models.DeadLetter
+	errs          map[string]error
+	scanFailAfter int
+	scanErr       error
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{failAfter: -1, errs: make(map[string]error), scanFailAfter: -1}
+}
+
+func (b *memoryBroker) failWith(method string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errs[method] = err
+}
+
+func (b *memoryBroker) PeekDeadLetters(_ context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return b.scan("PeekDeadLetters", limit, match, false)
+}
+
+// ReplayDeadLetters moves the matching dead letters to the published events
+func (b *memoryBroker) ReplayDeadLetters(_ context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return b.scan("ReplayDeadLetters", limit, match, true)
+}
+
+func (b *memoryBroker) DropDeadLetters(_ context.Context, limit int, match func(*models.DeadLetter) bool) ([]models.DeadLetter, int, error) {
+	return b.scan("DropDeadLetters", limit, match, true)
+}
+
+// scan matches the first limit dead letters, removing the matching ones
+// when remove is set
+func (b *memoryBroker) scan(method string, limit int, match func(*models.DeadLetter) bool, remove bool) ([]models.DeadLetter, int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.errs[method]; err != nil {
+		return nil, 0, err
+	}
+	var matched, kept []models.DeadLetter
+	scanned := 0
+	for i, dl := range b.deadLetters {
+		if scanned == limit || (remove && len(matched) == b.scanFailAfter) {
+			kept = append(kept, b.deadLetters[i:]...)
+			break
+		}
+		scanned++
+		if !match(&dl) {
+			kept = append(kept, dl)
+			continue
+		}
+		matched = append(matched, dl)
+		if !remove {
+			kept = append(kept, dl)
+		} else if method == "ReplayDeadLetters" && dl.Event != nil {
+			b.published = append(b.published, dl.Event)
+		}
+	}
+	b.deadLetters = kept
+	if remove && len(matched) == b.scanFailAfter {
+		return matched, scanned, b.scanErr
+	}
+	return matched, scanned, nil
+}
+
+func (b *memoryBroker) DeadLetterDepth(context.Context) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.errs["DeadLetterDepth"]; err != nil {
+		return 0, err
+	}
+	return len(b.deadLetters), nil
+}
+
+func (b *memoryBroker) PublishEvent(_ context.Context, event *models.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failAfter >= 0 && len(b.published) >= b.failAfter {
+		return b.publishErr
+	}
+	b.published = append(b.published, event)
+	return nil
+}
+
+// publishedEvents returns a copy of the published events
+func (b *memoryBroker) publishedEvents() []*models.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*models.Event(nil), b.published...)
+}
+
+func newTestService(counters repository.CounterBackend, db repository.Database, broker repository.EventBroker) *SequentialIDService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewSequentialIDService(counters, db, broker, config.Default(), logger)
+}
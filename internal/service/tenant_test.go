@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/putram11/sequential-id-counter-service/internal/auth"
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+func TestTenantsHaveSeparateSequences(t *testing.T) {
+	counters, db := newMemoryCounters(), newMemoryDatabase()
+	inv := models.PrefixConfig{Prefix: "INV", PaddingLength: 4, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever}
+	db.addConfig(inv)
+	inv.Tenant = "acme"
+	db.addConfig(inv)
+	db.addConfig(models.PrefixConfig{Prefix: "SG", PaddingLength: 6, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever})
+	s := newTestService(counters, db, nil)
+
+	ctx := context.Background()
+	acme := auth.WithPrincipal(ctx, &auth.Principal{Subject: "alice", Tenant: "acme"})
+	for _, want := range []int64{1, 2} {
+		if id, err := s.GetNext(ctx, &models.NextRequest{Prefix: "INV"}); err != nil || id.Counter != want {
+			t.Fatalf("GetNext(INV) = %+v, %v; want counter %d", id, err, want)
+		}
+	}
+	id, err := s.GetNext(acme, &models.NextRequest{Prefix: "INV"})
+	if err != nil {
+		t.Fatalf("GetNext(acme INV): %v", err)
+	}
+	if id.Tenant != "acme" || id.Counter != 1 || id.FullNumber != "INV0001" {
+		t.Errorf("GetNext(acme INV) = %+v, want acme's INV0001", id)
+	}
+	if got := counters.value("INV"); got != 2 {
+		t.Errorf("default INV counter = %d, want 2", got)
+	}
+	if got := counters.value("acme:INV"); got != 1 {
+		t.Errorf("acme INV counter = %d, want 1", got)
+	}
+	if events := db.outboxEvents(); events[2].Tenant != "acme" {
+		t.Errorf("audit event of acme's ID has tenant %q", events[2].Tenant)
+	}
+
+	// Prefixes of other tenants are unknown
+	if _, err := s.GetNext(acme, &models.NextRequest{Prefix: "SG"}); !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("GetNext(acme SG) = %v, want ErrPrefixNotFound", err)
+	}
+	if got := counters.value("acme:SG"); got != 0 {
+		t.Errorf("acme SG counter advanced to %d", got)
+	}
+	if err := s.UpdateConfig(acme, "PO", &models.ConfigUpdateRequest{AdminUser: "alice", CreateIfNotExists: true}); err != nil {
+		t.Fatalf("UpdateConfig(acme PO): %v", err)
+	}
+	if _, err := s.GetNext(ctx, &models.NextRequest{Prefix: "PO"}); !errors.Is(err, ErrPrefixNotFound) {
+		t.Errorf("GetNext(PO) of the default tenant = %v, want ErrPrefixNotFound", err)
+	}
+	if history, _ := s.GetConfigHistory(ctx, "PO", 0); len(history) != 0 {
+		t.Errorf("default tenant sees acme's PO history: %+v", history)
+	}
+}
+
+func TestLookupIsScopedToTheTenant(t *testing.T) {
+	db := newMemoryDatabase()
+	inv := models.PrefixConfig{Prefix: "INV", PaddingLength: 4, FormatTemplate: DefaultFormatTemplate, ResetRule: models.ResetRuleNever}
+	db.addConfig(inv)
+	inv.Tenant = "acme"
+	db.addConfig(inv)
+	s := newTestService(newMemoryCounters(), db, nil)
+
+	ctx := context.Background()
+	acme := auth.WithPrincipal(ctx, &auth.Principal{Subject: "alice", Tenant: "acme"})
+	globex := auth.WithPrincipal(ctx, &auth.Principal{Subject: "bob", Tenant: "globex"})
+	for _, c := range []context.Context{ctx, acme, ctx} {
+		if _, err := s.GetNext(c, &models.NextRequest{Prefix: "INV"}); err != nil {
+			t.Fatalf("GetNext: %v", err)
+		}
+	}
+	db.issue(db.outboxEvents()[1])
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		fullNumber string
+		status     string // "" when not found
+	}{
+		{"audited", acme, "INV0001", models.IDStatusAudited},
+		{"issued but not audited", ctx, "INV0001", models.IDStatusIssuedNotAudited},
+		{"not issued by this tenant", acme, "INV0002", ""},
+		{"unknown tenant", globex, "INV0001", ""},
+	}
+	for _, tt := range tests {
+		lookup, err := s.LookupID(tt.ctx, tt.fullNumber)
+		if err != nil {
+			t.Fatalf("%s: LookupID: %v", tt.name, err)
+		}
+		switch {
+		case tt.status == "" && lookup != nil:
+			t.Errorf("%s: LookupID = %+v, want nil", tt.name, lookup)
+		case tt.status != "" && (lookup == nil || lookup.Status != tt.status || lookup.Tenant != tenantOf(tt.ctx)):
+			t.Errorf("%s: LookupID = %+v, want %s in tenant %s", tt.name, lookup, tt.status, tenantOf(tt.ctx))
+		}
+	}
+}
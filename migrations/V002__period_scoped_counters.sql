@@ -0,0 +1,34 @@
+-- V002__period_scoped_counters.sql
+-- Period-scoped counters for prefixes with a daily, monthly or yearly reset rule
+
+-- Counter values restart every period, so the period becomes part of the identity
+ALTER TABLE seq_log ADD COLUMN period_key VARCHAR(20) NOT NULL DEFAULT '';
+
+ALTER TABLE seq_log DROP CONSTRAINT seq_log_prefix_counter_value_key;
+ALTER TABLE seq_log ADD CONSTRAINT seq_log_prefix_period_counter_key
+    UNIQUE (prefix, period_key, counter_value);
+
+DROP INDEX IF EXISTS idx_seq_log_prefix_counter;
+CREATE INDEX idx_seq_log_prefix_period_counter ON seq_log(prefix, period_key, counter_value);
+
+-- Checkpoints and resets refer to the period that was active at the time
+ALTER TABLE seq_checkpoint ADD COLUMN period_key VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE seq_reset_log ADD COLUMN period_key VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Max counter lookup within a period (used by reconciliation)
+CREATE OR REPLACE FUNCTION get_max_counter(p_prefix VARCHAR(50), p_period_key VARCHAR(20))
+RETURNS BIGINT AS $$
+DECLARE
+    max_counter BIGINT;
+BEGIN
+    SELECT COALESCE(MAX(counter_value), 0) INTO max_counter
+    FROM seq_log
+    WHERE prefix = p_prefix AND period_key = p_period_key;
+
+    RETURN max_counter;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN seq_log.period_key IS 'Reset period of the counter value: YYYY, YYYYMM, YYYYMMDD or empty for never';
+COMMENT ON COLUMN seq_checkpoint.period_key IS 'Reset period the checkpoint refers to';
+COMMENT ON COLUMN seq_reset_log.period_key IS 'Reset period whose counter was reset';
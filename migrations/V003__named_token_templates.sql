@@ -0,0 +1,44 @@
+-- V003__named_token_templates.sql
+-- Replace printf-style format templates with named-token templates
+-- (e.g. '%s%06d' becomes '{prefix}{seq:6}')
+
+ALTER TABLE seq_config DROP CONSTRAINT chk_format_template;
+
+-- Templates shipped with V001 whose meaning cannot be derived mechanically
+UPDATE seq_config SET format_template = '{prefix}{yyyy}-{seq:4}' WHERE format_template = 'INV%d-%04d';
+
+-- Generic conversion of the remaining printf templates
+UPDATE seq_config
+SET format_template = regexp_replace(
+        regexp_replace(
+            regexp_replace(format_template, '%s', '{prefix}', 'g'),
+            '%0?([0-9]+)d', '{seq:\1}', 'g'),
+        '%d', '{seq}', 'g')
+WHERE position('%' in format_template) > 0;
+
+ALTER TABLE seq_config ALTER COLUMN format_template SET DEFAULT '{prefix}{seq}';
+
+-- Basic validation - full parsing happens in the service on update
+CREATE OR REPLACE FUNCTION validate_format_template(template TEXT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    IF template IS NULL OR template = '' THEN
+        RETURN FALSE;
+    END IF;
+
+    -- Must contain a sequence token
+    IF template !~ '\{seq(:[0-9]+)?\}' THEN
+        RETURN FALSE;
+    END IF;
+
+    RETURN TRUE;
+EXCEPTION
+    WHEN OTHERS THEN
+        RETURN FALSE;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE seq_config ADD CONSTRAINT chk_format_template
+    CHECK (validate_format_template(format_template));
+
+COMMENT ON COLUMN seq_config.format_template IS 'Named-token template, e.g. {prefix}{yyyy}-{seq:6}{checksum}';
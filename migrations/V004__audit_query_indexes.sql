@@ -0,0 +1,10 @@
+-- V004__audit_query_indexes.sql
+-- Indexes supporting keyset pagination of audit log queries.
+-- Pages are ordered by (generated_at DESC, id DESC), optionally within a prefix.
+
+CREATE INDEX idx_seq_log_generated_at_id ON seq_log(generated_at DESC, id DESC);
+CREATE INDEX idx_seq_log_prefix_generated_at_id ON seq_log(prefix, generated_at DESC, id DESC);
+CREATE INDEX idx_seq_log_correlation_id ON seq_log(correlation_id) WHERE correlation_id IS NOT NULL;
+
+-- Superseded by idx_seq_log_generated_at_id
+DROP INDEX IF EXISTS idx_seq_log_generated_at;
@@ -0,0 +1,8 @@
+-- V005__audit_gap_placeholders.sql
+-- Distinguish real audit entries from placeholders written by the reconcile
+-- tool for counter values that were issued but never audited
+
+ALTER TABLE seq_log ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'issued'
+    CHECK (status IN ('issued', 'lost'));
+
+CREATE INDEX idx_seq_log_lost ON seq_log(prefix, period_key) WHERE status = 'lost';
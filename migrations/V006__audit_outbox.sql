@@ -0,0 +1,16 @@
+-- V006__audit_outbox.sql
+-- Durable outbox for audit events. The API writes an event here before
+-- returning the ID; a relay publishes it to RabbitMQ and deletes the row.
+
+CREATE TABLE seq_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(255) UNIQUE NOT NULL,
+    prefix VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_seq_outbox_next_attempt ON seq_outbox(next_attempt_at, id);
@@ -0,0 +1,26 @@
+-- V007__gapless_reservations.sql
+-- Opt-in gapless mode: numbers are reserved with a lease and only enter
+-- seq_log when committed. Released or expired reservations are reused,
+-- lowest number first, before the counter advances.
+
+ALTER TABLE seq_config ADD COLUMN gapless BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE seq_reservation (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id VARCHAR(255) UNIQUE NOT NULL,
+    prefix VARCHAR(50) NOT NULL,
+    period_key VARCHAR(20) NOT NULL DEFAULT '',
+    counter_value BIGINT NOT NULL,
+    full_number VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'reserved' CHECK (status IN ('reserved', 'released')),
+    client_id VARCHAR(100),
+    generated_by VARCHAR(100),
+    reserved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    -- A number is held by at most one reservation
+    UNIQUE(prefix, period_key, counter_value)
+);
+
+COMMENT ON COLUMN seq_config.gapless IS 'Numbers are issued through reserve/commit/release instead of GetNext';
+COMMENT ON COLUMN seq_reservation.status IS 'reserved while leased; released numbers (and expired leases) are reused';
@@ -0,0 +1,20 @@
+-- V008__postgres_counter_store.sql
+-- Tables of the Postgres counter backend (COUNTER_BACKEND=postgres), used by
+-- deployments without Redis. Unused with the Redis backend.
+
+CREATE TABLE seq_counter (
+    name VARCHAR(100) PRIMARY KEY, -- prefix, or prefix:period for resetting prefixes
+    value BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE seq_idempotency (
+    key VARCHAR(64) PRIMARY KEY,
+    record BYTEA NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_seq_idempotency_expires ON seq_idempotency(expires_at);
+
+COMMENT ON TABLE seq_counter IS 'Counter values of the Postgres counter backend; the Redis backend keeps them in seq:<name> keys';
+COMMENT ON TABLE seq_idempotency IS 'Idempotency records of the Postgres counter backend; expired rows are replaced or purged on write';
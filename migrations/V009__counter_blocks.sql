@@ -0,0 +1,8 @@
+-- V009__counter_blocks.sql
+-- Optional hi/lo allocation: with block_size > 1 each API instance leases
+-- that many counter values at once and serves GetNext from memory.
+
+ALTER TABLE seq_config ADD COLUMN block_size INTEGER NOT NULL DEFAULT 0
+    CHECK (block_size >= 0 AND block_size <= 10000);
+
+COMMENT ON COLUMN seq_config.block_size IS 'Counter values leased per API instance at once; 0 or 1 allocates one at a time';
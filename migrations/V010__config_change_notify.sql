@@ -0,0 +1,15 @@
+-- V010__config_change_notify.sql
+-- Announce prefix configuration changes so API instances can drop cached
+-- configuration. The payload is the prefix; changes made directly in SQL
+-- are announced as well.
+
+CREATE OR REPLACE FUNCTION seq_config_notify() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('seq_config_changed', COALESCE(NEW.prefix, OLD.prefix));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER seq_config_changed
+    AFTER INSERT OR UPDATE OR DELETE ON seq_config
+    FOR EACH ROW EXECUTE FUNCTION seq_config_notify();
@@ -0,0 +1,15 @@
+-- V011__config_audit_diff.sql
+-- Record every prefix configuration change with the changed fields, and
+-- allow deletions and rollbacks in the history.
+
+ALTER TABLE seq_config_audit ALTER COLUMN new_config DROP NOT NULL;
+ALTER TABLE seq_config_audit ADD COLUMN diff JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE seq_config_audit DROP CONSTRAINT seq_config_audit_change_type_check;
+ALTER TABLE seq_config_audit ADD CONSTRAINT seq_config_audit_change_type_check
+    CHECK (change_type IN ('CREATE', 'UPDATE', 'DELETE', 'ROLLBACK'));
+
+CREATE INDEX idx_seq_config_audit_prefix_id ON seq_config_audit(prefix, id DESC);
+
+COMMENT ON COLUMN seq_config_audit.diff IS 'Changed fields as {"field": {"old": ..., "new": ...}}';
+COMMENT ON COLUMN seq_config_audit.new_config IS 'Configuration after the change; NULL for DELETE';
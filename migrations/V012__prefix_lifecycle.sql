@@ -0,0 +1,12 @@
+-- V012__prefix_lifecycle.sql
+-- Archived prefixes keep their configuration and history but reject new
+-- issuance. Deletions record the reason they were forced.
+
+ALTER TABLE seq_config ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE seq_config_audit ADD COLUMN reason TEXT;
+
+-- Last issued time per prefix for the prefix listing
+CREATE INDEX idx_seq_log_prefix_generated_at ON seq_log(prefix, generated_at DESC);
+
+COMMENT ON COLUMN seq_config.archived_at IS 'Set while the prefix is archived; archived prefixes reject new IDs';
+COMMENT ON COLUMN seq_config_audit.reason IS 'Reason given for forced deletions';
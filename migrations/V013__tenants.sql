@@ -0,0 +1,52 @@
+-- V013__tenants.sql
+-- Prefixes are namespaced by tenant so teams can each configure their own
+-- sequence under the same prefix. Existing rows belong to the 'default'
+-- tenant, whose counters keep their previous names.
+
+ALTER TABLE seq_config ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE seq_config DROP CONSTRAINT seq_config_prefix_key;
+ALTER TABLE seq_config ADD CONSTRAINT seq_config_tenant_prefix_key UNIQUE (tenant, prefix);
+
+ALTER TABLE seq_log ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE seq_log DROP CONSTRAINT seq_log_prefix_period_counter_key;
+ALTER TABLE seq_log ADD CONSTRAINT seq_log_tenant_prefix_period_counter_key
+    UNIQUE (tenant, prefix, period_key, counter_value);
+
+DROP INDEX IF EXISTS idx_seq_log_prefix_period_counter;
+DROP INDEX IF EXISTS idx_seq_log_prefix_generated_at_id;
+DROP INDEX IF EXISTS idx_seq_log_prefix_generated_at;
+DROP INDEX IF EXISTS idx_seq_log_lost;
+CREATE INDEX idx_seq_log_tenant_prefix_generated_at_id ON seq_log(tenant, prefix, generated_at DESC, id DESC);
+CREATE INDEX idx_seq_log_lost ON seq_log(tenant, prefix, period_key) WHERE status = 'lost';
+
+ALTER TABLE seq_checkpoint ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE seq_checkpoint DROP CONSTRAINT seq_checkpoint_pkey;
+ALTER TABLE seq_checkpoint ADD PRIMARY KEY (tenant, prefix);
+
+ALTER TABLE seq_reset_log ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS idx_seq_reset_log_prefix;
+CREATE INDEX idx_seq_reset_log_tenant_prefix ON seq_reset_log(tenant, prefix);
+
+ALTER TABLE seq_config_audit ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS idx_seq_config_audit_prefix_id;
+CREATE INDEX idx_seq_config_audit_tenant_prefix_id ON seq_config_audit(tenant, prefix, id DESC);
+
+ALTER TABLE seq_reservation ADD COLUMN tenant VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE seq_reservation DROP CONSTRAINT seq_reservation_prefix_period_key_counter_value_key;
+ALTER TABLE seq_reservation ADD CONSTRAINT seq_reservation_tenant_prefix_period_counter_key
+    UNIQUE (tenant, prefix, period_key, counter_value);
+
+-- Counter names of other tenants are tenant:prefix[:period]
+ALTER TABLE seq_counter ALTER COLUMN name TYPE VARCHAR(200);
+
+-- The change notification payload becomes tenant:prefix
+CREATE OR REPLACE FUNCTION seq_config_notify() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('seq_config_changed',
+        COALESCE(NEW.tenant, OLD.tenant) || ':' || COALESCE(NEW.prefix, OLD.prefix));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN seq_config.tenant IS 'Namespace of the prefix; resolved from the authenticated caller';
+COMMENT ON COLUMN seq_log.tenant IS 'Tenant owning the prefix the ID was issued for';
@@ -0,0 +1,19 @@
+-- V014__dlq_audit.sql
+-- Record operators replaying or purging messages of the dead letter queue.
+
+CREATE TABLE seq_dlq_audit (
+    id BIGSERIAL PRIMARY KEY,
+    tenant VARCHAR(50),
+    action VARCHAR(10) NOT NULL CHECK (action IN ('REPLAY', 'PURGE')),
+    message_ids JSONB NOT NULL DEFAULT '[]',
+    message_count INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    admin_user VARCHAR(100) NOT NULL,
+    handled_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_seq_dlq_audit_handled_at ON seq_dlq_audit(handled_at);
+
+COMMENT ON TABLE seq_dlq_audit IS 'Replays and purges of dead-lettered audit events';
+COMMENT ON COLUMN seq_dlq_audit.tenant IS 'Tenant the request was limited to; NULL for all tenants';
+COMMENT ON COLUMN seq_dlq_audit.message_ids IS 'Message IDs of the replayed or purged events';
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BufferConfig configures a Buffered client
+type BufferConfig struct {
+	// BatchSize is the number of IDs prefetched per refill, at most
+	// MaxBatchSize
+	BatchSize int
+	// LowWater starts a background refill once a prefix has at most this
+	// many buffered IDs left; zero refills only when the buffer is empty
+	LowWater int
+	// MaxAge discards buffered IDs generated longer ago, e.g. so that IDs of
+	// a yearly prefix are not handed out after the period rolled over; zero
+	// keeps IDs until they are used
+	MaxAge time.Duration
+}
+
+// DefaultBufferConfig prefetches 100 IDs and refills at 20
+var DefaultBufferConfig = BufferConfig{
+	BatchSize: 100,
+	LowWater:  20,
+}
+
+// Buffered is a Client that serves Next from IDs prefetched in batches, one
+// buffer per prefix. Refills run in the background with one refill in
+// flight per prefix; callers that find the buffer empty wait for it within
+// their own context.
+//
+// Prefetched IDs are issued and audited by the service when the batch is
+// fetched. IDs that are never handed out, because they expired, the process
+// stopped or Close discarded them, are gaps in the sequence; do not buffer
+// prefixes that must be gapless. Buffered IDs are handed out in order per
+// prefix but not in global order across processes.
+//
+// Next calls with call options, NextBatch and Status bypass the buffer.
+type Buffered struct {
+	client Client
+	cfg    BufferConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	buffers map[string]*buffer
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+var _ Client = (*Buffered)(nil)
+
+// buffer holds the prefetched IDs of a prefix
+type buffer struct {
+	ids    []ID
+	refill *refill
+}
+
+// refill is a background NextBatch call; err is set before done is closed
+type refill struct {
+	done chan struct{}
+	err  error
+}
+
+// NewBuffered wraps client with prefetch buffers
+func NewBuffered(client Client, cfg BufferConfig) *Buffered {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBufferConfig.BatchSize
+	}
+	if cfg.BatchSize > MaxBatchSize {
+		cfg.BatchSize = MaxBatchSize
+	}
+	if cfg.LowWater >= cfg.BatchSize {
+		cfg.LowWater = cfg.BatchSize - 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Buffered{
+		client:  client,
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		buffers: make(map[string]*buffer),
+	}
+}
+
+// Next hands out the next buffered ID of a prefix, waiting for a refill
+// when none is left. The error of a failed refill is returned to the
+// callers waiting for it; the next call starts a new refill.
+func (b *Buffered) Next(ctx context.Context, prefix string, opts ...CallOption) (*ID, error) {
+	if len(opts) > 0 {
+		return b.client.Next(ctx, prefix, opts...)
+	}
+
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return b.client.Next(ctx, prefix)
+		}
+		buf := b.buffer(prefix)
+		b.expire(buf)
+		if len(buf.ids) > 0 {
+			id := buf.ids[0]
+			buf.ids = buf.ids[1:]
+			if len(buf.ids) <= b.cfg.LowWater && buf.refill == nil {
+				b.startRefill(prefix, buf)
+			}
+			b.mu.Unlock()
+			return &id, nil
+		}
+		r := buf.refill
+		if r == nil {
+			r = b.startRefill(prefix, buf)
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-r.done:
+			if r.err != nil {
+				return nil, r.err
+			}
+		case <-ctx.Done():
+			return nil, &Error{Kind: KindUnavailable, Message: "waiting for prefetched IDs", Err: ctx.Err()}
+		}
+	}
+}
+
+// NextBatch issues count consecutive IDs of a prefix, bypassing the buffer
+func (b *Buffered) NextBatch(ctx context.Context, prefix string, count int, opts ...CallOption) (*Batch, error) {
+	return b.client.NextBatch(ctx, prefix, count, opts...)
+}
+
+// Status reports the counter of a prefix and the health of its stores
+func (b *Buffered) Status(ctx context.Context, prefix string) (*Status, error) {
+	return b.client.Status(ctx, prefix)
+}
+
+// Len returns the number of IDs currently buffered for a prefix
+func (b *Buffered) Len(prefix string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if buf, ok := b.buffers[prefix]; ok {
+		return len(buf.ids)
+	}
+	return 0
+}
+
+// Close cancels in-flight refills, waits for them to return and discards
+// the buffered IDs. Later calls go straight to the wrapped client.
+func (b *Buffered) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.buffers = make(map[string]*buffer)
+	b.mu.Unlock()
+
+	b.cancel()
+	b.wg.Wait()
+}
+
+// buffer returns the buffer of a prefix; b.mu must be held
+func (b *Buffered) buffer(prefix string) *buffer {
+	buf, ok := b.buffers[prefix]
+	if !ok {
+		buf = &buffer{}
+		b.buffers[prefix] = buf
+	}
+	return buf
+}
+
+// expire drops IDs older than MaxAge from the front of buf; b.mu must be
+// held
+func (b *Buffered) expire(buf *buffer) {
+	if b.cfg.MaxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-b.cfg.MaxAge)
+	for len(buf.ids) > 0 && buf.ids[0].GeneratedAt.Before(cutoff) {
+		buf.ids = buf.ids[1:]
+	}
+}
+
+// startRefill fetches a batch into buf in the background. The call is
+// bounded by the wrapped client's timeout rather than by the context of the
+// caller that triggered it, so that a canceled caller does not waste the
+// batch. b.mu must be held.
+func (b *Buffered) startRefill(prefix string, buf *buffer) *refill {
+	r := &refill{done: make(chan struct{})}
+	buf.refill = r
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+		batch, err := b.client.NextBatch(b.ctx, prefix, b.cfg.BatchSize)
+
+		b.mu.Lock()
+		buf.refill = nil
+		if err == nil && !b.closed {
+			buf.ids = append(buf.ids, batch.IDs...)
+		}
+		r.err = err
+		b.mu.Unlock()
+		close(r.done)
+	}()
+	return r
+}
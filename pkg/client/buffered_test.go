@@ -0,0 +1,280 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/putram11/sequential-id-counter-service/pkg/client"
+	"github.com/putram11/sequential-id-counter-service/pkg/client/clienttest"
+)
+
+// gatedClient holds NextBatch calls until the gate is opened
+type gatedClient struct {
+	*clienttest.Fake
+	gate chan struct{}
+}
+
+func (g *gatedClient) NextBatch(ctx context.Context, prefix string, count int, opts ...client.CallOption) (*client.Batch, error) {
+	select {
+	case <-g.gate:
+	case <-ctx.Done():
+		return nil, &client.Error{Kind: client.KindUnavailable, Message: "request failed", Err: ctx.Err()}
+	}
+	return g.Fake.NextBatch(ctx, prefix, count, opts...)
+}
+
+func batchCalls(f *clienttest.Fake) []clienttest.Call {
+	var calls []clienttest.Call
+	for _, call := range f.Calls() {
+		if call.Method == "NextBatch" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// eventually polls cond until it holds or a second has passed
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBufferedServesIDsInOrder(t *testing.T) {
+	fake := clienttest.NewFake("SG", "PO")
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 10})
+	defer b.Close()
+	ctx := context.Background()
+
+	for want := int64(1); want <= 5; want++ {
+		for _, prefix := range []string{"SG", "PO"} {
+			id, err := b.Next(ctx, prefix)
+			if err != nil {
+				t.Fatalf("Next(%s): %v", prefix, err)
+			}
+			if id.Prefix != prefix || id.Counter != want {
+				t.Fatalf("Next(%s) = %s %d, want %s %d", prefix, id.Prefix, id.Counter, prefix, want)
+			}
+		}
+	}
+	if calls := batchCalls(fake); len(calls) != 2 {
+		t.Errorf("NextBatch calls = %+v, want one per prefix", calls)
+	}
+	if n := b.Len("SG"); n != 5 {
+		t.Errorf("Len(SG) = %d, want 5", n)
+	}
+}
+
+func TestBufferedBatchSize(t *testing.T) {
+	tests := []struct {
+		batchSize int
+		want      int
+	}{
+		{0, client.DefaultBufferConfig.BatchSize},
+		{-1, client.DefaultBufferConfig.BatchSize},
+		{25, 25},
+		{client.MaxBatchSize + 1, client.MaxBatchSize},
+	}
+	for _, tt := range tests {
+		fake := clienttest.NewFake("SG")
+		b := client.NewBuffered(fake, client.BufferConfig{BatchSize: tt.batchSize})
+		if _, err := b.Next(context.Background(), "SG"); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		b.Close()
+		if calls := batchCalls(fake); len(calls) != 1 || calls[0].Count != tt.want {
+			t.Errorf("BatchSize %d: NextBatch calls = %+v, want one of %d", tt.batchSize, calls, tt.want)
+		}
+	}
+}
+
+func TestBufferedRefillsAtLowWater(t *testing.T) {
+	fake := clienttest.NewFake("SG")
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 10, LowWater: 3})
+	defer b.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := b.Next(context.Background(), "SG"); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	if calls := batchCalls(fake); len(calls) != 1 {
+		t.Fatalf("NextBatch calls above low water = %d, want 1", len(calls))
+	}
+
+	if _, err := b.Next(context.Background(), "SG"); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	eventually(t, "the background refill", func() bool { return b.Len("SG") == 13 })
+	if calls := batchCalls(fake); len(calls) != 2 {
+		t.Errorf("NextBatch calls = %d, want 2", len(calls))
+	}
+}
+
+func TestBufferedConcurrentCallersGetDistinctIDs(t *testing.T) {
+	fake := clienttest.NewFake("SG")
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 7, LowWater: 2})
+	defer b.Close()
+
+	const workers, perWorker = 20, 50
+	ids := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			for i := 0; i < perWorker; i++ {
+				id, err := b.Next(context.Background(), "SG")
+				if err != nil {
+					t.Errorf("Next: %v", err)
+					return
+				}
+				if id.Counter <= last {
+					t.Errorf("Next = %d after %d, want increasing counters per caller", id.Counter, last)
+				}
+				last = id.Counter
+				ids <- id.Counter
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("counter %d handed out twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Errorf("handed out %d IDs, want %d", len(seen), workers*perWorker)
+	}
+}
+
+func TestBufferedReturnsRefillErrors(t *testing.T) {
+	fake := clienttest.NewFake("SG")
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 10})
+	defer b.Close()
+	ctx := context.Background()
+
+	fake.FailNext(&client.Error{Kind: client.KindUnavailable, Code: "COUNTER_STORE_UNAVAILABLE"})
+	if _, err := b.Next(ctx, "SG"); !errors.Is(err, client.ErrUnavailable) {
+		t.Fatalf("Next = %v, want the refill's Unavailable error", err)
+	}
+	id, err := b.Next(ctx, "SG")
+	if err != nil || id.Counter != 1 {
+		t.Fatalf("Next after a failed refill = %+v, %v; want counter 1", id, err)
+	}
+
+	if _, err := b.Next(ctx, "UNKNOWN"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Next(UNKNOWN) = %v, want NotFound", err)
+	}
+}
+
+func TestBufferedCallerCancelKeepsRefill(t *testing.T) {
+	gated := &gatedClient{Fake: clienttest.NewFake("SG"), gate: make(chan struct{})}
+	b := client.NewBuffered(gated, client.BufferConfig{BatchSize: 10})
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := b.Next(ctx, "SG")
+	if !errors.Is(err, client.ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Next = %v, want Unavailable wrapping the caller's deadline", err)
+	}
+
+	close(gated.gate)
+	eventually(t, "the refill started by the canceled caller", func() bool { return b.Len("SG") == 10 })
+	id, err := b.Next(context.Background(), "SG")
+	if err != nil || id.Counter != 1 {
+		t.Fatalf("Next = %+v, %v; want counter 1 from the kept batch", id, err)
+	}
+}
+
+func TestBufferedDiscardsExpiredIDs(t *testing.T) {
+	fake := clienttest.NewFake("SG")
+	stale := true
+	fake.SetClock(func() time.Time {
+		if stale {
+			stale = false
+			return time.Now().Add(-time.Hour)
+		}
+		return time.Now()
+	})
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 10, MaxAge: time.Minute})
+	defer b.Close()
+
+	id, err := b.Next(context.Background(), "SG")
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if id.Counter != 11 {
+		t.Errorf("Next = %d, want 11 from a fresh batch after the stale one expired", id.Counter)
+	}
+	if calls := batchCalls(fake); len(calls) != 2 {
+		t.Errorf("NextBatch calls = %d, want 2", len(calls))
+	}
+}
+
+func TestBufferedBypass(t *testing.T) {
+	fake := clienttest.NewFake("SG")
+	b := client.NewBuffered(fake, client.BufferConfig{BatchSize: 10})
+	ctx := context.Background()
+
+	id, err := b.Next(ctx, "SG", client.WithIdempotencyKey("order-1"))
+	if err != nil || id.Counter != 1 {
+		t.Fatalf("Next with call options = %+v, %v; want counter 1", id, err)
+	}
+	if _, err := b.NextBatch(ctx, "SG", 3); err != nil {
+		t.Fatalf("NextBatch: %v", err)
+	}
+	if st, err := b.Status(ctx, "SG"); err != nil || st.CurrentCounter != 4 {
+		t.Fatalf("Status = %+v, %v; want counter 4", st, err)
+	}
+	if n := b.Len("SG"); n != 0 {
+		t.Errorf("Len(SG) = %d, want 0", n)
+	}
+
+	// After Close, Next goes straight to the wrapped client
+	b.Close()
+	id, err = b.Next(ctx, "SG")
+	if err != nil || id.Counter != 5 {
+		t.Fatalf("Next after Close = %+v, %v; want counter 5", id, err)
+	}
+
+	var methods []string
+	for _, call := range fake.Calls() {
+		methods = append(methods, call.Method)
+	}
+	want := []string{"Next", "NextBatch", "Status", "Next"}
+	if len(methods) != len(want) {
+		t.Fatalf("calls = %v, want %v", methods, want)
+	}
+	for i := range want {
+		if methods[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", methods, want)
+		}
+	}
+}
+
+func TestBufferedCloseDiscardsBuffers(t *testing.T) {
+	b := client.NewBuffered(clienttest.NewFake("SG"), client.BufferConfig{BatchSize: 10, LowWater: 9})
+
+	// The first Next leaves 9 IDs and starts a refill that Close waits for
+	if _, err := b.Next(context.Background(), "SG"); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	b.Close()
+	if n := b.Len("SG"); n != 0 {
+		t.Errorf("Len(SG) after Close = %d, want 0", n)
+	}
+}
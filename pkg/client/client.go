@@ -0,0 +1,181 @@
+// Package client is the Go SDK of the sequential ID counter service.
+//
+// Client is implemented over REST (NewREST) and gRPC (NewGRPC) with the
+// same semantics: calls that fail with a retryable error are retried under
+// one idempotency key, so a retry never issues a second ID. Buffered adds
+// local prefetching of batches on top of any Client, and the clienttest
+// package provides an in-memory fake for unit tests.
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client issues sequential IDs
+type Client interface {
+	// Next issues the next ID of a prefix
+	Next(ctx context.Context, prefix string, opts ...CallOption) (*ID, error)
+	// NextBatch issues count consecutive IDs of a prefix
+	NextBatch(ctx context.Context, prefix string, count int, opts ...CallOption) (*Batch, error)
+	// Status reports the counter of a prefix and the health of its stores
+	Status(ctx context.Context, prefix string) (*Status, error)
+}
+
+// MaxBatchSize is the largest batch the service issues in one call
+const MaxBatchSize = 1000
+
+// ID is an issued sequential ID
+type ID struct {
+	FullNumber  string
+	Prefix      string
+	Counter     int64
+	PeriodKey   string
+	GeneratedAt time.Time
+}
+
+// Batch is a run of consecutive IDs issued together
+type Batch struct {
+	BatchID     string
+	IDs         []ID
+	GeneratedAt time.Time
+}
+
+// Status is the state of a prefix's counter
+type Status struct {
+	Prefix          string
+	PeriodKey       string
+	CurrentCounter  int64
+	NextCounter     int64
+	CounterHealthy  bool
+	QueueHealthy    bool
+	DatabaseHealthy bool
+}
+
+// Defaults applied by NewREST and NewGRPC
+const (
+	// DefaultTimeout bounds calls whose context has no deadline
+	DefaultTimeout = 10 * time.Second
+)
+
+// options holds the settings shared by the transports
+type options struct {
+	apiKey      string
+	bearerToken string
+	clientID    string
+	timeout     time.Duration
+	retry       RetryPolicy
+	httpClient  *http.Client
+}
+
+func defaultOptions() options {
+	return options{
+		timeout: DefaultTimeout,
+		retry:   DefaultRetryPolicy,
+	}
+}
+
+// Option configures a client
+type Option func(*options)
+
+// WithAPIKey authenticates calls with an API key
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.apiKey = key }
+}
+
+// WithBearerToken authenticates calls with a JWT
+func WithBearerToken(token string) Option {
+	return func(o *options) { o.bearerToken = token }
+}
+
+// WithClientID sets the client ID recorded in the audit log of every ID
+func WithClientID(id string) Option {
+	return func(o *options) { o.clientID = id }
+}
+
+// WithTimeout bounds calls whose context has no deadline; zero disables
+// the bound
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) { o.retry = p }
+}
+
+// WithHTTPClient replaces http.DefaultClient for REST calls; gRPC clients
+// ignore it
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) { o.httpClient = hc }
+}
+
+// withDeadline applies the default timeout to contexts without a deadline
+func (o *options) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// CallOptions are the per-call settings of Next and NextBatch
+type CallOptions struct {
+	IdempotencyKey string
+	GeneratedBy    string
+	CorrelationID  string
+}
+
+// CallOption configures a single call
+type CallOption func(*CallOptions)
+
+// WithIdempotencyKey sets the idempotency key of a call. Without one, a
+// fresh key is generated per call and reused by its retries.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *CallOptions) { o.IdempotencyKey = key }
+}
+
+// WithGeneratedBy records the user or system the ID is generated for
+func WithGeneratedBy(generatedBy string) CallOption {
+	return func(o *CallOptions) { o.GeneratedBy = generatedBy }
+}
+
+// WithCorrelationID records a correlation ID in the audit log
+func WithCorrelationID(id string) CallOption {
+	return func(o *CallOptions) { o.CorrelationID = id }
+}
+
+// ApplyCallOptions resolves opts, for Client implementations outside this
+// package such as clienttest.Fake
+func ApplyCallOptions(opts ...CallOption) CallOptions {
+	var o CallOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newCallOptions resolves opts and generates the idempotency key shared by
+// the attempts of a call when none is set
+func newCallOptions(opts []CallOption) CallOptions {
+	o := ApplyCallOptions(opts...)
+	if o.IdempotencyKey == "" {
+		o.IdempotencyKey = uuid.New().String()
+	}
+	return o
+}
+
+// invoke runs a call under the default timeout and the retry policy
+func (o *options) invoke(ctx context.Context, prefix string, attempt func(ctx context.Context) error) error {
+	if prefix == "" {
+		return &Error{Kind: KindInvalidArgument, Code: codeInvalidArgument, Message: "prefix is required", Field: "prefix"}
+	}
+	ctx, cancel := o.withDeadline(ctx)
+	defer cancel()
+	return o.retry.do(ctx, attempt)
+}
+
+// codeInvalidArgument is the code of malformed request fields
+const codeInvalidArgument = "INVALID_ARGUMENT"
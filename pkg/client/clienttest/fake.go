@@ -0,0 +1,243 @@
+// Package clienttest provides an in-memory client.Client for unit tests of
+// code that issues sequential IDs.
+package clienttest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/putram11/sequential-id-counter-service/pkg/client"
+)
+
+// Fake is an in-memory client.Client. Each configured prefix has a counter
+// that starts at 0, so the first ID is 1, and IDs render as the prefix
+// followed by the counter padded to six digits, like the service's default
+// format. Unknown prefixes fail with NotFound PREFIX_NOT_CONFIGURED.
+//
+// Calls with an idempotency key replay the result of the first call with
+// that key and fail with Unprocessable IDEMPOTENCY_KEY_REUSED when the key
+// is reused for another request, as the service does. A Fake is safe for
+// concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	replays  map[string]replay
+	failures []error
+	calls    []Call
+	now      func() time.Time
+}
+
+var _ client.Client = (*Fake)(nil)
+
+// Call records a call made to a Fake
+type Call struct {
+	Method  string // "Next", "NextBatch" or "Status"
+	Prefix  string
+	Count   int
+	Options client.CallOptions
+}
+
+// replay is the stored result of an idempotent call
+type replay struct {
+	call  Call
+	id    *client.ID
+	batch *client.Batch
+}
+
+// NewFake creates a Fake with the given prefixes configured
+func NewFake(prefixes ...string) *Fake {
+	f := &Fake{
+		counters: make(map[string]int64),
+		replays:  make(map[string]replay),
+		now:      time.Now,
+	}
+	for _, prefix := range prefixes {
+		f.counters[prefix] = 0
+	}
+	return f
+}
+
+// AddPrefix configures a prefix; its counter is left as is when it already
+// exists
+func (f *Fake) AddPrefix(prefix string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.counters[prefix]; !ok {
+		f.counters[prefix] = 0
+	}
+}
+
+// SetCounter sets the last issued counter of a prefix, configuring it if
+// needed
+func (f *Fake) SetCounter(prefix string, counter int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counters[prefix] = counter
+}
+
+// SetClock replaces time.Now as the source of GeneratedAt
+func (f *Fake) SetClock(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// FailNext makes the next calls fail with errs, one error per call in
+// order, before any other check. Use client errors such as
+// &client.Error{Kind: client.KindUnavailable} to exercise error handling.
+func (f *Fake) FailNext(errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, errs...)
+}
+
+// Calls returns the calls made so far, including failed ones
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Next issues the next ID of a prefix
+func (f *Fake) Next(ctx context.Context, prefix string, opts ...client.CallOption) (*client.ID, error) {
+	call := Call{Method: "Next", Prefix: prefix, Options: client.ApplyCallOptions(opts...)}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, call); err != nil {
+		return nil, err
+	}
+	if r, ok, err := f.replay(call); ok || err != nil {
+		return r.id, err
+	}
+
+	ids := f.issue(prefix, 1)
+	f.remember(call, replay{id: &ids[0]})
+	return &ids[0], nil
+}
+
+// NextBatch issues count consecutive IDs of a prefix
+func (f *Fake) NextBatch(ctx context.Context, prefix string, count int, opts ...client.CallOption) (*client.Batch, error) {
+	call := Call{Method: "NextBatch", Prefix: prefix, Count: count, Options: client.ApplyCallOptions(opts...)}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, call); err != nil {
+		return nil, err
+	}
+	if count < 1 || count > client.MaxBatchSize {
+		return nil, &client.Error{
+			Kind:    client.KindInvalidArgument,
+			Code:    "INVALID_COUNT",
+			Message: fmt.Sprintf("invalid count: must be between 1 and %d", client.MaxBatchSize),
+		}
+	}
+	if r, ok, err := f.replay(call); ok || err != nil {
+		return r.batch, err
+	}
+
+	ids := f.issue(prefix, count)
+	batch := &client.Batch{
+		BatchID:     uuid.New().String(),
+		IDs:         ids,
+		GeneratedAt: ids[0].GeneratedAt,
+	}
+	f.remember(call, replay{batch: batch})
+	return batch, nil
+}
+
+// Status reports the counter of a prefix; the stores are always healthy
+func (f *Fake) Status(ctx context.Context, prefix string) (*client.Status, error) {
+	call := Call{Method: "Status", Prefix: prefix}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, call); err != nil {
+		return nil, err
+	}
+	counter := f.counters[prefix]
+	return &client.Status{
+		Prefix:          prefix,
+		CurrentCounter:  counter,
+		NextCounter:     counter + 1,
+		CounterHealthy:  true,
+		QueueHealthy:    true,
+		DatabaseHealthy: true,
+	}, nil
+}
+
+// begin records a call and runs the checks shared by every method; f.mu
+// must be held
+func (f *Fake) begin(ctx context.Context, call Call) error {
+	f.calls = append(f.calls, call)
+
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return &client.Error{Kind: client.KindUnavailable, Message: "request failed", Err: err}
+	}
+	if call.Prefix == "" {
+		return &client.Error{Kind: client.KindInvalidArgument, Code: "INVALID_ARGUMENT", Message: "prefix is required", Field: "prefix"}
+	}
+	if _, ok := f.counters[call.Prefix]; !ok {
+		return &client.Error{
+			Kind:    client.KindNotFound,
+			Code:    "PREFIX_NOT_CONFIGURED",
+			Message: fmt.Sprintf("prefix not found: %s", call.Prefix),
+		}
+	}
+	return nil
+}
+
+// replay returns the stored result of an earlier call with the same
+// idempotency key; f.mu must be held
+func (f *Fake) replay(call Call) (replay, bool, error) {
+	key := call.Options.IdempotencyKey
+	if key == "" {
+		return replay{}, false, nil
+	}
+	r, ok := f.replays[key]
+	if !ok {
+		return replay{}, false, nil
+	}
+	if r.call != call {
+		return replay{}, false, &client.Error{
+			Kind:    client.KindUnprocessable,
+			Code:    "IDEMPOTENCY_KEY_REUSED",
+			Message: "idempotency key reused with different parameters",
+		}
+	}
+	return r, true, nil
+}
+
+// remember stores the result of an idempotent call; f.mu must be held
+func (f *Fake) remember(call Call, r replay) {
+	if call.Options.IdempotencyKey != "" {
+		r.call = call
+		f.replays[call.Options.IdempotencyKey] = r
+	}
+}
+
+// issue advances the counter of a prefix by count; f.mu must be held
+func (f *Fake) issue(prefix string, count int) []client.ID {
+	generatedAt := f.now()
+	ids := make([]client.ID, count)
+	for i := range ids {
+		f.counters[prefix]++
+		counter := f.counters[prefix]
+		ids[i] = client.ID{
+			FullNumber:  fmt.Sprintf("%s%06d", prefix, counter),
+			Prefix:      prefix,
+			Counter:     counter,
+			GeneratedAt: generatedAt,
+		}
+	}
+	return ids
+}
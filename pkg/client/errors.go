@@ -0,0 +1,216 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// Kind classifies errors of the service the same way over every transport
+type Kind int
+
+// Error kinds
+const (
+	// KindInternal marks unexpected failures of the service
+	KindInternal Kind = iota
+	// KindInvalidArgument marks malformed or out-of-range requests
+	KindInvalidArgument
+	// KindNotFound marks unknown prefixes
+	KindNotFound
+	// KindConflict marks requests that conflict with the state of the prefix
+	KindConflict
+	// KindExpired marks resources that can no longer be used
+	KindExpired
+	// KindUnprocessable marks an idempotency key reused for another request
+	KindUnprocessable
+	// KindAborted marks requests blocked by a concurrent one; retried
+	KindAborted
+	// KindUnavailable marks an unreachable service or store; retried
+	KindUnavailable
+	// KindPermissionDenied marks callers lacking a role or prefix scope
+	KindPermissionDenied
+	// KindUnauthenticated marks missing or invalid credentials
+	KindUnauthenticated
+)
+
+var kindNames = map[Kind]string{
+	KindInternal:         "internal",
+	KindInvalidArgument:  "invalid argument",
+	KindNotFound:         "not found",
+	KindConflict:         "conflict",
+	KindExpired:          "expired",
+	KindUnprocessable:    "unprocessable",
+	KindAborted:          "aborted",
+	KindUnavailable:      "unavailable",
+	KindPermissionDenied: "permission denied",
+	KindUnauthenticated:  "unauthenticated",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Error is a failed call. Code is the service's machine-readable error code
+// (such as PREFIX_NOT_CONFIGURED), empty when the service was not reached.
+//
+// errors.Is matches an Error against the kind sentinels below, so callers
+// can branch on errors.Is(err, client.ErrNotFound) regardless of transport.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	// Field names the offending request field of INVALID_ARGUMENT errors
+	Field string
+	// Err is the transport error of calls that did not reach the service
+	Err error
+}
+
+// Sentinels matching every Error of a kind
+var (
+	ErrInternal         = &Error{Kind: KindInternal}
+	ErrInvalidArgument  = &Error{Kind: KindInvalidArgument}
+	ErrNotFound         = &Error{Kind: KindNotFound}
+	ErrConflict         = &Error{Kind: KindConflict}
+	ErrExpired          = &Error{Kind: KindExpired}
+	ErrUnprocessable    = &Error{Kind: KindUnprocessable}
+	ErrAborted          = &Error{Kind: KindAborted}
+	ErrUnavailable      = &Error{Kind: KindUnavailable}
+	ErrPermissionDenied = &Error{Kind: KindPermissionDenied}
+	ErrUnauthenticated  = &Error{Kind: KindUnauthenticated}
+)
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel of e's kind
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == "" && t.Message == "" && t.Err == nil && t.Kind == e.Kind
+}
+
+// Temporary reports whether retrying the call may succeed
+func (e *Error) Temporary() bool {
+	return e.Kind == KindUnavailable || e.Kind == KindAborted
+}
+
+// IsRetryable reports whether err is an Error that a retry may resolve
+func IsRetryable(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Temporary()
+}
+
+// httpKinds maps the HTTP statuses of the REST API to error kinds.
+// 409 is both Conflict and Aborted; the code tells them apart.
+var httpKinds = map[int]Kind{
+	http.StatusBadRequest:          KindInvalidArgument,
+	http.StatusUnauthorized:        KindUnauthenticated,
+	http.StatusForbidden:           KindPermissionDenied,
+	http.StatusNotFound:            KindNotFound,
+	http.StatusConflict:            KindConflict,
+	http.StatusGone:                KindExpired,
+	http.StatusUnprocessableEntity: KindUnprocessable,
+	http.StatusTooManyRequests:     KindUnavailable,
+	http.StatusBadGateway:          KindUnavailable,
+	http.StatusServiceUnavailable:  KindUnavailable,
+	http.StatusGatewayTimeout:      KindUnavailable,
+}
+
+// abortedCodes are the codes of retryable conflicts
+var abortedCodes = map[string]bool{
+	"IDEMPOTENCY_KEY_IN_PROGRESS": true,
+}
+
+// fromHTTP builds the error of a failed REST response; body is nil when the
+// response carried no error envelope
+func fromHTTP(statusCode int, body *models.ErrorResponse) *Error {
+	kind, ok := httpKinds[statusCode]
+	if !ok {
+		kind = KindInternal
+	}
+	e := &Error{Kind: kind}
+	if body == nil {
+		e.Message = http.StatusText(statusCode)
+		return e
+	}
+	e.Code, e.Message, e.Field = body.Code, body.Error, body.Field
+	if kind == KindConflict && abortedCodes[e.Code] {
+		e.Kind = KindAborted
+	}
+	return e
+}
+
+// grpcKinds maps gRPC status codes to error kinds. FailedPrecondition is
+// refined by the ErrorInfo reason in fromGRPC.
+var grpcKinds = map[codes.Code]Kind{
+	codes.InvalidArgument:    KindInvalidArgument,
+	codes.OutOfRange:         KindInvalidArgument,
+	codes.NotFound:           KindNotFound,
+	codes.AlreadyExists:      KindConflict,
+	codes.FailedPrecondition: KindConflict,
+	codes.Aborted:            KindAborted,
+	codes.Unavailable:        KindUnavailable,
+	codes.ResourceExhausted:  KindUnavailable,
+	codes.PermissionDenied:   KindPermissionDenied,
+	codes.Unauthenticated:    KindUnauthenticated,
+}
+
+// preconditionKinds refines FailedPrecondition, which the service returns
+// for conflicts, expired resources and reused idempotency keys
+var preconditionKinds = map[string]Kind{
+	"RESERVATION_EXPIRED":    KindExpired,
+	"IDEMPOTENCY_KEY_REUSED": KindUnprocessable,
+}
+
+// fromGRPC builds the error of a failed gRPC call from its status and
+// ErrorInfo and BadRequest details
+func fromGRPC(err error) *Error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return &Error{Kind: KindUnavailable, Err: err}
+	}
+	kind, ok := grpcKinds[st.Code()]
+	if !ok {
+		kind = KindInternal
+	}
+	e := &Error{Kind: kind, Message: st.Message()}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.Code = d.GetReason()
+		case *errdetails.BadRequest:
+			if violations := d.GetFieldViolations(); len(violations) > 0 {
+				e.Field = violations[0].GetField()
+			}
+		}
+	}
+	if st.Code() == codes.FailedPrecondition {
+		if refined, ok := preconditionKinds[e.Code]; ok {
+			e.Kind = refined
+		}
+	}
+	return e
+}
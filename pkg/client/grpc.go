@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/putram11/sequential-id-counter-service/api/proto"
+)
+
+// GRPCClient calls the gRPC API of the service
+type GRPCClient struct {
+	client pb.SequentialIDServiceClient
+	opts   options
+}
+
+var _ Client = (*GRPCClient)(nil)
+
+// NewGRPC creates a client of the gRPC API over conn. The caller owns conn
+// and closes it when done.
+func NewGRPC(conn grpc.ClientConnInterface, opts ...Option) *GRPCClient {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &GRPCClient{
+		client: pb.NewSequentialIDServiceClient(conn),
+		opts:   o,
+	}
+}
+
+// Next issues the next ID of a prefix
+func (c *GRPCClient) Next(ctx context.Context, prefix string, opts ...CallOption) (*ID, error) {
+	co := newCallOptions(opts)
+	req := &pb.GetNextRequest{
+		Prefix:         prefix,
+		ClientId:       c.opts.clientID,
+		CorrelationId:  co.CorrelationID,
+		IdempotencyKey: co.IdempotencyKey,
+		GeneratedBy:    co.GeneratedBy,
+	}
+
+	var id *ID
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		resp, err := c.client.GetNext(c.outgoing(ctx), req)
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		id = &ID{
+			FullNumber:  resp.GetFullNumber(),
+			Prefix:      resp.GetPrefix(),
+			Counter:     resp.GetCounter(),
+			PeriodKey:   resp.GetPeriodKey(),
+			GeneratedAt: resp.GetGeneratedAt().AsTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// NextBatch issues count consecutive IDs of a prefix
+func (c *GRPCClient) NextBatch(ctx context.Context, prefix string, count int, opts ...CallOption) (*Batch, error) {
+	co := newCallOptions(opts)
+	req := &pb.GetNextBatchRequest{
+		Prefix:         prefix,
+		Count:          int32(count),
+		ClientId:       c.opts.clientID,
+		CorrelationId:  co.CorrelationID,
+		IdempotencyKey: co.IdempotencyKey,
+		GeneratedBy:    co.GeneratedBy,
+	}
+	if int(req.Count) != count {
+		return nil, &Error{Kind: KindInvalidArgument, Code: codeInvalidArgument, Message: "count is out of range", Field: "count"}
+	}
+
+	var batch *Batch
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		resp, err := c.client.GetNextBatch(c.outgoing(ctx), req)
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		generatedAt := resp.GetGeneratedAt().AsTime()
+		batch = &Batch{
+			BatchID:     resp.GetBatchId(),
+			IDs:         make([]ID, len(resp.GetFullNumbers())),
+			GeneratedAt: generatedAt,
+		}
+		// The IDs of a batch are consecutive, so counters follow from the start
+		for i, fullNumber := range resp.GetFullNumbers() {
+			batch.IDs[i] = ID{
+				FullNumber:  fullNumber,
+				Prefix:      resp.GetPrefix(),
+				Counter:     resp.GetStartCounter() + int64(i),
+				PeriodKey:   resp.GetPeriodKey(),
+				GeneratedAt: generatedAt,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Status reports the counter of a prefix and the health of its stores
+func (c *GRPCClient) Status(ctx context.Context, prefix string) (*Status, error) {
+	var st *Status
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		resp, err := c.client.GetStatus(c.outgoing(ctx), &pb.GetStatusRequest{Prefix: prefix})
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		st = &Status{
+			Prefix:          resp.GetPrefix(),
+			PeriodKey:       resp.GetPeriodKey(),
+			CurrentCounter:  resp.GetCurrentCounter(),
+			NextCounter:     resp.GetNextCounter(),
+			CounterHealthy:  resp.GetRedisHealthy(),
+			QueueHealthy:    resp.GetQueueHealthy(),
+			DatabaseHealthy: resp.GetDatabaseHealthy(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// outgoing attaches the credentials to the call's metadata
+func (c *GRPCClient) outgoing(ctx context.Context) context.Context {
+	switch {
+	case c.opts.bearerToken != "":
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.opts.bearerToken)
+	case c.opts.apiKey != "":
+		return metadata.AppendToOutgoingContext(ctx, "x-api-key", c.opts.apiKey)
+	default:
+		return ctx
+	}
+}
+
+// grpcError converts the error of a call. Calls cut short by their context
+// are Unavailable errors wrapping the context's error.
+func grpcError(ctx context.Context, err error) error {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Canceled:
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return &Error{Kind: KindUnavailable, Message: "request failed", Err: ctxErr}
+		}
+		return &Error{Kind: KindUnavailable, Message: "request failed", Err: err}
+	}
+	return fromGRPC(err)
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/putram11/sequential-id-counter-service/internal/models"
+)
+
+// idempotencyKeyHeader carries the idempotency key of REST calls
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxErrorBody bounds the error envelopes read from failed responses
+const maxErrorBody = 64 << 10
+
+// RESTClient calls the REST API of the service
+type RESTClient struct {
+	baseURL    string
+	httpClient *http.Client
+	opts       options
+}
+
+var _ Client = (*RESTClient)(nil)
+
+// NewREST creates a client of the REST API at baseURL, e.g.
+// http://localhost:8080
+func NewREST(baseURL string, opts ...Option) (*RESTClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &RESTClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		opts:       o,
+	}, nil
+}
+
+// Next issues the next ID of a prefix
+func (c *RESTClient) Next(ctx context.Context, prefix string, opts ...CallOption) (*ID, error) {
+	co := newCallOptions(opts)
+	query := url.Values{}
+	setQuery(query, "client_id", c.opts.clientID)
+	setQuery(query, "generated_by", co.GeneratedBy)
+	setQuery(query, "correlation_id", co.CorrelationID)
+
+	var id *ID
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		var resp models.SequentialID
+		if err := c.do(ctx, http.MethodGet, "/api/v1/next/"+url.PathEscape(prefix), query, nil, co.IdempotencyKey, &resp); err != nil {
+			return err
+		}
+		id = fromSequentialID(&resp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// NextBatch issues count consecutive IDs of a prefix
+func (c *RESTClient) NextBatch(ctx context.Context, prefix string, count int, opts ...CallOption) (*Batch, error) {
+	co := newCallOptions(opts)
+	body := &models.BatchRequest{
+		Count:         count,
+		ClientID:      c.opts.clientID,
+		GeneratedBy:   co.GeneratedBy,
+		CorrelationID: co.CorrelationID,
+	}
+
+	var batch *Batch
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		var resp models.BatchResponse
+		if err := c.do(ctx, http.MethodPost, "/api/v1/batch/"+url.PathEscape(prefix), nil, body, co.IdempotencyKey, &resp); err != nil {
+			return err
+		}
+		batch = &Batch{
+			BatchID:     resp.BatchID,
+			IDs:         make([]ID, len(resp.IDs)),
+			GeneratedAt: resp.GeneratedAt,
+		}
+		for i := range resp.IDs {
+			batch.IDs[i] = *fromSequentialID(&resp.IDs[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Status reports the counter of a prefix and the health of its stores
+func (c *RESTClient) Status(ctx context.Context, prefix string) (*Status, error) {
+	var st *Status
+	err := c.opts.invoke(ctx, prefix, func(ctx context.Context) error {
+		var resp models.CounterStatus
+		if err := c.do(ctx, http.MethodGet, "/api/v1/status/"+url.PathEscape(prefix), nil, nil, "", &resp); err != nil {
+			return err
+		}
+		st = &Status{
+			Prefix:          resp.Prefix,
+			PeriodKey:       resp.PeriodKey,
+			CurrentCounter:  resp.CurrentCounter,
+			NextCounter:     resp.NextCounter,
+			CounterHealthy:  resp.RedisHealthy,
+			QueueHealthy:    resp.QueueHealthy,
+			DatabaseHealthy: resp.DatabaseHealthy,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// do sends one request and decodes its JSON response into out. Requests
+// that fail before a response is received are Unavailable errors wrapping
+// the transport error.
+func (c *RESTClient) do(ctx context.Context, method, path string, query url.Values, body interface{}, idempotencyKey string, out interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return &Error{Kind: KindInternal, Message: "failed to encode request", Err: err}
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return &Error{Kind: KindInternal, Message: "failed to build request", Err: err}
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	switch {
+	case c.opts.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.opts.bearerToken)
+	case c.opts.apiKey != "":
+		req.Header.Set("X-API-Key", c.opts.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &Error{Kind: KindUnavailable, Message: "request failed", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var envelope models.ErrorResponse
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if json.Unmarshal(data, &envelope) != nil || envelope.Code == "" {
+			return fromHTTP(resp.StatusCode, nil)
+		}
+		return fromHTTP(resp.StatusCode, &envelope)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &Error{Kind: KindUnavailable, Message: "failed to decode response", Err: err}
+	}
+	return nil
+}
+
+// setQuery sets a query parameter unless the value is empty
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func fromSequentialID(id *models.SequentialID) *ID {
+	return &ID{
+		FullNumber:  id.FullNumber,
+		Prefix:      id.Prefix,
+		Counter:     id.Counter,
+		PeriodKey:   id.PeriodKey,
+		GeneratedAt: id.GeneratedAt,
+	}
+}
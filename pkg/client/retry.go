@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how calls that fail with a retryable error are
+// retried. Unavailable and Aborted errors, transport failures and attempts
+// cut short by AttemptTimeout are retried; every other error is returned at
+// once. Retries of Next and NextBatch reuse the idempotency key of the
+// first attempt, so the service replays the IDs of an attempt whose
+// response was lost instead of issuing new ones.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per call, including the first;
+	// values below 1 are treated as 1
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles after
+	// every attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+	// AttemptTimeout bounds each attempt within the call's deadline; zero
+	// leaves attempts bounded by the call's deadline only
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy retries up to three times with backoff from 100ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	AttemptTimeout: 3 * time.Second,
+}
+
+// NoRetry disables retries
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// do runs attempt until it succeeds, fails with an error that is not
+// retryable, the attempts are exhausted or ctx is done, and returns the
+// error of the last attempt. Waits between attempts are jittered by up to
+// half the backoff.
+func (p RetryPolicy) do(ctx context.Context, attempt func(ctx context.Context) error) error {
+	backoff := p.InitialBackoff
+	for n := 1; ; n++ {
+		err := p.try(ctx, attempt)
+		if err == nil || n >= p.MaxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := backoff
+		if wait > 0 {
+			wait -= time.Duration(rand.Int63n(int64(wait)/2 + 1))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// try runs one attempt within AttemptTimeout. An attempt that runs out of
+// its own time while the call has time left is reported as Unavailable so
+// it is retried.
+func (p RetryPolicy) try(ctx context.Context, attempt func(ctx context.Context) error) error {
+	if p.AttemptTimeout <= 0 {
+		return attempt(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+
+	err := attempt(attemptCtx)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &Error{Kind: KindUnavailable, Message: "attempt timed out", Err: err}
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyAttempts(t *testing.T) {
+	unavailable := &Error{Kind: KindUnavailable, Code: "COUNTER_STORE_UNAVAILABLE"}
+	aborted := &Error{Kind: KindAborted, Code: "IDEMPOTENCY_KEY_IN_PROGRESS"}
+	notFound := &Error{Kind: KindNotFound, Code: "PREFIX_NOT_CONFIGURED"}
+	boom := errors.New("boom")
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		errs         []error // returned by successive attempts; nil after the last
+		wantAttempts int
+		want         error
+	}{
+		{"success", 3, nil, 1, nil},
+		{"recovers", 3, []error{unavailable, aborted}, 3, nil},
+		{"exhausted", 3, []error{unavailable, unavailable, unavailable, unavailable}, 3, unavailable},
+		{"not retryable", 3, []error{notFound}, 1, notFound},
+		{"stops at non-retryable", 3, []error{unavailable, notFound}, 2, notFound},
+		{"plain errors are not retried", 3, []error{boom}, 1, boom},
+		{"no retry", 1, []error{unavailable}, 1, unavailable},
+		{"zero attempts runs once", 0, []error{unavailable}, 1, unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := RetryPolicy{MaxAttempts: tt.maxAttempts, InitialBackoff: time.Millisecond}
+			attempts := 0
+			err := p.do(context.Background(), func(context.Context) error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			})
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if err != tt.want {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, InitialBackoff: 20 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+	// Waits are jittered down by at most half: 20ms, then 40ms capped
+	minWaits := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond}
+	maxWait := 40 * time.Millisecond
+
+	var starts []time.Time
+	err := p.do(context.Background(), func(context.Context) error {
+		starts = append(starts, time.Now())
+		return ErrUnavailable
+	})
+	if !errors.Is(err, ErrUnavailable) || len(starts) != 5 {
+		t.Fatalf("do = %v after %d attempts, want Unavailable after 5", err, len(starts))
+	}
+	for i, least := range minWaits {
+		wait := starts[i+1].Sub(starts[i])
+		if wait < least {
+			t.Errorf("wait before attempt %d = %v, want at least %v", i+2, wait, least)
+		}
+		// Generous upper bound; timers may fire late on a loaded machine
+		if wait > maxWait+200*time.Millisecond {
+			t.Errorf("wait before attempt %d = %v, want about %v at most", i+2, wait, maxWait)
+		}
+	}
+}
+
+func TestRetryPolicyStopsWhenContextIsDone(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	err := p.do(ctx, func(context.Context) error {
+		attempts++
+		return ErrUnavailable
+	})
+	if !errors.Is(err, ErrUnavailable) || attempts != 1 {
+		t.Errorf("do = %v after %d attempts, want Unavailable after 1", err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("do returned after %v, want as soon as the context is done", elapsed)
+	}
+
+	attempts = 0
+	err = p.do(ctx, func(ctx context.Context) error {
+		attempts++
+		return &Error{Kind: KindUnavailable, Message: "request failed", Err: ctx.Err()}
+	})
+	if attempts != 1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("do on an expired context = %v after %d attempts, want the attempt's error after 1", err, attempts)
+	}
+}
+
+func TestRetryPolicyAttemptTimeout(t *testing.T) {
+	block := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("attempt times out", func(t *testing.T) {
+		p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, AttemptTimeout: 10 * time.Millisecond}
+		attempts := 0
+		err := p.do(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return block(ctx)
+		})
+		var e *Error
+		if !errors.As(err, &e) || e.Kind != KindUnavailable || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("error = %v, want Unavailable wrapping the attempt's deadline", err)
+		}
+		if attempts != 3 {
+			t.Errorf("attempts = %d, want 3", attempts)
+		}
+	})
+
+	t.Run("call times out", func(t *testing.T) {
+		p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, AttemptTimeout: time.Hour}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		attempts := 0
+		err := p.do(ctx, func(ctx context.Context) error {
+			attempts++
+			return block(ctx)
+		})
+		if err != context.DeadlineExceeded || attempts != 1 {
+			t.Errorf("do = %v after %d attempts, want the call's deadline after 1", err, attempts)
+		}
+	})
+
+	t.Run("attempt within its time", func(t *testing.T) {
+		p := RetryPolicy{MaxAttempts: 3, AttemptTimeout: time.Hour}
+		err := p.do(context.Background(), func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("attempt has no deadline")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("do = %v, want nil", err)
+		}
+	})
+}